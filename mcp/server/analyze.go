@@ -0,0 +1,501 @@
+package server
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gmeghnag/omc/cmd/etcd"
+	"github.com/gmeghnag/omc/cmd/events"
+	"github.com/gmeghnag/omc/cmd/helpers"
+	"github.com/gmeghnag/omc/cmd/logs"
+	"github.com/gmeghnag/omc/cmd/prometheus"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// maxEventHotspots is the number of objects listed in the warning
+	// events section.
+	maxEventHotspots = 10
+	// maxErrorLines is the number of error lines kept for each node service.
+	maxErrorLines = 20
+)
+
+// errorLine matches the log lines reporting an error, including the klog
+// error and fatal prefixes.
+var errorLine = regexp.MustCompile(`(?i)\b(error|failed|fatal|panic)\b|\s[EF]\d{4} \d{2}:\d{2}`)
+
+// analysisSection is a part of a health summary, it holds a table or lines of
+// findings, or the error which prevented computing it.
+type analysisSection struct {
+	Title   string     `json:"title"`
+	Headers []string   `json:"headers,omitempty"`
+	Rows    [][]string `json:"rows,omitempty"`
+	Lines   []string   `json:"lines,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
+
+// analyzer computes a section of a health summary from the must-gather root,
+// or from the output of the omc commands run by omc.
+type analyzer struct {
+	title   string
+	analyze func(omc omcRunner, root string, section *analysisSection) error
+}
+
+// omcRunner runs an omc command against the must-gather of the request and
+// returns its output.
+type omcRunner func(args ...string) (string, error)
+
+// Register the analysis tools
+func (s *Server) initAnalyze() []server.ServerTool {
+	return []server.ServerTool{
+		{Tool: mcp.NewTool("mustgather_analyze",
+			mcp.WithDescription("Summarize the health of the cluster: pods not running, degraded cluster operators, nodes not ready, firing alerts, etcd health and the objects with the most warning events"),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_analyze{}")
+
+			root, err := s.mustGatherRoot(ctx)
+			if err != nil {
+				return NewTextResult("", err), nil
+			}
+			omc := func(args ...string) (string, error) {
+				return s.executeOMCCommand(ctx, args)
+			}
+			sections := runAnalyzers(omc, root, []analyzer{
+				{title: "Pods not running", analyze: analyzePods},
+				{title: "Cluster operators not healthy", analyze: analyzeClusterOperators},
+				{title: "Nodes not ready", analyze: analyzeNodes},
+				{title: "Firing alerts", analyze: analyzeAlerts},
+				{title: "Etcd health", analyze: analyzeEtcd},
+				{title: "Warning events hotspots", analyze: analyzeEvents},
+			})
+			return sectionsResult(formatArgument(ctr), sections), nil
+		}},
+
+		{Tool: mcp.NewTool("mustgather_analyze_node_logs",
+			mcp.WithDescription("Count the error lines in the node service logs (kubelet, crio, NetworkManager...) and show the last ones of each service"),
+			mcp.WithString("services", mcp.Description("Comma separated list of services to analyze, all of them by default")),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_analyze_node_logs{}")
+
+			root, err := s.mustGatherRoot(ctx)
+			if err != nil {
+				return NewTextResult("", err), nil
+			}
+			var services []string
+			if list, ok := ctr.GetArguments()["services"].(string); ok && list != "" {
+				services = strings.Split(list, ",")
+			}
+			sections, err := analyzeNodeLogs(root, services)
+			if err != nil {
+				return NewTextResult("", err), nil
+			}
+			return sectionsResult(formatArgument(ctr), sections), nil
+		}},
+	}
+}
+
+// runAnalyzers computes the sections of a health summary, an analyzer failing
+// is reported in its section without affecting the others.
+func runAnalyzers(omc omcRunner, root string, analyzers []analyzer) []analysisSection {
+	sections := make([]analysisSection, 0, len(analyzers))
+	for _, a := range analyzers {
+		section := analysisSection{Title: a.title}
+		if err := runAnalyzer(omc, root, a, &section); err != nil {
+			section = analysisSection{Title: a.title, Error: err.Error()}
+		}
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+func runAnalyzer(omc omcRunner, root string, a analyzer, section *analysisSection) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("analysis failed: %v", r)
+		}
+	}()
+	return a.analyze(omc, root, section)
+}
+
+// sectionsResult returns the result of an analysis tool in the requested
+// format.
+func sectionsResult(format string, sections []analysisSection) *mcp.CallToolResult {
+	return NewFormattedResult(format, func() (string, error) {
+		return renderSections(sections), nil
+	}, func() (any, error) {
+		return map[string]any{"sections": sections}, nil
+	})
+}
+
+// renderSections returns the sections as text, with the table and the lines
+// of each section.
+func renderSections(sections []analysisSection) string {
+	var sb strings.Builder
+	for i, section := range sections {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "== %s ==\n", section.Title)
+		if section.Error != "" {
+			fmt.Fprintf(&sb, "error: %s\n", section.Error)
+			continue
+		}
+		if len(section.Rows) > 0 {
+			helpers.FprintTable(&sb, section.Headers, section.Rows)
+		}
+		if len(section.Lines) > 0 {
+			sb.WriteString(strings.Join(section.Lines, "\n") + "\n")
+		}
+		if len(section.Rows) == 0 && len(section.Lines) == 0 {
+			sb.WriteString("None found.\n")
+		}
+	}
+	return sb.String()
+}
+
+// getItems returns the objects printed by omc get with args, decoded as T.
+func getItems[T any](omc omcRunner, args ...string) ([]T, error) {
+	output, err := omc(append(args, "-o", "json")...)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid([]byte(output)) {
+		// omc found nothing to print
+		return nil, nil
+	}
+	var list struct {
+		Items []T `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, fmt.Errorf("unable to parse the output of omc %s: %w", strings.Join(args, " "), err)
+	}
+	return list.Items, nil
+}
+
+// analyzePods lists the pods which are neither running nor completed, and
+// the running pods with containers waiting to start, e.g. crash looping.
+func analyzePods(omc omcRunner, _ string, section *analysisSection) error {
+	pods, err := getItems[corev1.Pod](omc, "get", "pods", "--all-namespaces")
+	if err != nil {
+		return err
+	}
+	section.Headers = []string{"namespace", "name", "phase", "reason", "restarts"}
+	for _, pod := range pods {
+		reason := pod.Status.Reason
+		restarts := int32(0)
+		waiting := false
+		for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			restarts += status.RestartCount
+			if status.State.Waiting != nil {
+				waiting = true
+				if reason == "" {
+					reason = status.State.Waiting.Reason
+				}
+			} else if status.State.Terminated != nil && status.State.Terminated.ExitCode != 0 && reason == "" {
+				reason = status.State.Terminated.Reason
+			}
+		}
+		phase := pod.Status.Phase
+		if (phase == corev1.PodRunning || phase == corev1.PodSucceeded) && !waiting {
+			continue
+		}
+		section.Rows = append(section.Rows, []string{pod.Namespace, pod.Name, string(phase), reason, strconv.Itoa(int(restarts))})
+	}
+	return nil
+}
+
+// analyzeClusterOperators lists the cluster operators which are not
+// available, or are degraded or progressing.
+func analyzeClusterOperators(omc omcRunner, _ string, section *analysisSection) error {
+	operators, err := getItems[configv1.ClusterOperator](omc, "get", "clusteroperators")
+	if err != nil {
+		return err
+	}
+	section.Headers = []string{"name", "available", "progressing", "degraded", "message"}
+	for _, operator := range operators {
+		conditions := map[configv1.ClusterStatusConditionType]configv1.ClusterOperatorStatusCondition{}
+		for _, condition := range operator.Status.Conditions {
+			conditions[condition.Type] = condition
+		}
+		available := conditions[configv1.OperatorAvailable]
+		progressing := conditions[configv1.OperatorProgressing]
+		degraded := conditions[configv1.OperatorDegraded]
+		if available.Status == configv1.ConditionTrue && progressing.Status != configv1.ConditionTrue && degraded.Status != configv1.ConditionTrue {
+			continue
+		}
+		message := progressing.Message
+		if degraded.Status == configv1.ConditionTrue {
+			message = degraded.Message
+		} else if available.Status != configv1.ConditionTrue {
+			message = available.Message
+		}
+		section.Rows = append(section.Rows, []string{operator.Name,
+			string(available.Status), string(progressing.Status), string(degraded.Status), message})
+	}
+	return nil
+}
+
+// analyzeNodes lists the nodes which are not ready, not schedulable or under
+// pressure.
+func analyzeNodes(omc omcRunner, _ string, section *analysisSection) error {
+	nodes, err := getItems[corev1.Node](omc, "get", "nodes")
+	if err != nil {
+		return err
+	}
+	section.Headers = []string{"name", "roles", "status", "conditions"}
+	for _, node := range nodes {
+		status := "Unknown"
+		var pressures []string
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady {
+				if condition.Status == corev1.ConditionTrue {
+					status = "Ready"
+				} else {
+					status = "NotReady"
+				}
+			} else if condition.Status == corev1.ConditionTrue {
+				pressures = append(pressures, string(condition.Type))
+			}
+		}
+		if node.Spec.Unschedulable {
+			status += ",SchedulingDisabled"
+		}
+		if status == "Ready" && len(pressures) == 0 {
+			continue
+		}
+		var roles []string
+		for label := range node.Labels {
+			if role, ok := strings.CutPrefix(label, "node-role.kubernetes.io/"); ok {
+				roles = append(roles, role)
+			}
+		}
+		sort.Strings(roles)
+		section.Rows = append(section.Rows, []string{node.Name, strings.Join(roles, ","), status, strings.Join(pressures, ",")})
+	}
+	return nil
+}
+
+// analyzeAlerts lists the alerting rules which are firing.
+func analyzeAlerts(omc omcRunner, _ string, section *analysisSection) error {
+	output, err := omc("prometheus", "alertrule", "--state", "firing", "-o", "json")
+	if err != nil {
+		return err
+	}
+	var rules prometheus.FilteredRulesList
+	if err := json.Unmarshal([]byte(output), &rules); err != nil {
+		return fmt.Errorf("unable to parse the output of omc prometheus alertrule: %w", err)
+	}
+	section.Headers = []string{"alert", "severity", "alerts", "namespaces", "active since"}
+	for _, rule := range rules.Data {
+		severity := ""
+		if labels, ok := rule["labels"].(map[string]interface{}); ok {
+			severity = fmt.Sprint(labels["severity"])
+		}
+		var alerts []struct {
+			Labels   map[string]string `json:"labels"`
+			ActiveAt *time.Time        `json:"activeAt"`
+		}
+		b, _ := json.Marshal(rule["alerts"])
+		_ = json.Unmarshal(b, &alerts)
+		var activeSince *time.Time
+		namespaces := map[string]bool{}
+		for _, alert := range alerts {
+			if alert.ActiveAt != nil && (activeSince == nil || alert.ActiveAt.Before(*activeSince)) {
+				activeSince = alert.ActiveAt
+			}
+			if namespace := alert.Labels["namespace"]; namespace != "" {
+				namespaces[namespace] = true
+			}
+		}
+		since := ""
+		if activeSince != nil {
+			since = activeSince.Format(time.RFC3339)
+		}
+		section.Rows = append(section.Rows, []string{fmt.Sprint(rule["name"]), severity,
+			strconv.Itoa(len(alerts)), strings.Join(sortedKeys(namespaces), ","), since})
+	}
+	return nil
+}
+
+// analyzeEtcd lists the etcd members with their health and status.
+func analyzeEtcd(_ omcRunner, root string, section *analysisSection) error {
+	etcdFolderPath := root + "/etcd_info/"
+	healthList, err := etcd.ReadEndpointHealth(etcdFolderPath)
+	if err != nil {
+		return err
+	}
+	statuses, err := etcd.ReadEndpointStatus(etcdFolderPath)
+	if err != nil {
+		// the health is still worth reporting
+		section.Lines = append(section.Lines, err.Error())
+	}
+	section.Headers = []string{"endpoint", "health", "took", "leader", "db size", "raft term", "errors"}
+	for _, health := range healthList {
+		row := []string{health.Ep, strconv.FormatBool(health.Health), health.Took, "", "", "", health.Error}
+		for _, status := range statuses {
+			if status.Endpoint != health.Ep {
+				continue
+			}
+			row[3] = strconv.FormatBool(status.Resp.Leader == status.Resp.Header.MemberId)
+			row[4] = humanize.Bytes(uint64(status.Resp.DbSize))
+			row[5] = strconv.FormatUint(status.Resp.RaftTerm, 10)
+			if len(status.Resp.Errors) > 0 {
+				row[6] = strings.TrimSpace(strings.Join(status.Resp.Errors, " ") + " " + health.Error)
+			}
+		}
+		section.Rows = append(section.Rows, row)
+	}
+	return nil
+}
+
+// analyzeEvents lists the objects with the most warning events.
+func analyzeEvents(_ omcRunner, root string, section *analysisSection) error {
+	eventList, err := events.GetEventList(root, "", true)
+	if err != nil {
+		return err
+	}
+	events.FilterEventList(&eventList, []string{corev1.EventTypeWarning}, "")
+	type hotspot struct {
+		namespace, object string
+		count             int32
+		reasons           map[string]int32
+		lastSeen          time.Time
+	}
+	hotspots := map[string]*hotspot{}
+	for _, event := range eventList.Items {
+		object := strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name
+		key := event.Namespace + "/" + object
+		h, ok := hotspots[key]
+		if !ok {
+			h = &hotspot{namespace: event.Namespace, object: object, reasons: map[string]int32{}}
+			hotspots[key] = h
+		}
+		count := max(event.Count, 1)
+		h.count += count
+		h.reasons[event.Reason] += count
+		if lastSeen := events.GetLastTime(event).Time; lastSeen.After(h.lastSeen) {
+			h.lastSeen = lastSeen
+		}
+	}
+	sorted := make([]*hotspot, 0, len(hotspots))
+	for _, h := range hotspots {
+		sorted = append(sorted, h)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		return sorted[i].namespace+sorted[i].object < sorted[j].namespace+sorted[j].object
+	})
+	section.Headers = []string{"namespace", "object", "warnings", "top reason", "last seen"}
+	for _, h := range sorted[:min(len(sorted), maxEventHotspots)] {
+		topReason := ""
+		for reason, count := range h.reasons {
+			if count > h.reasons[topReason] || (count == h.reasons[topReason] && reason < topReason) {
+				topReason = reason
+			}
+		}
+		section.Rows = append(section.Rows, []string{h.namespace, h.object, strconv.Itoa(int(h.count)), topReason, h.lastSeen.Format(time.RFC3339)})
+	}
+	return nil
+}
+
+// analyzeNodeLogs counts the error lines of the node service logs, the first
+// section summarizes all the services and the next ones hold the last error
+// lines of each service.
+func analyzeNodeLogs(root string, services []string) ([]analysisSection, error) {
+	logFiles := logs.NodeLogFiles(root)
+	if len(logFiles) == 0 {
+		return nil, fmt.Errorf("node service logs not found in must-gather")
+	}
+	summary := analysisSection{Title: "Node services", Headers: []string{"role", "node", "service", "lines", "errors"}}
+	var sections []analysisSection
+	for _, logFile := range logFiles {
+		if len(services) > 0 && !helpers.StringInSlice(logFile.Service, services) {
+			continue
+		}
+		// the logs of a role hold the lines of all its nodes
+		role := logFile.Role + "s"
+		title := role + "/" + logFile.Service
+		if logFile.Node != "" {
+			role = logFile.Role
+			title = logFile.Node + "/" + logFile.Service
+		}
+		lines, errors, lastErrors, err := scanErrors(logFile.Path)
+		if err != nil {
+			sections = append(sections, analysisSection{Title: title, Error: err.Error()})
+			continue
+		}
+		summary.Rows = append(summary.Rows, []string{role, logFile.Node, logFile.Service, strconv.Itoa(lines), strconv.Itoa(errors)})
+		if errors > 0 {
+			sections = append(sections, analysisSection{
+				Title: fmt.Sprintf("%s, last %d error lines", title, len(lastErrors)),
+				Lines: lastErrors,
+			})
+		}
+	}
+	return append([]analysisSection{summary}, sections...), nil
+}
+
+// scanErrors returns the number of lines and of error lines of a log file,
+// gzipped or not, and its last error lines.
+func scanErrors(logFile string) (int, int, []string, error) {
+	file, err := os.Open(logFile)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	defer file.Close()
+	var reader io.Reader = file
+	if strings.HasSuffix(logFile, ".gz") {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lines, errors := 0, 0
+	lastErrors := make([]string, 0, maxErrorLines)
+	for scanner.Scan() {
+		lines++
+		if !errorLine.MatchString(scanner.Text()) {
+			continue
+		}
+		errors++
+		if len(lastErrors) == maxErrorLines {
+			lastErrors = lastErrors[1:]
+		}
+		lastErrors = append(lastErrors, scanner.Text())
+	}
+	return lines, errors, lastErrors, scanner.Err()
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gmeghnag/omc/types"
+)
+
+const testAnalyzePods = `apiVersion: v1
+kind: PodList
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: healthy
+    namespace: test-namespace
+  status:
+    phase: Running
+    containerStatuses:
+    - name: app
+      restartCount: 0
+      state:
+        running: {}
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: crashing
+    namespace: test-namespace
+  status:
+    phase: Running
+    containerStatuses:
+    - name: app
+      restartCount: 7
+      state:
+        waiting:
+          reason: CrashLoopBackOff
+`
+
+const testAnalyzeOperatorCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: clusteroperators.config.openshift.io
+spec:
+  group: config.openshift.io
+  names:
+    kind: ClusterOperator
+    listKind: ClusterOperatorList
+    plural: clusteroperators
+    singular: clusteroperator
+    shortNames:
+    - co
+  scope: Cluster
+  versions:
+  - name: v1
+    served: true
+    storage: true
+`
+
+const testAnalyzeOperators = `apiVersion: config.openshift.io/v1
+kind: ClusterOperatorList
+items:
+- apiVersion: config.openshift.io/v1
+  kind: ClusterOperator
+  metadata:
+    name: dns
+  status:
+    conditions:
+    - type: Available
+      status: "True"
+    - type: Progressing
+      status: "False"
+    - type: Degraded
+      status: "False"
+- apiVersion: config.openshift.io/v1
+  kind: ClusterOperator
+  metadata:
+    name: etcd
+  status:
+    conditions:
+    - type: Available
+      status: "True"
+    - type: Progressing
+      status: "False"
+    - type: Degraded
+      status: "True"
+      message: member etcd-2 is unhealthy
+`
+
+const testAnalyzeNodes = `apiVersion: v1
+kind: NodeList
+items:
+- apiVersion: v1
+  kind: Node
+  metadata:
+    name: master-0
+    labels:
+      node-role.kubernetes.io/master: ""
+  status:
+    conditions:
+    - type: Ready
+      status: "True"
+- apiVersion: v1
+  kind: Node
+  metadata:
+    name: worker-0
+    labels:
+      node-role.kubernetes.io/worker: ""
+  spec:
+    unschedulable: true
+  status:
+    conditions:
+    - type: Ready
+      status: "False"
+    - type: DiskPressure
+      status: "True"
+`
+
+const testAnalyzeAlerts = `{
+  "status": "success",
+  "data": {
+    "groups": [
+      {
+        "name": "test-group",
+        "file": "test.yaml",
+        "rules": [
+          {
+            "type": "alerting",
+            "name": "KubePodCrashLooping",
+            "state": "firing",
+            "labels": {"severity": "warning"},
+            "alerts": [
+              {"labels": {"namespace": "test-namespace"}, "state": "firing", "activeAt": "2024-01-02T03:04:05Z"},
+              {"labels": {"namespace": "other-namespace"}, "state": "firing", "activeAt": "2024-01-01T03:04:05Z"}
+            ]
+          },
+          {
+            "type": "alerting",
+            "name": "Watchdog",
+            "state": "inactive",
+            "labels": {"severity": "none"},
+            "alerts": []
+          }
+        ]
+      }
+    ]
+  }
+}`
+
+// analyzeMustGather writes a must-gather holding the objects read by the
+// analyzers and returns a server using it in the sessionless requests.
+func analyzeMustGather(t *testing.T) *Server {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	root := writeMustGather(t, map[string]string{
+		"namespaces/test-namespace/core/pods.yaml": testAnalyzePods,
+		"cluster-scoped-resources/apiextensions.k8s.io/customresourcedefinitions/clusteroperators.config.openshift.io.yaml": testAnalyzeOperatorCRD,
+		"cluster-scoped-resources/config.openshift.io/clusteroperators.yaml":                                                testAnalyzeOperators,
+		"cluster-scoped-resources/core/nodes.yaml":                                                                          testAnalyzeNodes,
+		"monitoring/alerts.json": testAnalyzeAlerts,
+	})
+	s := &Server{}
+	s.setSessionContext(context.Background(), types.Context{Id: "test", Path: root})
+	return s
+}
+
+func TestAnalyzers(t *testing.T) {
+	s := analyzeMustGather(t)
+	omc := func(args ...string) (string, error) {
+		return s.executeOMCCommand(context.Background(), args)
+	}
+	tests := []struct {
+		name    string
+		analyze func(omcRunner, string, *analysisSection) error
+		rows    [][]string
+	}{
+		{
+			name:    "pods",
+			analyze: analyzePods,
+			rows:    [][]string{{"test-namespace", "crashing", "Running", "CrashLoopBackOff", "7"}},
+		},
+		{
+			name:    "cluster operators",
+			analyze: analyzeClusterOperators,
+			rows:    [][]string{{"etcd", "True", "False", "True", "member etcd-2 is unhealthy"}},
+		},
+		{
+			name:    "nodes",
+			analyze: analyzeNodes,
+			rows:    [][]string{{"worker-0", "worker", "NotReady,SchedulingDisabled", "DiskPressure"}},
+		},
+		{
+			name:    "alerts",
+			analyze: analyzeAlerts,
+			rows:    [][]string{{"KubePodCrashLooping", "warning", "2", "other-namespace,test-namespace", "2024-01-01T03:04:05Z"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			section := analysisSection{}
+			if err := tt.analyze(omc, "", &section); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(section.Rows, tt.rows) {
+				t.Errorf("got rows %v, want %v", section.Rows, tt.rows)
+			}
+		})
+	}
+}
+
+func TestRunAnalyzersReportsFailures(t *testing.T) {
+	s := analyzeMustGather(t)
+	omc := func(args ...string) (string, error) {
+		return s.executeOMCCommand(context.Background(), args)
+	}
+	sections := runAnalyzers(omc, "", []analyzer{
+		{title: "failing", analyze: func(omcRunner, string, *analysisSection) error { panic("boom") }},
+		{title: "pods", analyze: analyzePods},
+	})
+	if sections[0].Error != "analysis failed: boom" {
+		t.Errorf("got error %q for the failing analyzer", sections[0].Error)
+	}
+	if sections[1].Error != "" || len(sections[1].Rows) != 1 {
+		t.Errorf("got section %+v after a failing analyzer", sections[1])
+	}
+}
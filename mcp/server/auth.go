@@ -0,0 +1,199 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"sigs.k8s.io/yaml"
+)
+
+// AuthConfiguration lists the clients allowed to reach the network transports.
+type AuthConfiguration struct {
+	Principals []Principal `json:"principals"`
+}
+
+// Principal is a client authenticated by a bearer token or by the common name
+// of its client certificate.
+type Principal struct {
+	Name string `json:"name"`
+	// Token is the bearer token of the client.
+	Token string `json:"token,omitempty"`
+	// CommonName is the subject common name of the client certificate.
+	CommonName string `json:"commonName,omitempty"`
+	// Tools are the tools the client can list and call, all of them when
+	// empty.
+	Tools []string `json:"tools,omitempty"`
+}
+
+type principalKey struct{}
+
+// LoadAuthConfiguration reads the YAML or JSON authentication configuration
+// at path.
+func LoadAuthConfiguration(path string) (*AuthConfiguration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	configuration := &AuthConfiguration{}
+	if err := yaml.UnmarshalStrict(data, configuration); err != nil {
+		return nil, fmt.Errorf("invalid authentication configuration %s: %w", path, err)
+	}
+	for i, principal := range configuration.Principals {
+		if principal.Name == "" {
+			return nil, fmt.Errorf("invalid authentication configuration %s: principal %d has no name", path, i)
+		}
+		if principal.Token == "" && principal.CommonName == "" {
+			return nil, fmt.Errorf("invalid authentication configuration %s: principal %s has no token nor commonName", path, principal.Name)
+		}
+	}
+	return configuration, nil
+}
+
+// authRequired tells whether the clients of the network transports have to
+// authenticate.
+func (s *Server) authRequired() bool {
+	return s.configuration.Auth != nil || s.configuration.TLSClientCAFile != ""
+}
+
+// authenticate rejects the requests of the clients which are not
+// authenticated, and passes the principal of the others in the request
+// context.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if !s.authRequired() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := s.principal(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mustgather-mcp-server"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
+
+// principal returns the principal of the verified client certificate or of
+// the bearer token of the request.
+func (s *Server) principal(r *http.Request) (*Principal, bool) {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		commonName := r.TLS.VerifiedChains[0][0].Subject.CommonName
+		if s.configuration.Auth == nil {
+			// the client CA alone grants access to every tool
+			return &Principal{Name: commonName, CommonName: commonName}, true
+		}
+		for i, principal := range s.configuration.Auth.Principals {
+			if principal.CommonName != "" && principal.CommonName == commonName {
+				return &s.configuration.Auth.Principals[i], true
+			}
+		}
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" || s.configuration.Auth == nil {
+		return nil, false
+	}
+	// compare the hashes so that the time taken doesn't depend on the length
+	sum := sha256.Sum256([]byte(token))
+	for i, principal := range s.configuration.Auth.Principals {
+		expected := sha256.Sum256([]byte(principal.Token))
+		if principal.Token != "" && subtle.ConstantTimeCompare(sum[:], expected[:]) == 1 {
+			return &s.configuration.Auth.Principals[i], true
+		}
+	}
+	return nil, false
+}
+
+// toolAllowed tells whether the principal of the request can use the tool, the
+// requests without principal come from stdio and can use every tool.
+func toolAllowed(ctx context.Context, name string) bool {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return !ok || len(principal.Tools) == 0 || slices.Contains(principal.Tools, name)
+}
+
+// filterTools returns the tools the principal of the request can use.
+func filterTools(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	allowed := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if toolAllowed(ctx, tool.Name) {
+			allowed = append(allowed, tool)
+		}
+	}
+	return allowed
+}
+
+// authorize refuses the tool calls the principal of the request is not allowed
+// to make.
+func authorize(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !toolAllowed(ctx, ctr.Params.Name) {
+			principal := ctx.Value(principalKey{}).(*Principal)
+			return NewTextResult("", fmt.Errorf("tool %s is not allowed for %s", ctr.Params.Name, principal.Name)), nil
+		}
+		return next(ctx, ctr)
+	}
+}
+
+// tlsConfig returns the TLS configuration of the network transports, nil when
+// they are served in clear text.
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	c := s.configuration
+	if c.TLSCertFile == "" && c.TLSKeyFile == "" {
+		if c.TLSClientCAFile != "" {
+			return nil, errors.New("a client CA requires a TLS certificate and key")
+		}
+		return nil, nil
+	}
+	if c.TLSCertFile == "" || c.TLSKeyFile == "" {
+		return nil, errors.New("both the TLS certificate and key are required")
+	}
+	certificate, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the TLS certificate: %w", err)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{certificate},
+	}
+	if c.TLSClientCAFile != "" {
+		pem, err := os.ReadFile(c.TLSClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", c.TLSClientCAFile)
+		}
+		// the clients without certificate can still use a bearer token
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return config, nil
+}
+
+// newHTTPServer returns the HTTP server of a network transport listening on
+// addr, its handler is set by the transport.
+func (s *Server) newHTTPServer(addr string) (*http.Server, error) {
+	config, err := s.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Server{Addr: addr, TLSConfig: config}, nil
+}
+
+// ListenAndServe serves srv, over TLS when it has a TLS configuration.
+func ListenAndServe(srv *http.Server) error {
+	if srv.TLSConfig != nil {
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServe()
+}
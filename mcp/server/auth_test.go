@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var testPrincipals = &AuthConfiguration{Principals: []Principal{
+	{Name: "alice", Token: "alice-token", Tools: []string{"mustgather_get"}},
+	{Name: "bob", CommonName: "bob-client"},
+}}
+
+// verifiedTLS returns the TLS state of a connection whose client certificate
+// of commonName was verified.
+func verifiedTLS(commonName string) *tls.ConnectionState {
+	certificate := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+	return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{certificate}}}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name          string
+		configuration Configuration
+		token         string
+		tls           *tls.ConnectionState
+		want          string
+	}{
+		{name: "no authentication", want: "-"},
+		{name: "missing token", configuration: Configuration{Auth: testPrincipals}},
+		{name: "unknown token", configuration: Configuration{Auth: testPrincipals}, token: "bob-client"},
+		{name: "token", configuration: Configuration{Auth: testPrincipals}, token: "alice-token", want: "alice"},
+		{name: "client certificate", configuration: Configuration{Auth: testPrincipals}, tls: verifiedTLS("bob-client"), want: "bob"},
+		{name: "unknown client certificate", configuration: Configuration{Auth: testPrincipals}, tls: verifiedTLS("eve")},
+		{name: "unknown client certificate and token", configuration: Configuration{Auth: testPrincipals}, tls: verifiedTLS("eve"), token: "alice-token", want: "alice"},
+		{name: "client CA alone", configuration: Configuration{TLSClientCAFile: "ca.pem"}, tls: verifiedTLS("eve"), want: "eve"},
+		{name: "client CA alone without certificate", configuration: Configuration{TLSClientCAFile: "ca.pem"}, token: "alice-token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{configuration: tt.configuration}
+			got := ""
+			handler := s.authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = "-"
+				if principal, ok := r.Context().Value(principalKey{}).(*Principal); ok {
+					got = principal.Name
+				}
+			}))
+			r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			r.TLS = tt.tls
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if got != tt.want {
+				t.Errorf("got principal %q, want %q", got, tt.want)
+			}
+			if tt.want == "" && (w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "") {
+				t.Errorf("got status %d and WWW-Authenticate %q for a client not authenticated", w.Code, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestFilterTools(t *testing.T) {
+	tools := []mcp.Tool{mcp.NewTool("mustgather_get"), mcp.NewTool("download_must_gather")}
+	tests := []struct {
+		name      string
+		principal *Principal
+		want      []string
+	}{
+		{name: "stdio", want: []string{"mustgather_get", "download_must_gather"}},
+		{name: "principal with every tool", principal: &testPrincipals.Principals[1], want: []string{"mustgather_get", "download_must_gather"}},
+		{name: "principal with some tools", principal: &testPrincipals.Principals[0], want: []string{"mustgather_get"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = context.WithValue(ctx, principalKey{}, tt.principal)
+			}
+			var got []string
+			for _, tool := range filterTools(ctx, tools) {
+				got = append(got, tool.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got tools %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	called := false
+	handler := authorize(func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		called = true
+		return NewTextResult("done", nil), nil
+	})
+	ctx := context.WithValue(context.Background(), principalKey{}, &testPrincipals.Principals[0])
+
+	result, err := handler(ctx, toolRequest("download_must_gather", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if called || !result.IsError || result.Content[0].(mcp.TextContent).Text != "tool download_must_gather is not allowed for alice" {
+		t.Errorf("got result %+v for a tool not allowed, handler called: %v", result, called)
+	}
+
+	for _, ctx := range []context.Context{ctx, context.Background()} {
+		called = false
+		result, err = handler(ctx, toolRequest("mustgather_get", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !called || result.IsError {
+			t.Errorf("got result %+v for an allowed tool, handler called: %v", result, called)
+		}
+	}
+}
+
+func TestLoadAuthConfiguration(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *AuthConfiguration
+		wantErr string
+	}{
+		{
+			name: "token and common name",
+			content: `principals:
+- name: alice
+  token: alice-token
+  tools: [mustgather_get]
+- name: bob
+  commonName: bob-client
+`,
+			want: testPrincipals,
+		},
+		{name: "principal without name", content: "principals:\n- token: t\n", wantErr: "principal 0 has no name"},
+		{name: "principal without credentials", content: "principals:\n- name: alice\n", wantErr: "principal alice has no token nor commonName"},
+		{name: "unknown field", content: "principals:\n- name: alice\n  password: p\n", wantErr: "unknown field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "auth.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadAuthConfiguration(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTLSConfigRequiresCertificateAndKey(t *testing.T) {
+	for _, configuration := range []Configuration{
+		{TLSClientCAFile: "ca.pem"},
+		{TLSCertFile: "cert.pem"},
+		{TLSKeyFile: "key.pem"},
+	} {
+		if _, err := (&Server{configuration: configuration}).tlsConfig(); err == nil {
+			t.Errorf("tlsConfig() of %+v didn't fail", configuration)
+		}
+	}
+	if config, err := (&Server{}).tlsConfig(); config != nil || err != nil {
+		t.Errorf("got %v, %v without TLS configuration", config, err)
+	}
+}
@@ -0,0 +1,613 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	omccmd "github.com/gmeghnag/omc/cmd"
+	"github.com/gmeghnag/omc/cmd/helpers"
+	"github.com/gmeghnag/omc/cmd/ovn"
+	"github.com/gmeghnag/omc/cmd/prometheus"
+	"github.com/gmeghnag/omc/cmd/timeline"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Register the tools of the omc subcommands reading specific parts of the
+// must-gather: certificates, monitoring, networking, machine configs and
+// insights, comparing must-gathers, searching and summarizing the logs and
+// building the timeline of the cluster.
+func (s *Server) initCommands() []server.ServerTool {
+	return []server.ServerTool{
+		// 14. omc certs inspect
+		{Tool: mcp.NewTool("mustgather_certs_inspect",
+			mcp.WithDescription("Inspect the certificates held by the configmaps, secrets and certificate signing requests, with their subject, issuer and validity"),
+			mcp.WithString("resource_types", mcp.Description("Comma separated resource types to inspect among cm, secret and csr, all of them by default")),
+			mcp.WithBoolean("all_namespaces", mcp.Description("Inspect resources from all namespaces (-A flag)")),
+			mcp.WithString("namespace", mcp.Description("Namespace to inspect resources from (-n flag)")),
+			mcp.WithString("label_selector", mcp.Description("Label selector of the resources to inspect, in any form kubectl accepts, e.g. app in (a,b),!canary. A bare key selects the resources having that label")),
+			mcp.WithBoolean("list_non_certs", mcp.Description("List the resources which don't hold a certificate too (--list-non-certs flag)")),
+			mcp.WithBoolean("show_parse_failure", mcp.Description("Show why the resources could not be parsed (--show-parse-failure flag)")),
+			mcp.WithString("output", mcp.Description("Output format"), mcp.Enum("wide", "yaml", "json")),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_certs_inspect{}")
+
+			var positional []string
+			if resourceTypes, ok := ctr.GetArguments()["resource_types"].(string); ok && resourceTypes != "" {
+				positional = append(positional, resourceTypes)
+			}
+			cmdArgs := append([]string{"certs", "inspect"}, namespaceArgs(ctr)...)
+			if labelSelector, ok := ctr.GetArguments()["label_selector"].(string); ok && labelSelector != "" {
+				cmdArgs = append(cmdArgs, "-l", labelSelector)
+			}
+			for argument, flag := range map[string]string{"list_non_certs": "--list-non-certs", "show_parse_failure": "--show-parse-failure"} {
+				if value, ok := ctr.GetArguments()[argument].(bool); ok && value {
+					cmdArgs = append(cmdArgs, flag)
+				}
+			}
+			jsonArgs := positionalArgs(append(slices.Clone(cmdArgs), "-o", "json"), positional...)
+
+			if output, ok := ctr.GetArguments()["output"].(string); ok {
+				cmdArgs = append(cmdArgs, "-o", output)
+			}
+			cmdArgs = positionalArgs(cmdArgs, positional...)
+
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				return s.executeOMCJSON(ctx, jsonArgs)
+			}), nil
+		}},
+
+		// 15. omc prometheus alertrule
+		{Tool: mcp.NewTool("mustgather_prometheus_alertrules",
+			mcp.WithDescription("Get the alerting rules configured in Prometheus and their state"),
+			mcp.WithString("names", mcp.Description("Comma separated names of the alerting rules, all of them by default")),
+			mcp.WithString("group", mcp.Description("Comma separated alert groups of the rules (--group flag)")),
+			mcp.WithString("state", mcp.Description("Comma separated states of the rules among firing, pending and inactive (--state flag)")),
+			mcp.WithString("output", mcp.Description("Output format"), mcp.Enum("yaml", "json")),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_prometheus_alertrules{}")
+
+			cmdArgs := []string{"prometheus", "alertrule"}
+			if group, ok := ctr.GetArguments()["group"].(string); ok && group != "" {
+				cmdArgs = append(cmdArgs, "--group", group)
+			}
+			if state, ok := ctr.GetArguments()["state"].(string); ok && state != "" {
+				cmdArgs = append(cmdArgs, "--state", state)
+			}
+			jsonArgs := positionalArgs(append(slices.Clone(cmdArgs), "-o", "json"), listArgument(ctr, "names")...)
+
+			if output, ok := ctr.GetArguments()["output"].(string); ok {
+				cmdArgs = append(cmdArgs, "-o", output)
+			}
+			cmdArgs = positionalArgs(cmdArgs, listArgument(ctr, "names")...)
+
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				return s.executeOMCJSON(ctx, jsonArgs)
+			}), nil
+		}},
+
+		// 16. omc prometheus alertgroup
+		{Tool: mcp.NewTool("mustgather_prometheus_alertgroups",
+			mcp.WithDescription("Get the groups of alerting rules configured in Prometheus"),
+			mcp.WithString("names", mcp.Description("Comma separated names of the groups, all of them by default")),
+			mcp.WithString("filename", mcp.Description("File defining the groups (--filename flag)")),
+			mcp.WithString("output", mcp.Description("Output format"), mcp.Enum("yaml", "json")),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_prometheus_alertgroups{}")
+
+			cmdArgs := []string{"prometheus", "alertgroup"}
+			if filename, ok := ctr.GetArguments()["filename"].(string); ok && filename != "" {
+				cmdArgs = append(cmdArgs, "--filename", filename)
+			}
+			jsonArgs := positionalArgs(append(slices.Clone(cmdArgs), "-o", "json"), listArgument(ctr, "names")...)
+
+			if output, ok := ctr.GetArguments()["output"].(string); ok {
+				cmdArgs = append(cmdArgs, "-o", output)
+			}
+			cmdArgs = positionalArgs(cmdArgs, listArgument(ctr, "names")...)
+
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				return s.executeOMCJSON(ctx, jsonArgs)
+			}), nil
+		}},
+
+		// 17. omc prometheus target
+		{Tool: mcp.NewTool("mustgather_prometheus_targets",
+			mcp.WithDescription("Get the targets scraped by Prometheus and their health"),
+			mcp.WithString("instance", mcp.Description("Prometheus instance (--instance flag), prometheus-k8s-0 by default"), mcp.Enum("prometheus-k8s-0", "prometheus-k8s-1")),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_prometheus_targets{}")
+
+			instance := "prometheus-k8s-0"
+			if value, ok := ctr.GetArguments()["instance"].(string); ok && value != "" {
+				instance = value
+			}
+
+			cmdArgs := []string{"prometheus", "target", "--instance", instance}
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				root, err := s.mustGatherRoot(ctx)
+				if err != nil {
+					return nil, err
+				}
+				rows, err := prometheus.TargetsRows(root, instance)
+				if err != nil {
+					return nil, err
+				}
+				return tableDocument(prometheus.TargetsHeaders, rows), nil
+			}), nil
+		}},
+
+		// 18. omc ovn subnets
+		{Tool: mcp.NewTool("mustgather_ovn_subnets",
+			mcp.WithDescription("Get the OVN-Kubernetes subnets of the nodes"),
+			mcp.WithString("output", mcp.Description("Output format, wide adds the gateway details"), mcp.Enum("wide")),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_ovn_subnets{}")
+
+			cmdArgs := []string{"ovn", "subnets"}
+			output, _ := ctr.GetArguments()["output"].(string)
+			if output != "" {
+				cmdArgs = append(cmdArgs, "-o", output)
+			}
+
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				root, err := s.mustGatherRoot(ctx)
+				if err != nil {
+					return nil, err
+				}
+				unlock, err := lockOMC(ctx)
+				if err != nil {
+					return nil, err
+				}
+				defer unlock()
+				columns, rows, err := ovn.Subnets(root, output == "wide")
+				if err != nil {
+					return nil, err
+				}
+				return tableDocument(columns, rows), nil
+			}), nil
+		}},
+
+		// 19. omc machine-config diff
+		{Tool: mcp.NewTool("mustgather_machineconfig_diff",
+			mcp.WithDescription("Show the unified diff of the decoded files, systemd units and dropins, kernel arguments and OS image of two machine configs"),
+			mcp.WithString("first", mcp.Description("Name of the first machine config"), mcp.Required()),
+			mcp.WithString("second", mcp.Description("Name of the second machine config"), mcp.Required()),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_machineconfig_diff{}")
+
+			first := ctr.GetArguments()["first"].(string)
+			second := ctr.GetArguments()["second"].(string)
+
+			cmdArgs := positionalArgs([]string{"machine-config", "diff"}, first, second)
+			diff := sync.OnceValues(func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			})
+			return NewFormattedResult(formatArgument(ctr), diff, func() (any, error) {
+				output, err := diff()
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{"first": first, "second": second, "lines": splitLines(output)}, nil
+			}), nil
+		}},
+
+		// 20. omc machine-config extract
+		{Tool: mcp.NewTool("mustgather_machineconfig_extract",
+			mcp.WithDescription("Extract the files and systemd units of a machine config in the must-gather, and list their paths"),
+			mcp.WithString("name", mcp.Description("Name of the machine config"), mcp.Required()),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_machineconfig_extract{}")
+
+			name := ctr.GetArguments()["name"].(string)
+
+			cmdArgs := positionalArgs([]string{"machine-config", "extract"}, name)
+			files := sync.OnceValues(func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			})
+			return NewFormattedResult(formatArgument(ctr), files, func() (any, error) {
+				output, err := files()
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{"name": name, "files": splitLines(output)}, nil
+			}), nil
+		}},
+
+		// 21. omc insights get
+		{Tool: mcp.NewTool("mustgather_insights_get",
+			mcp.WithDescription("Get the resources gathered in an Insights archive"),
+			mcp.WithString("kind", mcp.Description("Resource kind"), mcp.Required()),
+			mcp.WithString("name", mcp.Description("Resource name")),
+			mcp.WithString("insights_file", mcp.Description("Path of the Insights archive, the one selected with omc insights use by default")),
+			mcp.WithBoolean("all_namespaces", mcp.Description("Get resources from all namespaces (-A flag)")),
+			mcp.WithString("namespace", mcp.Description("Namespace to get resources from (-n flag)")),
+			mcp.WithString("output", mcp.Description("Output format"), mcp.Enum("yaml", "json", "name")),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_insights_get{}")
+
+			positional := []string{ctr.GetArguments()["kind"].(string)}
+			if name, ok := ctr.GetArguments()["name"].(string); ok && name != "" {
+				positional = append(positional, name)
+			}
+			cmdArgs := append([]string{"insights", "get"}, insightsArgs(ctr)...)
+			cmdArgs = append(cmdArgs, namespaceArgs(ctr)...)
+			jsonArgs := positionalArgs(append(slices.Clone(cmdArgs), "-o", "json"), positional...)
+
+			if output, ok := ctr.GetArguments()["output"].(string); ok {
+				cmdArgs = append(cmdArgs, "-o", output)
+			}
+			cmdArgs = positionalArgs(cmdArgs, positional...)
+
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				return s.executeOMCJSON(ctx, jsonArgs)
+			}), nil
+		}},
+
+		// 22. omc insights logs
+		{Tool: mcp.NewTool("mustgather_insights_logs",
+			mcp.WithDescription("Get the logs of a pod gathered in an Insights archive"),
+			mcp.WithString("pod_name", mcp.Description("Name of the pod to get logs from"), mcp.Required()),
+			mcp.WithString("namespace", mcp.Description("Namespace of the pod"), mcp.Required()),
+			mcp.WithString("container", mcp.Description("Container name within the pod")),
+			mcp.WithBoolean("previous", mcp.Description("Get the logs of the previous container instance (-p flag)")),
+			mcp.WithString("insights_file", mcp.Description("Path of the Insights archive, the one selected with omc insights use by default")),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_insights_logs{}")
+
+			podName := ctr.GetArguments()["pod_name"].(string)
+			namespace := ctr.GetArguments()["namespace"].(string)
+
+			cmdArgs := append([]string{"insights", "logs", "-n", namespace}, insightsArgs(ctr)...)
+			if container, ok := ctr.GetArguments()["container"].(string); ok && container != "" {
+				cmdArgs = append(cmdArgs, "-c", container)
+			}
+			if previous, ok := ctr.GetArguments()["previous"].(bool); ok && previous {
+				cmdArgs = append(cmdArgs, "-p")
+			}
+			cmdArgs = positionalArgs(cmdArgs, podName)
+			logs := sync.OnceValues(func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			})
+			return NewFormattedResult(formatArgument(ctr), logs, func() (any, error) {
+				output, err := logs()
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{"namespace": namespace, "pod": podName, "lines": splitLines(output)}, nil
+			}), nil
+		}},
+
+		// 23. the must-gathers of the session
+		{Tool: mcp.NewTool("mustgather_list",
+			mcp.WithDescription("List the must-gathers selected in this session with mustgather_use or download_must_gather, the current one is marked with *"),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_list{}")
+
+			rows := s.mustGatherRows(ctx)
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				var sb strings.Builder
+				helpers.FprintTable(&sb, omccmd.MustGatherHeaders, rows)
+				return sb.String(), nil
+			}, func() (any, error) {
+				return tableDocument(omccmd.MustGatherHeaders, rows), nil
+			}), nil
+		}},
+
+		// 24. omc diff
+		{Tool: mcp.NewTool("mustgather_compare",
+			mcp.WithDescription("Compare two must-gathers of the same cluster, e.g. taken before and after an incident, and report the resources added, removed and changed in the second one with their changed fields, status conditions included. The managed fields, resource versions, heartbeat and probe times and observed generations are left out"),
+			mcp.WithString("before", mcp.Description("Id or path of the first must-gather, the ids are the ones returned by mustgather_use and mustgather_list"), mcp.Required()),
+			mcp.WithString("after", mcp.Description("Id or path of the second must-gather"), mcp.Required()),
+			mcp.WithString("kind", mcp.Description("Resource kind to compare, e.g. deployments or clusteroperators, all of them but the events by default")),
+			mcp.WithString("name", mcp.Description("Name of the resource to compare, requires kind")),
+			mcp.WithString("namespace", mcp.Description("Namespace to compare, all of them by default (-n flag)")),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_compare{}")
+
+			before, _ := ctr.GetArguments()["before"].(string)
+			after, _ := ctr.GetArguments()["after"].(string)
+			positional := []string{}
+			for _, mustGather := range []string{before, after} {
+				path, err := s.bundlePath(ctx, mustGather)
+				if err != nil {
+					return NewTextResult("", err), nil
+				}
+				positional = append(positional, path)
+			}
+			if kind, ok := ctr.GetArguments()["kind"].(string); ok && kind != "" {
+				positional = append(positional, kind)
+				if name, ok := ctr.GetArguments()["name"].(string); ok && name != "" {
+					positional = append(positional, name)
+				}
+			}
+			cmdArgs := []string{"diff"}
+			if namespace, ok := ctr.GetArguments()["namespace"].(string); ok && namespace != "" {
+				cmdArgs = append(cmdArgs, "-n", namespace)
+			}
+			jsonArgs := positionalArgs(append(slices.Clone(cmdArgs), "-o", "json"), positional...)
+			cmdArgs = positionalArgs(cmdArgs, positional...)
+
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				return s.executeOMCJSON(ctx, jsonArgs)
+			}), nil
+		}},
+
+		// 25. omc machine-config explain
+		{Tool: mcp.NewTool("mustgather_machineconfig_explain",
+			mcp.WithDescription("Map the files, systemd units, dropins and users of a rendered machine config, e.g. rendered-worker-<hash>, to the source machine configs of its pool they come from, and flag the paths the sources write with different contents"),
+			mcp.WithString("name", mcp.Description("Name of the rendered machine config"), mcp.Required()),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_machineconfig_explain{}")
+
+			name := ctr.GetArguments()["name"].(string)
+
+			cmdArgs := positionalArgs([]string{"machine-config", "explain"}, name)
+			jsonArgs := positionalArgs([]string{"machine-config", "explain", "-o", "json"}, name)
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				return s.executeOMCJSON(ctx, jsonArgs)
+			}), nil
+		}},
+
+		// 26. omc logs grep
+		{Tool: mcp.NewTool("mustgather_log_search",
+			mcp.WithDescription("Search the current, previous and rotated logs of every container of a namespace, or of all namespaces, for the lines matching a regular expression. The matches come with their pod, container, log file and line number, and the lines around them"),
+			mcp.WithString("pattern", mcp.Description("Regular expression to search for, in Go syntax, e.g. connection refused|timeout"), mcp.Required()),
+			mcp.WithString("namespace", mcp.Description("Namespace of the pods to search (-n flag)")),
+			mcp.WithBoolean("all_namespaces", mcp.Description("Search the pods of all namespaces (-A flag)")),
+			mcp.WithString("container", mcp.Description("Only search the containers with this name (-c flag)")),
+			mcp.WithBoolean("ignore_case", mcp.Description("Ignore the case of the pattern (-i flag)")),
+			mcp.WithNumber("context", mcp.Description("Number of lines to return before and after each matching line, 0 by default (-C flag)"), mcp.Min(0), mcp.Max(maxLogSearchContext)),
+			mcp.WithNumber("max_matches", mcp.Description(fmt.Sprintf("Maximum number of matching lines to return, %d by default", defaultLogSearchMatches)), mcp.Min(1), mcp.Max(maxLogSearchMatches)),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_log_search{}")
+
+			pattern, _ := ctr.GetArguments()["pattern"].(string)
+			cmdArgs := append([]string{"logs", "grep"}, namespaceArgs(ctr)...)
+			if container, ok := ctr.GetArguments()["container"].(string); ok && container != "" {
+				cmdArgs = append(cmdArgs, "-c", container)
+			}
+			if ignoreCase, ok := ctr.GetArguments()["ignore_case"].(bool); ok && ignoreCase {
+				cmdArgs = append(cmdArgs, "-i")
+			}
+			if contextLines, ok := ctr.GetArguments()["context"].(float64); ok && contextLines > 0 {
+				cmdArgs = append(cmdArgs, "-C", strconv.Itoa(min(int(contextLines), maxLogSearchContext)))
+			}
+			// the search is bounded, a pattern matching every line of the
+			// must-gather would not fit in a response anyway
+			maxMatches := defaultLogSearchMatches
+			if value, ok := ctr.GetArguments()["max_matches"].(float64); ok && value >= 1 {
+				maxMatches = min(int(value), maxLogSearchMatches)
+			}
+			cmdArgs = append(cmdArgs, "--max-matches", strconv.Itoa(maxMatches))
+			jsonArgs := positionalArgs(append(slices.Clone(cmdArgs), "-o", "json"), pattern)
+			cmdArgs = positionalArgs(cmdArgs, pattern)
+
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				return s.executeOMCJSON(ctx, jsonArgs)
+			}), nil
+		}},
+
+		// 27. omc logs summarize
+		{Tool: mcp.NewTool("mustgather_log_summary",
+			mcp.WithDescription("Summarize the logs of a pod, of the pods of a namespace or of all namespaces, or of a node service: the lines are grouped in templates with their IDs, IPs, timestamps, UUIDs and numbers masked, and the most frequent error and warning signatures are returned with their count, first and last occurrence and an example line. Cheaper than reading the logs"),
+			mcp.WithString("pod", mcp.Description("Pod to summarize the logs of, all the pods of the namespace when not set")),
+			mcp.WithString("namespace", mcp.Description("Namespace of the pods (-n flag)")),
+			mcp.WithBoolean("all_namespaces", mcp.Description("Summarize the logs of the pods of all namespaces (-A flag)")),
+			mcp.WithString("container", mcp.Description("Only summarize the logs of the containers with this name (-c flag)")),
+			mcp.WithString("service", mcp.Description("Node service to summarize the log of instead of the pods, like kubelet or crio (--service flag)")),
+			mcp.WithNumber("top", mcp.Description(fmt.Sprintf("Number of error and warning signatures to return, %d by default", defaultLogSummarySignatures)), mcp.Min(1), mcp.Max(maxLogSummarySignatures)),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_log_summary{}")
+
+			cmdArgs := []string{"logs", "summarize"}
+			var positional []string
+			if service, ok := ctr.GetArguments()["service"].(string); ok && service != "" {
+				cmdArgs = append(cmdArgs, "--service", service)
+			} else {
+				if pod, ok := ctr.GetArguments()["pod"].(string); ok && pod != "" {
+					positional = append(positional, pod)
+				}
+				cmdArgs = append(cmdArgs, namespaceArgs(ctr)...)
+				if container, ok := ctr.GetArguments()["container"].(string); ok && container != "" {
+					cmdArgs = append(cmdArgs, "-c", container)
+				}
+			}
+			top := defaultLogSummarySignatures
+			if value, ok := ctr.GetArguments()["top"].(float64); ok && value >= 1 {
+				top = min(int(value), maxLogSummarySignatures)
+			}
+			cmdArgs = append(cmdArgs, "--top", strconv.Itoa(top))
+			jsonArgs := positionalArgs(append(slices.Clone(cmdArgs), "-o", "json"), positional...)
+			cmdArgs = positionalArgs(cmdArgs, positional...)
+
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				return s.executeOMCJSON(ctx, jsonArgs)
+			}), nil
+		}},
+
+		// 28. omc timeline
+		{Tool: mcp.NewTool("mustgather_timeline",
+			mcp.WithDescription("Merge in one time-sorted stream the events, the container starts and terminations, the node and cluster operator condition transitions and the first occurrence of the error signatures of the container logs, to see in which order things broke. Each entry has its time, source, namespace, object, type, reason and message"),
+			mcp.WithString("namespace", mcp.Description("Namespace of the events, pods and logs (-n flag), the node and cluster operator conditions are always returned")),
+			mcp.WithBoolean("all_namespaces", mcp.Description("Read the events, pods and logs of all namespaces (-A flag)")),
+			mcp.WithString("since", mcp.Description("Only return the entries after this RFC3339 time, or in this duration before the must-gather was collected, e.g. 2h (--since flag)")),
+			mcp.WithString("until", mcp.Description("Only return the entries before this RFC3339 time, or this duration before the must-gather was collected (--until flag)")),
+			mcp.WithString("sources", mcp.Description("Comma separated sources to return, some of clusteroperator, node, event, pod and log, all of them by default except log with all_namespaces and neither since nor until (--sources flag)")),
+			mcp.WithBoolean("csv", mcp.Description("Return the text output as CSV instead of a table")),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Printf("mustgather_timeline{}")
+
+			cmdArgs := []string{"timeline"}
+			cmdArgs = append(cmdArgs, namespaceArgs(ctr)...)
+			windowed := false
+			for _, argument := range []string{"since", "until"} {
+				if value, ok := ctr.GetArguments()[argument].(string); ok && value != "" {
+					cmdArgs = append(cmdArgs, "--"+argument, value)
+					windowed = true
+				}
+			}
+			sources := listArgument(ctr, "sources")
+			if allNamespaces, _ := ctr.GetArguments()["all_namespaces"].(bool); len(sources) == 0 && allNamespaces && !windowed {
+				// the logs of every container would be read holding omcLock,
+				// they have to be asked for explicitly
+				sources = slices.DeleteFunc(slices.Clone(timeline.Sources), func(source string) bool {
+					return source == timeline.SourceLog
+				})
+			}
+			if len(sources) > 0 {
+				cmdArgs = append(cmdArgs, "--sources", strings.Join(sources, ","))
+			}
+			jsonArgs := append(slices.Clone(cmdArgs), "-o", "json")
+			if csv, ok := ctr.GetArguments()["csv"].(bool); ok && csv {
+				cmdArgs = append(cmdArgs, "-o", "csv")
+			}
+
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				return s.executeOMCJSON(ctx, jsonArgs)
+			}), nil
+		}},
+	}
+}
+
+const (
+	// defaultLogSearchMatches and maxLogSearchMatches bound the number of
+	// lines returned by mustgather_log_search, maxLogSearchContext the lines
+	// around each of them.
+	defaultLogSearchMatches = 100
+	maxLogSearchMatches     = 1000
+	maxLogSearchContext     = 10
+
+	// defaultLogSummarySignatures and maxLogSummarySignatures bound the
+	// number of signatures returned by mustgather_log_summary.
+	defaultLogSummarySignatures = 20
+	maxLogSummarySignatures     = 200
+)
+
+// bundlePath returns the path of the must-gather opened by the server with
+// the id mustGather, omc resolves the other ids and the paths itself. The
+// must-gathers opened by others are refused.
+func (s *Server) bundlePath(ctx context.Context, mustGather string) (string, error) {
+	if _, ok := s.bundles.Load(mustGather); !ok {
+		return mustGather, nil
+	}
+	b, err := s.lookupBundle(ctx, mustGather)
+	if err != nil {
+		return "", err
+	}
+	return b.path, nil
+}
+
+// mustGatherRows returns the rows of the must-gathers table of the bundles the
+// owner of the request selected, sorted on their id. The must-gather of the
+// session is marked as the current one, with its namespace.
+func (s *Server) mustGatherRows(ctx context.Context) [][]string {
+	mgContext, _ := s.sessionContext(ctx)
+	var rows [][]string
+	s.bundles.Range(func(_, value any) bool {
+		b := value.(*bundle)
+		if !b.ownedBy(ctx) {
+			return true
+		}
+		if b.id == mgContext.Id {
+			rows = append(rows, []string{"*", b.id, b.path, mgContext.Project})
+		} else {
+			rows = append(rows, []string{"", b.id, b.path, ""})
+		}
+		return true
+	})
+	slices.SortFunc(rows, func(a, b []string) int {
+		return strings.Compare(a[1], b[1])
+	})
+	return rows
+}
+
+// namespaceArgs returns the namespace flags selected by the "all_namespaces"
+// and "namespace" arguments of a tool call.
+func namespaceArgs(ctr mcp.CallToolRequest) []string {
+	if allNamespaces, ok := ctr.GetArguments()["all_namespaces"].(bool); ok && allNamespaces {
+		return []string{"-A"}
+	}
+	if namespace, ok := ctr.GetArguments()["namespace"].(string); ok && namespace != "" {
+		return []string{"-n", namespace}
+	}
+	return nil
+}
+
+// listArgument returns the values of a comma separated argument of a tool
+// call.
+func listArgument(ctr mcp.CallToolRequest, name string) []string {
+	value, _ := ctr.GetArguments()[name].(string)
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// insightsArgs returns the flag selecting the Insights archive of a tool call.
+func insightsArgs(ctr mcp.CallToolRequest) []string {
+	if path, ok := ctr.GetArguments()["insights_file"].(string); ok && path != "" {
+		return []string{"--insights-file", path}
+	}
+	return nil
+}
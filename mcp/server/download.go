@@ -0,0 +1,377 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gmeghnag/omc/cmd/use"
+	"github.com/gmeghnag/omc/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// extractionPrefix names the directories of the downloaded must-gathers
+	// in the temporary directory.
+	extractionPrefix = "must-gather-extract-"
+	// DefaultMaxExtractBytes is the size a downloaded must-gather can have
+	// once extracted when none is configured.
+	DefaultMaxExtractBytes = 20 << 30
+	// DefaultMaxExtractFiles is the number of files a downloaded must-gather
+	// can have when none is configured.
+	DefaultMaxExtractFiles = 1000000
+	// DefaultExtractionRetention is how long the downloaded must-gathers are
+	// kept when none is configured.
+	DefaultExtractionRetention = 24 * time.Hour
+	// progressInterval is the minimum time between two progress notifications.
+	progressInterval = time.Second
+	// cleanupInterval is the maximum time between two cleanups of the
+	// extractions.
+	cleanupInterval = 10 * time.Minute
+)
+
+// extractionsMu serializes the cleanups of the extraction directories.
+var extractionsMu sync.Mutex
+
+// DownloadMustGather implements the "download_must_gather" tool.
+func (s *Server) DownloadMustGather(ctx context.Context, mustGatherURL string, progressToken mcp.ProgressToken) (string, error) {
+	destDir, err := s.downloadMustGather(ctx, mustGatherURL, s.progressNotifier(ctx, progressToken))
+	if err != nil {
+		return "", err
+	}
+
+	// Set the session to use the downloaded directory
+	if _, err := s.selectExtraction(ctx, destDir); err != nil {
+		return "", fmt.Errorf("omc use command failed: %w", err)
+	}
+
+	log.Printf("Successfully set omc to use directory: %s\n", destDir)
+	return "Must-gather download and extraction successful.", nil
+}
+
+// downloadMustGather downloads the must-gather at mustGatherURL in a new
+// extraction directory, extracting it within the extraction limits, and
+// returns the directory.
+func (s *Server) downloadMustGather(ctx context.Context, mustGatherURL string, progress func(float64, string)) (string, error) {
+	s.cleanupExtractions()
+
+	// Create temporary directory for extraction
+	destDir, err := os.MkdirTemp("", extractionPrefix)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	done := false
+	defer func() {
+		if !done {
+			_ = os.RemoveAll(destDir)
+		}
+	}()
+
+	log.Printf("Downloading must-gather from: %s\n", mustGatherURL)
+
+	// Check if URL contains inspect.local - if so, download directory contents directly
+	if strings.Contains(mustGatherURL, "inspect.local") {
+		log.Printf("Detected inspect.local URL - downloading directory contents directly\n")
+
+		if err := downloadInspectLocal(ctx, mustGatherURL, destDir); err != nil {
+			return "", fmt.Errorf("failed to download inspect.local contents: %w", err)
+		}
+		// gsutil can't bound the copy, the contents are checked once copied
+		if err := s.checkDownloadLimits(destDir); err != nil {
+			return "", fmt.Errorf("failed to download inspect.local contents: %w", err)
+		}
+
+		log.Printf("Successfully downloaded inspect.local contents to: %s\n", destDir)
+	} else {
+		archive := filepath.Join(destDir, "must-gather.archive")
+		if err := s.downloadFile(ctx, mustGatherURL, archive, progress); err != nil {
+			return "", fmt.Errorf("failed to download must-gather: %w", err)
+		}
+		if err := s.extractArchive(archive, destDir, progress); err != nil {
+			return "", err
+		}
+		if err := os.Remove(archive); err != nil {
+			return "", err
+		}
+	}
+	done = true
+	return destDir, nil
+}
+
+// extractMustGather extracts the must-gather archive at path in a new
+// extraction directory, within the extraction limits, and returns the
+// directory. The archive is left untouched.
+func (s *Server) extractMustGather(path string, progress func(float64, string)) (string, error) {
+	s.cleanupExtractions()
+
+	destDir, err := os.MkdirTemp("", extractionPrefix)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	if err := s.extractArchive(path, destDir, progress); err != nil {
+		_ = os.RemoveAll(destDir)
+		return "", err
+	}
+	return destDir, nil
+}
+
+// extractArchive extracts the archive at path in destDir, within the
+// extraction limits. The progress counts the size of the archive and the bytes
+// extracted.
+func (s *Server) extractArchive(path string, destDir string, progress func(float64, string)) error {
+	archive, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	_, fileType, err := use.IsCompressedFile(path)
+	if err != nil {
+		return fmt.Errorf("must-gather is not a tar, tar.gz, tar.xz or zip archive: %w", err)
+	}
+	log.Printf("Extracting must-gather from %s to: %s\n", path, destDir)
+	_, err = use.ExtractArchive(path, destDir, fileType, use.ExtractLimits{
+		MaxBytes: s.configuration.MaxExtractBytes,
+		MaxFiles: s.configuration.MaxExtractFiles,
+		Progress: func(bytes int64, files int) {
+			progress(float64(archive.Size()+bytes), fmt.Sprintf("Extracted %d files, %s", files, humanize.IBytes(uint64(bytes))))
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to extract must-gather: %w", err)
+	}
+	return nil
+}
+
+// selectExtraction selects the must-gather extracted in destDir for the
+// session of the request, destDir is removed when it can't be used.
+func (s *Server) selectExtraction(ctx context.Context, destDir string) (string, error) {
+	output, err := s.selectMustGather(ctx, destDir)
+	if err != nil {
+		_ = os.RemoveAll(destDir)
+	}
+	return output, err
+}
+
+// downloadInspectLocal downloads inspect.local directory contents
+func downloadInspectLocal(ctx context.Context, url, destDir string) error {
+	if strings.HasPrefix(url, "gs://") {
+		// Use gsutil for Google Cloud Storage - recursive copy
+		cmd := exec.CommandContext(ctx, "gsutil", "-m", "cp", "-r", url, destDir)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("gsutil recursive cp command failed: %w", err)
+		}
+	} else {
+		// For HTTP URLs, we can't do recursive download easily
+		// This would require a more complex implementation or the user to provide a tar/zip
+		return fmt.Errorf("HTTP recursive directory download not supported for inspect.local - please provide gs:// URL")
+	}
+	return nil
+}
+
+// checkDownloadLimits checks the files downloaded at path, a file or a
+// directory, are within the extraction limits.
+func (s *Server) checkDownloadLimits(path string) error {
+	var bytes int64
+	files := 0
+	return filepath.WalkDir(path, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != path {
+			files++
+		}
+		if files > s.configuration.MaxExtractFiles {
+			return fmt.Errorf("download has more than %d files", s.configuration.MaxExtractFiles)
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		bytes += info.Size()
+		if bytes > s.configuration.MaxExtractBytes {
+			return fmt.Errorf("download is larger than %s", humanize.IBytes(uint64(s.configuration.MaxExtractBytes)))
+		}
+		return nil
+	})
+}
+
+// downloadFile downloads a file from either HTTP/HTTPS URL or GS bucket URL
+func (s *Server) downloadFile(ctx context.Context, url, destPath string, progress func(float64, string)) error {
+	if strings.HasPrefix(url, "gs://") {
+		// Use gsutil for Google Cloud Storage
+		cmd := exec.CommandContext(ctx, "gsutil", "-m", "cp", url, destPath)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("gsutil cp command failed: %w", err)
+		}
+		if err := s.checkDownloadLimits(destPath); err != nil {
+			_ = os.RemoveAll(destPath)
+			return err
+		}
+	} else if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+		// Use HTTP client for HTTP/HTTPS URLs
+		if err := s.downloadHTTPFile(ctx, url, destPath, progress); err != nil {
+			return fmt.Errorf("HTTP download failed: %w", err)
+		}
+	} else {
+		return fmt.Errorf("unsupported URL scheme: %s", url)
+	}
+	return nil
+}
+
+// downloadHTTPFile downloads a file via HTTP/HTTPS, up to the extraction size
+// limit.
+func (s *Server) downloadHTTPFile(ctx context.Context, url, destPath string, progress func(float64, string)) error {
+	// Create the destination file
+	out, err := os.Create(destPath)
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %w", err)
+	}
+	defer out.Close()
+
+	// Create HTTP client with timeout
+	client := &http.Client{
+		Timeout: 10 * time.Minute, // Allow for large file downloads
+	}
+
+	// Get the data
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check server response
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad status: %s", resp.Status)
+	}
+	maxBytes := s.configuration.MaxExtractBytes
+	if resp.ContentLength > maxBytes {
+		return fmt.Errorf("file is larger than %s", humanize.IBytes(uint64(maxBytes)))
+	}
+
+	// Copy the body to file, reading one byte more than allowed to tell when
+	// the limit is exceeded
+	counter := &progressWriter{total: resp.ContentLength, progress: progress}
+	n, err := io.Copy(out, io.TeeReader(io.LimitReader(resp.Body, maxBytes+1), counter))
+	if err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	if n > maxBytes {
+		return fmt.Errorf("file is larger than %s", humanize.IBytes(uint64(maxBytes)))
+	}
+
+	return nil
+}
+
+// progressWriter reports the progress of a download.
+type progressWriter struct {
+	written  int64
+	total    int64
+	progress func(float64, string)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.written += int64(len(p))
+	total := "?"
+	if w.total > 0 {
+		total = humanize.IBytes(uint64(w.total))
+	}
+	w.progress(float64(w.written), fmt.Sprintf("Downloaded %s / %s", humanize.IBytes(uint64(w.written)), total))
+	return len(p), nil
+}
+
+// progressNotifier returns a function sending the progress of the request
+// with progressToken to its client, at most once per progressInterval. It
+// does nothing when the client didn't ask for progress notifications.
+func (s *Server) progressNotifier(ctx context.Context, progressToken mcp.ProgressToken) func(progress float64, message string) {
+	if progressToken == nil {
+		return func(float64, string) {}
+	}
+	var last time.Time
+	return func(progress float64, message string) {
+		if time.Since(last) < progressInterval {
+			return
+		}
+		last = time.Now()
+		err := s.server.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+			"progressToken": progressToken,
+			"progress":      progress,
+			"message":       message,
+		})
+		if err != nil {
+			log.Printf("unable to send progress: %v", err)
+		}
+	}
+}
+
+// cleanupPeriodically releases the must-gathers of the idle sessions and
+// removes the extractions past their retention until the server is closed.
+func (s *Server) cleanupPeriodically() {
+	ticker := time.NewTicker(min(cleanupInterval, s.configuration.ExtractionRetention))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.expireSessions()
+			s.cleanupExtractions()
+		}
+	}
+}
+
+// cleanupExtractions removes the downloaded must-gathers older than the
+// retention which are not selected by a session.
+func (s *Server) cleanupExtractions() {
+	extractionsMu.Lock()
+	defer extractionsMu.Unlock()
+	dirs, err := filepath.Glob(filepath.Join(os.TempDir(), extractionPrefix+"*"))
+	if err != nil {
+		return
+	}
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() || time.Since(info.ModTime()) < s.configuration.ExtractionRetention {
+			continue
+		}
+		if s.extractionInUse(dir) {
+			continue
+		}
+		log.Printf("Removing the must-gather downloaded in %s\n", dir)
+		s.removeBundles(dir)
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("unable to remove %s: %v", dir, err)
+		}
+	}
+}
+
+// extractionInUse tells whether a session selected a must-gather in dir.
+func (s *Server) extractionInUse(dir string) bool {
+	inUse := false
+	s.contexts.Range(func(_, value any) bool {
+		inUse = isWithin(value.(types.Context).Path, dir)
+		return !inUse
+	})
+	return inUse
+}
+
+// isWithin tells whether path is dir or one of its descendants.
+func isWithin(path string, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
@@ -0,0 +1,276 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gmeghnag/omc/types"
+)
+
+// tarGz returns a gzipped tar archive of the files, keyed on their path.
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+var testArchiveFiles = map[string]string{
+	"must-gather/quay-io-image/namespaces/test-namespace/core/pods.yaml": "apiVersion: v1\nkind: PodList\nitems: []\n",
+	"must-gather/quay-io-image/timestamp":                                "2024-01-02 03:04:05.000000000 +0000 UTC m=+0.000000001\n",
+}
+
+// extractions returns the extraction directories of the temporary directory.
+func extractions(t *testing.T) []string {
+	t.Helper()
+	dirs, err := filepath.Glob(filepath.Join(os.TempDir(), extractionPrefix+"*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dirs
+}
+
+func noProgress(float64, string) {}
+
+func TestDownloadHTTPFileLimit(t *testing.T) {
+	body := strings.Repeat("x", 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sized":
+			w.Write([]byte(body))
+		case "/chunked":
+			// flushing before writing everything leaves out the length
+			w.Write([]byte(body[:10]))
+			w.(http.Flusher).Flush()
+			w.Write([]byte(body[10:]))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		maxBytes int64
+		wantErr  string
+	}{
+		{name: "within the limit", path: "/sized", maxBytes: 100},
+		{name: "length above the limit", path: "/sized", maxBytes: 99, wantErr: "file is larger than 99 B"},
+		{name: "chunked above the limit", path: "/chunked", maxBytes: 99, wantErr: "file is larger than 99 B"},
+		{name: "bad status", path: "/missing", maxBytes: 100, wantErr: "bad status: 404 Not Found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{configuration: Configuration{MaxExtractBytes: tt.maxBytes}}
+			dest := filepath.Join(t.TempDir(), "archive")
+			err := s.downloadHTTPFile(context.Background(), srv.URL+tt.path, dest, noProgress)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data, _ := os.ReadFile(dest); string(data) != body {
+				t.Errorf("got %d bytes downloaded, want %d", len(data), len(body))
+			}
+		})
+	}
+}
+
+func TestDownloadMustGather(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	archive := tarGz(t, testArchiveFiles)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	}))
+	defer srv.Close()
+
+	s := &Server{configuration: Configuration{MaxExtractBytes: 1 << 20, MaxExtractFiles: 1, ExtractionRetention: time.Hour}}
+	if _, err := s.downloadMustGather(context.Background(), srv.URL+"/must-gather.tar.gz", noProgress); err == nil || !strings.Contains(err.Error(), "failed to extract must-gather") {
+		t.Fatalf("got error %v for an archive above the files limit", err)
+	}
+	if dirs := extractions(t); len(dirs) != 0 {
+		t.Fatalf("got extractions %v left after a failed extraction", dirs)
+	}
+
+	s.configuration.MaxExtractFiles = 10
+	destDir, err := s.downloadMustGather(context.Background(), srv.URL+"/must-gather.tar.gz", noProgress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name := range testArchiveFiles {
+		if _, err := os.Stat(filepath.Join(destDir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "must-gather.archive")); !os.IsNotExist(err) {
+		t.Errorf("the downloaded archive is left in the extraction: %v", err)
+	}
+}
+
+// fakeGsutil puts first on the PATH a gsutil copying source instead of the
+// gs:// URL it is given.
+func fakeGsutil(t *testing.T, source string) {
+	t.Helper()
+	bin := t.TempDir()
+	script := "#!/bin/sh\n# gsutil -m cp [-r] <url> <destination>\nshift 2\n[ \"$1\" = -r ] && shift\nexec cp -R \"$GSUTIL_SOURCE\" \"$2\"\n"
+	if err := os.WriteFile(filepath.Join(bin, "gsutil"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("GSUTIL_SOURCE", source)
+}
+
+func TestDownloadGsutilLimits(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	inspect := filepath.Join(writeMustGather(t, map[string]string{
+		"inspect.local.123/namespaces/test-namespace/core/pods.yaml": "apiVersion: v1\nkind: PodList\nitems: []\n",
+		"inspect.local.123/timestamp":                                "2024-01-02\n",
+	}), "inspect.local.123")
+	archive := filepath.Join(t.TempDir(), "must-gather.tar.gz")
+	if err := os.WriteFile(archive, tarGz(t, testArchiveFiles), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		source   string
+		url      string
+		maxBytes int64
+		maxFiles int
+		want     string
+		wantErr  string
+	}{
+		{name: "directory", source: inspect, url: "gs://bucket/inspect.local.123", maxBytes: 1 << 20, maxFiles: 10, want: "inspect.local.123/namespaces/test-namespace/core/pods.yaml"},
+		{name: "directory above the files limit", source: inspect, url: "gs://bucket/inspect.local.123", maxBytes: 1 << 20, maxFiles: 5, wantErr: "download has more than 5 files"},
+		{name: "directory above the size limit", source: inspect, url: "gs://bucket/inspect.local.123", maxBytes: 40, maxFiles: 10, wantErr: "download is larger than 40 B"},
+		{name: "archive", source: archive, url: "gs://bucket/must-gather.tar.gz", maxBytes: 1 << 20, maxFiles: 10, want: "must-gather/quay-io-image/timestamp"},
+		{name: "archive above the size limit", source: archive, url: "gs://bucket/must-gather.tar.gz", maxBytes: 40, maxFiles: 10, wantErr: "download is larger than 40 B"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeGsutil(t, tt.source)
+			s := &Server{configuration: Configuration{MaxExtractBytes: tt.maxBytes, MaxExtractFiles: tt.maxFiles, ExtractionRetention: time.Hour}}
+			destDir, err := s.downloadMustGather(context.Background(), tt.url, noProgress)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				if dirs := extractions(t); len(dirs) != 0 {
+					t.Errorf("got extractions %v left after a download above the limits", dirs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(destDir)
+			if _, err := os.Stat(filepath.Join(destDir, tt.want)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestUseMustGatherArchive(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	s := newTestServer(t, Configuration{MaxExtractFiles: 10})
+	path := filepath.Join(t.TempDir(), "must-gather.tar.gz")
+	if err := os.WriteFile(path, tarGz(t, testArchiveFiles), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := s.server.WithContext(context.Background(), testSession{id: "archive"})
+	if _, err := s.useMustGather(ctx, path, nil); err != nil {
+		t.Fatal(err)
+	}
+	dirs := extractions(t)
+	mgContext, ok := s.sessionContext(ctx)
+	if len(dirs) != 1 || !ok || !isWithin(mgContext.Path, dirs[0]) {
+		t.Fatalf("got context %+v and extractions %v after using an archive", mgContext, dirs)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("the archive is not left untouched: %v", err)
+	}
+}
+
+func TestUseMustGatherURLRequiresDownload(t *testing.T) {
+	s := newTestServer(t, Configuration{})
+	principal := &Principal{Name: "alice", Tools: []string{"mustgather_use"}}
+	ctx := context.WithValue(context.Background(), principalKey{}, principal)
+	_, err := s.useMustGather(ctx, "https://example.com/must-gather.tar.gz", nil)
+	if want := "downloading must-gathers requires the download_must_gather tool, which is not allowed for alice"; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
+
+func TestCleanupExtractions(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	s := &Server{configuration: Configuration{ExtractionRetention: time.Hour}}
+	old := time.Now().Add(-2 * time.Hour)
+	dirs := map[string]string{}
+	for _, name := range []string{"expired", "selected", "recent"} {
+		dir, err := os.MkdirTemp("", extractionPrefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != "recent" {
+			if err := os.Chtimes(dir, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+		dirs[name] = dir
+	}
+	s.setSessionContext(context.Background(), types.Context{Id: "selected", Path: filepath.Join(dirs["selected"], "must-gather")})
+
+	s.cleanupExtractions()
+	for name, kept := range map[string]bool{"expired": false, "selected": true, "recent": true} {
+		if _, err := os.Stat(dirs[name]); (err == nil) != kept {
+			t.Errorf("got the %s extraction kept: %v, want %v", name, err == nil, kept)
+		}
+	}
+}
+
+func TestExpireSessions(t *testing.T) {
+	s := &Server{configuration: Configuration{ExtractionRetention: time.Hour}}
+	idle := s.server.WithContext(context.Background(), testSession{id: "idle"})
+	active := s.server.WithContext(context.Background(), testSession{id: "active"})
+	s.setSessionContext(idle, types.Context{Id: "idle"})
+	s.setSessionContext(active, types.Context{Id: "active"})
+	s.lastUsed.Store("idle", time.Now().Add(-2*time.Hour))
+
+	s.expireSessions()
+	if _, ok := s.sessionContext(idle); ok {
+		t.Error("the must-gather of an idle session is still selected")
+	}
+	if _, ok := s.sessionContext(active); !ok {
+		t.Error("the must-gather of an active session was released")
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestFormatArgument(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments map[string]any
+		want      string
+	}{
+		{name: "default", arguments: map[string]any{}, want: ""},
+		{name: "text", arguments: map[string]any{"format": "text"}, want: "text"},
+		{name: "json", arguments: map[string]any{"format": "json"}, want: "json"},
+		{name: "both", arguments: map[string]any{"format": "both"}, want: "both"},
+		{name: "not a string", arguments: map[string]any{"format": 1}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatArgument(toolRequest("mustgather_get", tt.arguments)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFormattedResult(t *testing.T) {
+	text := func() (string, error) { return "NAME\ntest-pod\n", nil }
+	data := func() (any, error) { return map[string]any{"items": []string{"test-pod"}}, nil }
+	failing := func() (any, error) { return nil, fmt.Errorf("no must-gather selected") }
+	unencodable := func() (any, error) { return map[string]any{"channel": make(chan int)}, nil }
+
+	tests := []struct {
+		name    string
+		format  string
+		data    func() (any, error)
+		want    []string
+		isError bool
+	}{
+		{name: "default", want: []string{"NAME\ntest-pod\n"}},
+		{name: "text", format: "text", want: []string{"NAME\ntest-pod\n"}},
+		{name: "json", format: "json", want: []string{`{"items":["test-pod"]}`}},
+		{name: "both", format: "both", want: []string{"NAME\ntest-pod\n", `{"items":["test-pod"]}`}},
+		{name: "invalid format", format: "yaml", want: []string{`unsupported format "yaml", use text, json or both`}, isError: true},
+		{name: "JSON error", format: "json", data: failing, want: []string{"no must-gather selected"}, isError: true},
+		{name: "JSON error with both", format: "both", data: failing, want: []string{"no must-gather selected"}, isError: true},
+		{name: "JSON encoding error", format: "json", data: unencodable, want: []string{"json: unsupported type: chan int"}, isError: true},
+		{name: "JSON not computed for text", format: "text", data: failing, want: []string{"NAME\ntest-pod\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.data == nil {
+				tt.data = data
+			}
+			result := NewFormattedResult(tt.format, text, tt.data)
+			var got []string
+			for _, content := range result.Content {
+				textContent, ok := content.(mcp.TextContent)
+				if !ok {
+					t.Fatalf("got content %+v, want text", content)
+				}
+				got = append(got, textContent.Text)
+			}
+			if !reflect.DeepEqual(got, tt.want) || result.IsError != tt.isError {
+				t.Errorf("got %q, error %t, want %q, error %t", got, result.IsError, tt.want, tt.isError)
+			}
+			// the JSON document is a content block, not structured content
+			if result.StructuredContent != nil {
+				t.Errorf("got structured content %+v, want none", result.StructuredContent)
+			}
+		})
+	}
+
+	t.Run("text error", func(t *testing.T) {
+		result := NewFormattedResult("both", func() (string, error) { return "", fmt.Errorf("command failed") }, data)
+		if len(result.Content) != 1 || !result.IsError || result.Content[0].(mcp.TextContent).Text != "command failed" {
+			t.Errorf("got %+v, want the text error only", result)
+		}
+	})
+}
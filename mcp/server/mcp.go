@@ -1,7 +1,16 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
 	"slices"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -9,36 +18,174 @@ import (
 
 type Server struct {
 	server *server.MCPServer
+	// contexts holds the must-gather context selected by each MCP session,
+	// keyed on the session ID.
+	contexts sync.Map
+	// lastUsed holds the last time each MCP session used its must-gather,
+	// keyed on the session ID.
+	lastUsed sync.Map
+	// bundles holds the must-gathers exposed as resources, keyed on the
+	// context ID.
+	bundles sync.Map
+	// sessions holds the registered MCP sessions, keyed on the session ID.
+	sessions      sync.Map
+	configuration Configuration
+	// stop ends the periodic cleanup of the extractions.
+	stop chan struct{}
 }
 
-func NewSever() (*Server, error) {
-	s := &Server{
-		server: server.NewMCPServer(
-			"mustgather-mcp-server",
-			"0.0.1",
-			server.WithInstructions("kubectl like functionality but read only access to a openshift kubernetes cluster snapshot"),
-			server.WithResourceCapabilities(true, true),
-			server.WithPromptCapabilities(true),
-			server.WithToolCapabilities(true),
-			server.WithLogging(),
-		),
-	}
-	s.server.AddTools(slices.Concat(
+// Configuration holds the settings of the server.
+type Configuration struct {
+	// MaxResponseBytes is the byte budget of a tool response, the output
+	// above it is paginated. DefaultMaxResponseBytes when zero.
+	MaxResponseBytes int
+	// TLSCertFile and TLSKeyFile are the certificate and key of the network
+	// transports, which are served in clear text when they are empty.
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSClientCAFile is the CA bundle verifying the client certificates.
+	TLSClientCAFile string
+	// MaxExtractBytes and MaxExtractFiles bound the size and the number of
+	// files of a downloaded must-gather. DefaultMaxExtractBytes and
+	// DefaultMaxExtractFiles when zero.
+	MaxExtractBytes int64
+	MaxExtractFiles int
+	// ExtractionRetention is how long the downloaded must-gathers which are
+	// not in use are kept, and how long a session can be idle before its
+	// must-gather is released. DefaultExtractionRetention when zero.
+	ExtractionRetention time.Duration
+	// Auth lists the clients of the network transports and their tools, nil
+	// when they don't authenticate.
+	Auth *AuthConfiguration
+}
+
+func NewSever(configuration Configuration) (*Server, error) {
+	if configuration.MaxResponseBytes < 0 {
+		return nil, fmt.Errorf("invalid maximum response size %d", configuration.MaxResponseBytes)
+	}
+	if configuration.MaxExtractBytes < 0 {
+		return nil, fmt.Errorf("invalid maximum extraction size %d", configuration.MaxExtractBytes)
+	}
+	if configuration.MaxExtractFiles < 0 {
+		return nil, fmt.Errorf("invalid maximum extraction files %d", configuration.MaxExtractFiles)
+	}
+	if configuration.ExtractionRetention < 0 {
+		return nil, fmt.Errorf("invalid extraction retention %s", configuration.ExtractionRetention)
+	}
+	if configuration.MaxResponseBytes == 0 {
+		configuration.MaxResponseBytes = DefaultMaxResponseBytes
+	}
+	if configuration.MaxExtractBytes == 0 {
+		configuration.MaxExtractBytes = DefaultMaxExtractBytes
+	}
+	if configuration.MaxExtractFiles == 0 {
+		configuration.MaxExtractFiles = DefaultMaxExtractFiles
+	}
+	if configuration.ExtractionRetention == 0 {
+		configuration.ExtractionRetention = DefaultExtractionRetention
+	}
+	s := &Server{configuration: configuration, stop: make(chan struct{})}
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		s.sessions.Store(session.SessionID(), session)
+	})
+	// forget the must-gather, the bundles and the subscriptions of the
+	// sessions which ended, so that they can be cleaned up
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		s.sessions.Delete(session.SessionID())
+		s.contexts.Delete(session.SessionID())
+		s.lastUsed.Delete(session.SessionID())
+		s.unsubscribe(session.SessionID(), "")
+		s.forgetOwner(session.SessionID())
+	})
+	// list only the bundles of the session
+	hooks.AddAfterListResources(s.filterResources)
+	s.server = server.NewMCPServer(
+		"mustgather-mcp-server",
+		"0.0.1",
+		server.WithInstructions("kubectl like functionality but read only access to a openshift kubernetes cluster snapshot"),
+		server.WithResourceCapabilities(true, true),
+		server.WithPromptCapabilities(true),
+		server.WithToolCapabilities(true),
+		server.WithToolFilter(filterTools),
+		server.WithToolHandlerMiddleware(authorize),
+		server.WithCompletions(),
+		server.WithPromptCompletionProvider(s),
+		server.WithLogging(),
+		server.WithHooks(hooks),
+	)
+	s.server.AddTools(s.paginate(slices.Concat(
 		s.initOMC(),
-	)...)
+		s.initAnalyze(),
+		s.initCommands(),
+	))...)
+	s.server.AddPrompts(s.initPrompts()...)
+	s.initResources()
+	go s.cleanupPeriodically()
 	return s, nil
 }
 
+// Close stops the periodic cleanup of the extractions.
+func (s *Server) Close() {
+	close(s.stop)
+}
+
+// ServeStdio serves the MCP session of the standard input and output until
+// they are closed or the process is interrupted.
 func (s *Server) ServeStdio() error {
-	return server.ServeStdio(s.server)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	stdout := &syncWriter{w: os.Stdout}
+	return server.NewStdioServer(s.server).Listen(ctx, s.stdioSubscriptions(ctx, os.Stdin, stdout), stdout)
 }
 
-func (s *Server) ServeSse(baseUrl string) *server.SSEServer {
-	options := make([]server.SSEOption, 0)
+// ServeSse returns the SSE server and the HTTP server serving it on addr.
+func (s *Server) ServeSse(addr string, baseUrl string) (*server.SSEServer, *http.Server, error) {
+	srv, err := s.newHTTPServer(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	options := []server.SSEOption{server.WithHTTPServer(srv)}
 	if baseUrl != "" {
 		options = append(options, server.WithBaseURL(baseUrl))
 	}
-	return server.NewSSEServer(s.server, options...)
+	sseServer := server.NewSSEServer(s.server, options...)
+	session := func(r *http.Request) string {
+		return r.URL.Query().Get("sessionId")
+	}
+	// the SSE transport sends the responses on the event stream of the session
+	reply := func(w http.ResponseWriter, session string, response mcp.JSONRPCMessage) {
+		if err := sseServer.SendEventToSession(session, response); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+	srv.Handler = s.authenticate(s.subscriptions(sseServer, session, reply))
+	return sseServer, srv, nil
+}
+
+// ServeHTTP returns the Streamable HTTP server and the HTTP server serving it
+// on the /mcp endpoint of addr, each client gets a session ID in the
+// Mcp-Session-Id header.
+func (s *Server) ServeHTTP(addr string) (*server.StreamableHTTPServer, *http.Server, error) {
+	srv, err := s.newHTTPServer(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	httpServer := server.NewStreamableHTTPServer(s.server, server.WithStreamableHTTPServer(srv))
+	mux := http.NewServeMux()
+	session := func(r *http.Request) string {
+		return r.Header.Get(server.HeaderKeySessionID)
+	}
+	reply := func(w http.ResponseWriter, session string, response mcp.JSONRPCMessage) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(response)
+	}
+	mux.Handle("/mcp", s.subscriptions(httpServer, session, reply))
+	srv.Handler = s.authenticate(mux)
+	return httpServer, srv, nil
 }
 
 func NewTextResult(content string, err error) *mcp.CallToolResult {
@@ -62,3 +209,41 @@ func NewTextResult(content string, err error) *mcp.CallToolResult {
 		},
 	}
 }
+
+// WithFormat adds the "format" argument selecting the content returned by a
+// tool: its text output, the JSON document behind it, or both.
+func WithFormat() mcp.ToolOption {
+	return mcp.WithString("format",
+		mcp.Description("Result format: text (default), json for a machine readable document, or both"),
+		mcp.Enum("text", "json", "both"),
+	)
+}
+
+// NewFormattedResult returns the text and JSON content of a tool as requested
+// by format, only the content requested is computed.
+func NewFormattedResult(format string, text func() (string, error), data func() (any, error)) *mcp.CallToolResult {
+	result := &mcp.CallToolResult{}
+	switch format {
+	case "", "text", "both":
+		content, err := text()
+		if err != nil {
+			return NewTextResult("", err)
+		}
+		result.Content = append(result.Content, mcp.NewTextContent(content))
+	case "json":
+	default:
+		return NewTextResult("", fmt.Errorf("unsupported format %q, use text, json or both", format))
+	}
+	if format == "json" || format == "both" {
+		value, err := data()
+		if err != nil {
+			return NewTextResult("", err)
+		}
+		document, err := json.Marshal(value)
+		if err != nil {
+			return NewTextResult("", err)
+		}
+		result.Content = append(result.Content, mcp.NewTextContent(string(document)))
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestServeHTTPEndpoint(t *testing.T) {
+	s := newTestServer(t, Configuration{})
+	_, srv, err := s.ServeHTTP(":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	post := func(t *testing.T, path string, session string, body string) *http.Response {
+		t.Helper()
+		request, err := http.NewRequest(http.MethodPost, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Content-Type", "application/json")
+		if session != "" {
+			request.Header.Set(server.HeaderKeySessionID, session)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { response.Body.Close() })
+		return response
+	}
+
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1.0"}}}`
+	response := post(t, "/mcp", "", initialize)
+	var initialized struct {
+		Result struct {
+			ServerInfo   struct{ Name string } `json:"serverInfo"`
+			Capabilities struct {
+				Resources struct{ Subscribe bool }
+			}
+		}
+	}
+	if err := json.NewDecoder(response.Body).Decode(&initialized); err != nil {
+		t.Fatal(err)
+	}
+	session := response.Header.Get(server.HeaderKeySessionID)
+	if response.StatusCode != http.StatusOK || session == "" || initialized.Result.ServerInfo.Name != "mustgather-mcp-server" {
+		t.Fatalf("got status %d, session %q and %+v, want the server initialized", response.StatusCode, session, initialized)
+	}
+	if !initialized.Result.Capabilities.Resources.Subscribe {
+		t.Error("the resource subscriptions are not advertised")
+	}
+
+	response = post(t, "/mcp", session, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	var tools struct {
+		Result struct {
+			Tools []struct{ Name string }
+		}
+	}
+	if err := json.NewDecoder(response.Body).Decode(&tools); err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusOK || len(tools.Result.Tools) == 0 {
+		t.Errorf("got status %d and %d tools, want every tool listed without authentication", response.StatusCode, len(tools.Result.Tools))
+	}
+
+	response = post(t, "/mcp", session, `{"jsonrpc":"2.0","id":3,"method":"resources/subscribe","params":{"uri":"mustgather://unknown/"}}`)
+	var subscribed struct {
+		Error struct{ Message string }
+	}
+	if err := json.NewDecoder(response.Body).Decode(&subscribed); err != nil {
+		t.Fatal(err)
+	}
+	if subscribed.Error.Message != `unknown must-gather "unknown"` {
+		t.Errorf("got error %q subscribing to an unknown must-gather", subscribed.Error.Message)
+	}
+
+	if response := post(t, "/other", "", initialize); response.StatusCode != http.StatusNotFound {
+		t.Errorf("got status %d outside of /mcp, want %d", response.StatusCode, http.StatusNotFound)
+	}
+}
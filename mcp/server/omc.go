@@ -1,441 +1,534 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/gmeghnag/omc/cmd/etcd"
+	"github.com/gmeghnag/omc/cmd/haproxy"
+	"github.com/gmeghnag/omc/cmd/use"
+	"github.com/gmeghnag/omc/root"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
 const execTimeout = 2 * time.Minute
 
+// downloadTool is the tool downloading must-gathers, the principals which
+// can't call it can't download with mustgather_use either.
+const downloadTool = "download_must_gather"
+
 // Register the CLI tools for the OMC
 func (s *Server) initOMC() []server.ServerTool {
 	return []server.ServerTool{
 		// 1. omc get
-		{mcp.NewTool("mustgather_get",
+		{Tool: mcp.NewTool("mustgather_get",
 			mcp.WithDescription("Get kubernetes and openshift resources using oc get command"),
 			mcp.WithString("kind", mcp.Description("Resource kind"), mcp.Required()),
 			mcp.WithBoolean("all_namespaces", mcp.Description("Get resources from all namespaces (-A flag)")),
 			mcp.WithString("namespace", mcp.Description("Namespace to get resources from (-n flag)")),
+			mcp.WithString("label_selector", mcp.Description("Label selector to filter on, in any form kubectl accepts, e.g. app in (a,b),!canary. A bare key selects the objects having that label, it does not mean app=<key>")),
+			mcp.WithString("field_selector", mcp.Description("Field selector to filter on, supporting =, == and != on any field, e.g. status.phase!=Running,spec.nodeName=worker-0")),
+			mcp.WithString("sort_by", mcp.Description("JSONPath expression of the field to sort the resources by, e.g. .metadata.creationTimestamp or .status.containerStatuses[0].restartCount")),
 			mcp.WithString("output", mcp.Description("Output format"), mcp.Enum("wide", "yaml", "json")),
-		), func(_ context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			log.Printf("mustgather_get{}")
 
-			kind := ctr.Params.Arguments["kind"].(string)
+			kind := ctr.GetArguments()["kind"].(string)
 
-			cmdArgs := []string{"get", kind}
+			cmdArgs := []string{"get"}
 
-			if allNamespaces, ok := ctr.Params.Arguments["all_namespaces"].(bool); ok && allNamespaces {
+			if allNamespaces, ok := ctr.GetArguments()["all_namespaces"].(bool); ok && allNamespaces {
 				cmdArgs = append(cmdArgs, "-A")
-			} else if namespace, ok := ctr.Params.Arguments["namespace"].(string); ok {
+			} else if namespace, ok := ctr.GetArguments()["namespace"].(string); ok {
 				cmdArgs = append(cmdArgs, "-n", namespace)
 			}
+			if labelSelector, ok := ctr.GetArguments()["label_selector"].(string); ok && labelSelector != "" {
+				cmdArgs = append(cmdArgs, "-l", labelSelector)
+			}
+			if fieldSelector, ok := ctr.GetArguments()["field_selector"].(string); ok && fieldSelector != "" {
+				cmdArgs = append(cmdArgs, "--field-selector", fieldSelector)
+			}
+			if sortBy, ok := ctr.GetArguments()["sort_by"].(string); ok && sortBy != "" {
+				cmdArgs = append(cmdArgs, "--sort-by", sortBy)
+			}
+			jsonArgs := positionalArgs(append(slices.Clone(cmdArgs), "-o", "json"), kind)
 
-			if output, ok := ctr.Params.Arguments["output"].(string); ok {
+			if output, ok := ctr.GetArguments()["output"].(string); ok {
 				cmdArgs = append(cmdArgs, "-o", output)
 			}
+			cmdArgs = positionalArgs(cmdArgs, kind)
 
-			result, err := executeOMCCommand(cmdArgs)
-			return NewTextResult(result, err), nil
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				return s.executeOMCJSON(ctx, jsonArgs)
+			}), nil
 		}},
 
 		// 2. omc describe
-		{mcp.NewTool("mustgather_describe",
-			mcp.WithDescription("Describe pods or nodes using oc describe command, other resources are not supported."),
-			mcp.WithString("kind", mcp.Description("Resource kind (pods or nodes only)"), mcp.Required(), mcp.Enum("pods", "nodes")),
+		{Tool: mcp.NewTool("mustgather_describe",
+			mcp.WithDescription("Describe kubernetes and openshift resources of any kind, custom resources included, with their events, using oc describe command"),
+			mcp.WithString("kind", mcp.Description("Resource kind, e.g. pods, deployments.apps or a custom resource"), mcp.Required()),
+			mcp.WithString("name", mcp.Description("Name of the resource to describe, all of them by default")),
 			mcp.WithBoolean("all_namespaces", mcp.Description("Describe resources from all namespaces (-A flag)")),
 			mcp.WithString("namespace", mcp.Description("Namespace to describe resources from (-n flag)")),
-			mcp.WithString("output", mcp.Description("Output format"), mcp.Enum("wide", "yaml")),
-		), func(_ context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			log.Printf("mustgather_describe{}")
 
-			kind := ctr.Params.Arguments["kind"].(string)
-			if kind != "pods" && kind != "nodes" {
-				return NewTextResult("", fmt.Errorf("describe only supports 'pods' or 'nodes'")), nil
-			}
-
-			cmdArgs := []string{"describe", kind}
+			kind := ctr.GetArguments()["kind"].(string)
 
-			if allNamespaces, ok := ctr.Params.Arguments["all_namespaces"].(bool); ok && allNamespaces {
-				cmdArgs = append(cmdArgs, "-A")
-			} else if namespace, ok := ctr.Params.Arguments["namespace"].(string); ok {
-				cmdArgs = append(cmdArgs, "-n", namespace)
-			}
-
-			if output, ok := ctr.Params.Arguments["output"].(string); ok {
-				cmdArgs = append(cmdArgs, "-o", output)
+			positional := []string{kind}
+			if name, ok := ctr.GetArguments()["name"].(string); ok && name != "" {
+				positional = append(positional, name)
 			}
-
-			result, err := executeOMCCommand(cmdArgs)
-			return NewTextResult(result, err), nil
+			cmdArgs := positionalArgs(append([]string{"describe"}, namespaceArgs(ctr)...), positional...)
+			// the JSON document holds the described objects
+			jsonArgs := positionalArgs(append(append([]string{"get"}, namespaceArgs(ctr)...), "-o", "json"), positional...)
+
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				return s.executeOMCJSON(ctx, jsonArgs)
+			}), nil
 		}},
 
 		// 3. omc logs
-		{mcp.NewTool("mustgather_logs",
+		{Tool: mcp.NewTool("mustgather_logs",
 			mcp.WithDescription("Get logs from a specific pod and container"),
 			mcp.WithString("pod_name", mcp.Description("Name of the pod to get logs from"), mcp.Required()),
 			mcp.WithString("namespace", mcp.Description("Namespace of the pod"), mcp.Required()),
 			mcp.WithString("container", mcp.Description("Container name within the pod"), mcp.Required()),
-		), func(_ context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			WithTimeWindow(),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			log.Printf("mustgather_logs{}")
 
-			podName := ctr.Params.Arguments["pod_name"].(string)
-			namespace := ctr.Params.Arguments["namespace"].(string)
-			container := ctr.Params.Arguments["container"].(string)
-
-			cmdArgs := []string{"logs", podName, "-n", namespace, "-c", container}
-			result, err := executeOMCCommand(cmdArgs)
-			return NewTextResult(result, err), nil
+			podName := ctr.GetArguments()["pod_name"].(string)
+			namespace := ctr.GetArguments()["namespace"].(string)
+			container := ctr.GetArguments()["container"].(string)
+
+			cmdArgs := append([]string{"logs", "-n", namespace, "-c", container}, timeWindowArgs(ctr)...)
+			cmdArgs = positionalArgs(cmdArgs, podName)
+			logs := sync.OnceValues(func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			})
+			return NewFormattedResult(formatArgument(ctr), logs, func() (any, error) {
+				output, err := logs()
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{"namespace": namespace, "pod": podName, "container": container, "lines": splitLines(output)}, nil
+			}), nil
 		}},
 
 		// 4. omc events
-		{mcp.NewTool("mustgather_events",
+		{Tool: mcp.NewTool("mustgather_events",
 			mcp.WithDescription("Get cluster events using oc events command"),
 			mcp.WithBoolean("all_namespaces", mcp.Description("Get events from all namespaces (-A flag)")),
 			mcp.WithString("namespace", mcp.Description("Namespace to get events from (-n flag)")),
 			mcp.WithString("for", mcp.Description("Filter events for a specific resource (--for flag)")),
 			mcp.WithString("output", mcp.Description("Output format"), mcp.Enum("yaml", "name")),
-		), func(_ context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			log.Printf("mustgather_events{}")
 
 			cmdArgs := []string{"events"}
 
-			if allNamespaces, ok := ctr.Params.Arguments["all_namespaces"].(bool); ok && allNamespaces {
+			if allNamespaces, ok := ctr.GetArguments()["all_namespaces"].(bool); ok && allNamespaces {
 				cmdArgs = append(cmdArgs, "-A")
-			} else if namespace, ok := ctr.Params.Arguments["namespace"].(string); ok {
+			} else if namespace, ok := ctr.GetArguments()["namespace"].(string); ok {
 				cmdArgs = append(cmdArgs, "-n", namespace)
 			}
 
-			if forResource, ok := ctr.Params.Arguments["for"].(string); ok {
+			if forResource, ok := ctr.GetArguments()["for"].(string); ok {
 				cmdArgs = append(cmdArgs, "--for", forResource)
 			}
+			jsonArgs := append(slices.Clone(cmdArgs), "-o", "json")
 
-			if output, ok := ctr.Params.Arguments["output"].(string); ok {
+			if output, ok := ctr.GetArguments()["output"].(string); ok {
 				if output != "yaml" && output != "name" {
 					return NewTextResult("", fmt.Errorf("events only supports 'yaml' or 'name' output")), nil
 				}
 				cmdArgs = append(cmdArgs, "-o", output)
 			}
 
-			result, err := executeOMCCommand(cmdArgs)
-			return NewTextResult(result, err), nil
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				return s.executeOMCJSON(ctx, jsonArgs)
+			}), nil
 		}},
 
 		// 5. omc node-logs
-		{mcp.NewTool("mustgather_node_logs",
-			mcp.WithDescription("Get node logs for a specific journalctl service like NetworkManager, crio, kubelet, machine-config-daemon-firstboot, machine-config-daemon-host, openvswitch, ostree-finalize-staged, ovs-configuration, ovs-vswitchd, ovsdb-server, rpm-ostreed"),
+		{Tool: mcp.NewTool("mustgather_node_logs",
+			mcp.WithDescription("Get the logs of the master and worker nodes, or of a single node or role, for a specific journalctl service like NetworkManager, crio, kubelet, machine-config-daemon-firstboot, machine-config-daemon-host, openvswitch, ostree-finalize-staged, ovs-configuration, ovs-vswitchd, ovsdb-server, rpm-ostreed"),
 			mcp.WithString("service_name", mcp.Description("Journalctl service name to get logs from"), mcp.Required()),
-		), func(_ context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			mcp.WithString("node", mcp.Description("Only return the logs of this node, master and worker nodes alike (--node flag)")),
+			mcp.WithString("role", mcp.Description("Only return the logs of the nodes of this role (--role flag)"), mcp.Enum("master", "worker")),
+			WithTimeWindow(),
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			log.Printf("mustgather_node_logs{}")
 
-			serviceName := ctr.Params.Arguments["service_name"].(string)
+			serviceName := ctr.GetArguments()["service_name"].(string)
 
-			cmdArgs := []string{"node-logs", serviceName}
-			result, err := executeOMCCommand(cmdArgs)
-			return NewTextResult(result, err), nil
+			cmdArgs := []string{"node-logs"}
+			for _, argument := range []string{"node", "role"} {
+				if value, ok := ctr.GetArguments()[argument].(string); ok && value != "" {
+					cmdArgs = append(cmdArgs, "--"+argument, value)
+				}
+			}
+			cmdArgs = positionalArgs(append(cmdArgs, timeWindowArgs(ctr)...), serviceName)
+			logs := sync.OnceValues(func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			})
+			return NewFormattedResult(formatArgument(ctr), logs, func() (any, error) {
+				output, err := logs()
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{"service": serviceName, "lines": splitLines(output)}, nil
+			}), nil
 		}},
 
 		// 6. omc haproxy backends
-		{mcp.NewTool("mustgather_haproxy_backends",
+		{Tool: mcp.NewTool("mustgather_haproxy_backends",
 			mcp.WithDescription("Get HAProxy backends information from openshift router"),
-		), func(_ context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			log.Printf("mustgather_haproxy_backends{}")
 
 			cmdArgs := []string{"haproxy", "backends"}
-			result, err := executeOMCCommand(cmdArgs)
-			return NewTextResult(result, err), nil
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				root, err := s.mustGatherRoot(ctx)
+				if err != nil {
+					return nil, err
+				}
+				unlock, err := lockOMC(ctx)
+				if err != nil {
+					return nil, err
+				}
+				defer unlock()
+				return tableDocument(haproxy.BackendsHeaders, haproxy.BackendsRows(root, "")), nil
+			}), nil
 		}},
 
 		// 7. omc etcd health
-		{mcp.NewTool("mustgather_etcd_health",
+		{Tool: mcp.NewTool("mustgather_etcd_health",
 			mcp.WithDescription("Check etcd cluster health"),
-		), func(_ context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			log.Printf("mustgather_etcd_health{}")
 
 			cmdArgs := []string{"etcd", "health"}
-			result, err := executeOMCCommand(cmdArgs)
-			return NewTextResult(result, err), nil
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				root, err := s.mustGatherRoot(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return etcd.ReadEndpointHealth(root + "/etcd_info/")
+			}), nil
 		}},
 
 		// 8. omc etcd status
-		{mcp.NewTool("mustgather_etcd_status",
+		{Tool: mcp.NewTool("mustgather_etcd_status",
 			mcp.WithDescription("Get etcd cluster status"),
-		), func(_ context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			log.Printf("mustgather_etcd_status{}")
 
 			cmdArgs := []string{"etcd", "status"}
-			result, err := executeOMCCommand(cmdArgs)
-			return NewTextResult(result, err), nil
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				root, err := s.mustGatherRoot(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return etcd.ReadEndpointStatus(root + "/etcd_info/")
+			}), nil
 		}},
 
 		// 9. omc projects
-		{mcp.NewTool("mustgather_projects",
+		{Tool: mcp.NewTool("mustgather_projects",
 			mcp.WithDescription("List available projects / namespaces in the cluster"),
-		), func(_ context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			WithFormat(),
+			WithPagination(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			log.Printf("mustgather_projects{}")
 
 			cmdArgs := []string{"projects"}
-			result, err := executeOMCCommand(cmdArgs)
-			return NewTextResult(result, err), nil
+			return NewFormattedResult(formatArgument(ctr), func() (string, error) {
+				return s.executeOMCCommand(ctx, cmdArgs)
+			}, func() (any, error) {
+				root, err := s.mustGatherRoot(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{"projects": listNames(filepath.Join(root, "namespaces"), "")}, nil
+			}), nil
 		}},
 
 		// 10. omc use
-		{mcp.NewTool("mustgather_use",
+		{Tool: mcp.NewTool("mustgather_use",
 			mcp.WithDescription("Switch to a different mustgather snapshot directory: supports https, local, gcs bucket"),
 			mcp.WithString("path", mcp.Description("Path to switch to"), mcp.Required()),
-		), func(_ context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			WithFormat(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			log.Printf("mustgather_use{}")
 
-			path := ctr.Params.Arguments["path"].(string)
+			path := ctr.GetArguments()["path"].(string)
 
-			cmdArgs := []string{"use", path}
-			result, err := executeOMCCommand(cmdArgs)
-			return NewTextResult(result, err), nil
+			result := sync.OnceValues(func() (string, error) {
+				var progressToken mcp.ProgressToken
+				if ctr.Params.Meta != nil {
+					progressToken = ctr.Params.Meta.ProgressToken
+				}
+				return s.useMustGather(ctx, path, progressToken)
+			})
+			return NewFormattedResult(formatArgument(ctr), result, func() (any, error) {
+				if _, err := result(); err != nil {
+					return nil, err
+				}
+				return s.sessionDocument(ctx), nil
+			}), nil
 		}},
 
 		// 11. download_must_gather
-		{mcp.NewTool("download_must_gather",
+		{Tool: mcp.NewTool(downloadTool,
 			mcp.WithDescription("Download must-gather from a specific URL"),
 			mcp.WithString("url", mcp.Description("URL of the must-gather to download"), mcp.Required()),
-		), func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			WithFormat(),
+		), Handler: func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			log.Printf("download_must_gather{}")
 
-			result, err := s.DownloadMustGather(ctx, ctr.Params.Arguments["url"].(string))
-			return NewTextResult(result, err), nil
+			result := sync.OnceValues(func() (string, error) {
+				var progressToken mcp.ProgressToken
+				if ctr.Params.Meta != nil {
+					progressToken = ctr.Params.Meta.ProgressToken
+				}
+				return s.DownloadMustGather(ctx, ctr.GetArguments()["url"].(string), progressToken)
+			})
+			return NewFormattedResult(formatArgument(ctr), result, func() (any, error) {
+				if _, err := result(); err != nil {
+					return nil, err
+				}
+				return s.sessionDocument(ctx), nil
+			}), nil
 		}},
 	}
 }
 
-func executeOMCCommand(args []string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), execTimeout)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "omc", args...)
-	output, err := cmd.CombinedOutput()
-
-	if ctx.Err() == context.DeadlineExceeded {
-		return "", fmt.Errorf("command timed out after 30 seconds")
-	}
-
-	if err != nil {
-		return string(output), fmt.Errorf("command failed: %v\nOutput: %s", err, string(output))
-	}
+// positionalArgs returns args followed by the positional arguments of a
+// command, after a "--" so that the values starting with a dash, like a
+// pattern, aren't parsed as flags.
+func positionalArgs(args []string, positional ...string) []string {
+	return append(append(args, "--"), positional...)
+}
 
-	return string(output), nil
+// formatArgument returns the "format" argument of a tool call.
+func formatArgument(ctr mcp.CallToolRequest) string {
+	format, _ := ctr.GetArguments()["format"].(string)
+	return format
 }
 
-// DownloadMustGather implements the "download_must_gather" tool.
-func (s *Server) DownloadMustGather(ctx context.Context, mustGatherURL string) (string, error) {
-	// Create temporary directory for extraction
-	destDir, err := os.MkdirTemp("", "must-gather-extract-")
+// executeOMCJSON runs an omc command printing JSON and returns the document,
+// an empty list when omc found nothing to print.
+func (s *Server) executeOMCJSON(ctx context.Context, args []string) (any, error) {
+	output, err := s.executeOMCCommand(ctx, args)
 	if err != nil {
-		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+		return nil, err
 	}
-
-	fmt.Printf("Downloading must-gather from: %s\n", mustGatherURL)
-
-	// Check if URL contains inspect.local - if so, download directory contents directly
-	if strings.Contains(mustGatherURL, "inspect.local") {
-		fmt.Printf("Detected inspect.local URL - downloading directory contents directly\n")
-		
-		if err := downloadInspectLocal(mustGatherURL, destDir); err != nil {
-			return "", fmt.Errorf("failed to download inspect.local contents: %w", err)
-		}
-		
-		fmt.Printf("Successfully downloaded inspect.local contents to: %s\n", destDir)
-	} else {
-		// Standard tar file download and extraction
-		mustGatherDestPath := filepath.Join(destDir, "must-gather.tar")
-		if err := downloadFile(mustGatherURL, mustGatherDestPath); err != nil {
-			return "", fmt.Errorf("failed to download must-gather: %w", err)
-		}
-
-		fmt.Printf("Extracting must-gather from %s to: %s\n", mustGatherDestPath, destDir)
-		if err := extractTarFile(mustGatherDestPath, destDir); err != nil {
-			return "", fmt.Errorf("failed to extract must-gather: %w", err)
-		}
+	if !json.Valid([]byte(output)) {
+		return map[string]any{"apiVersion": "v1", "kind": "List", "items": []any{}}, nil
 	}
+	return json.RawMessage(output), nil
+}
 
-	// Set omc to use the downloaded directory
-	cmd := exec.Command("omc", "use", destDir)
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("omc use command failed: %w", err)
+// tableDocument returns the JSON document of a table.
+func tableDocument(columns []string, rows [][]string) map[string]any {
+	if rows == nil {
+		rows = [][]string{}
 	}
-
-	fmt.Printf("Successfully set omc to use directory: %s\n", destDir)
-	return "Must-gather download and extraction successful.", nil
+	return map[string]any{"columns": columns, "rows": rows}
 }
 
-// downloadInspectLocal downloads inspect.local directory contents
-func downloadInspectLocal(url, destDir string) error {
-	if strings.HasPrefix(url, "gs://") {
-		// Use gsutil for Google Cloud Storage - recursive copy
-		cmd := exec.Command("gsutil", "-m", "cp", "-r", url, destDir)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("gsutil recursive cp command failed: %w", err)
-		}
-	} else {
-		// For HTTP URLs, we can't do recursive download easily
-		// This would require a more complex implementation or the user to provide a tar/zip
-		return fmt.Errorf("HTTP recursive directory download not supported for inspect.local - please provide gs:// URL")
+// splitLines returns the lines of a command output.
+func splitLines(output string) []string {
+	if output == "" {
+		return []string{}
 	}
-	return nil
+	return strings.Split(strings.TrimSuffix(output, "\n"), "\n")
 }
 
-// downloadFile downloads a file from either HTTP/HTTPS URL or GS bucket URL
-func downloadFile(url, destPath string) error {
-	if strings.HasPrefix(url, "gs://") {
-		// Use gsutil for Google Cloud Storage
-		cmd := exec.Command("gsutil", "-m", "cp", url, destPath)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("gsutil cp command failed: %w", err)
-		}
-	} else if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
-		// Use HTTP client for HTTP/HTTPS URLs
-		if err := downloadHTTPFile(url, destPath); err != nil {
-			return fmt.Errorf("HTTP download failed: %w", err)
+// WithTimeWindow adds the arguments selecting the log lines written in a time
+// window, e.g. the minutes around an incident.
+func WithTimeWindow() mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		for _, option := range []mcp.ToolOption{
+			mcp.WithString("since_time", mcp.Description("Only return the logs written after this RFC3339 time, e.g. 2024-01-02T15:04:05Z (--since-time flag)")),
+			mcp.WithString("until_time", mcp.Description("Only return the logs written before this RFC3339 time (--until-time flag)")),
+			mcp.WithString("since", mcp.Description("Only return the logs written in this duration before the must-gather was collected, e.g. 30m or 2h (--since flag)")),
+		} {
+			option(tool)
 		}
-	} else {
-		return fmt.Errorf("unsupported URL scheme: %s", url)
 	}
-	return nil
 }
 
-// downloadHTTPFile downloads a file via HTTP/HTTPS
-func downloadHTTPFile(url, destPath string) error {
-	// Create the destination file
-	out, err := os.Create(destPath)
-	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
-	}
-	defer out.Close()
-
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Minute, // Allow for large file downloads
-	}
-
-	// Get the data
-	resp, err := client.Get(url)
-	if err != nil {
-		return fmt.Errorf("failed to download file: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check server response
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("bad status: %s", resp.Status)
-	}
-
-	// Copy the body to file
-	_, err = io.Copy(out, resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to save file: %w", err)
+// timeWindowArgs returns the flags of the time window arguments of a tool
+// call.
+func timeWindowArgs(ctr mcp.CallToolRequest) []string {
+	var args []string
+	for _, argument := range []string{"since_time", "until_time", "since"} {
+		if value, ok := ctr.GetArguments()[argument].(string); ok && value != "" {
+			args = append(args, "--"+strings.ReplaceAll(argument, "_", "-"), value)
+		}
 	}
-
-	return nil
+	return args
 }
 
-// extractTarFile extracts a tar file to a destination directory
-func extractTarFile(tarPath, destDir string) error {
-	cmd := exec.Command("tar", "-xf", tarPath, "-C", destDir)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("tar extraction failed: %w", err)
+// omcLock serializes the omc executions, omc keeps its state in package level
+// variables and can't run more than one command at a time. It is held by
+// sending to it, see lockOMC.
+var omcLock = make(chan struct{}, 1)
+
+// lockOMC waits for the running omc execution to finish, or for ctx to be
+// done, and returns the function releasing omcLock.
+func lockOMC(ctx context.Context) (func(), error) {
+	select {
+	case omcLock <- struct{}{}:
+		return func() { <-omcLock }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
-	return nil
 }
 
-// AnalyzeMustGather implements the "analyze_must_gather" tool.
-func (s *Server) AnalyzeMustGather(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var Output []byte
-	// run omc get pods
-	cmd := exec.Command("omc", "get", "pods", "--all-namespaces")
+type omcResult struct {
+	output string
+	err    error
+}
 
-	/*err := cmd.Run()
-	if err != nil {
-		// cmd.Run() returns an error if the command fails (non-zero exit code)
-		return nil, fmt.Errorf("omc get pods command failed: %w", err)
-	}*/
+// executeOMCCommand runs omc in-process with the given arguments and returns
+// its output. The must-gather selected by the session of the request is used,
+// falling back to the current context of the omc config file when the session
+// did not select one. A panic raised by omc is turned into an error so that a
+// single bad call can't take the server down.
+//
+// The command is cancelled when ctx is done or after execTimeout, waiting for
+// omcLock included, omc holding omcLock until it returns. The commands reading
+// the logs, which can take long on a large must-gather, stop reading at once;
+// the other ones read a bounded set of manifests and run to completion.
+func (s *Server) executeOMCCommand(ctx context.Context, args []string) (string, error) {
+	mgContext, hasContext := s.sessionContext(ctx)
+	ctx, cancel := context.WithTimeout(ctx, execTimeout)
+	defer cancel()
 
-	// get the output of the command
-	output, err := cmd.Output()
+	unlock, err := lockOMC(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("omc get pods command failed: %w", err)
+		return "", executionError(err)
 	}
-
-	Output = append(Output, output...)
-
-	// get the pod which is not in running state
-
-	cmd = exec.Command("omc", "get", "pods", "--all-namespaces", "--field-selector=status.phase!=Running")
-	output, err = cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("omc get pods command failed: %w", err)
+	done := make(chan omcResult, 1)
+	go func() {
+		defer unlock()
+		var buf bytes.Buffer
+		defer func() {
+			if r := recover(); r != nil {
+				done <- omcResult{output: buf.String(), err: fmt.Errorf("omc panicked: %v", r)}
+			}
+		}()
+		var err error
+		if hasContext {
+			err = root.ExecuteInContext(ctx, mgContext, args, &buf)
+		} else {
+			err = root.ExecuteWithContext(ctx, args, &buf)
+		}
+		done <- omcResult{output: buf.String(), err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return "", executionError(ctx.Err())
+	case res := <-done:
+		if res.err != nil {
+			return res.output, fmt.Errorf("command failed: %v\nOutput: %s", res.err, res.output)
+		}
+		return res.output, nil
 	}
-	Output = append(Output, output...)
+}
 
-	// get the logs of the pod which is not in running state
-	cmd = exec.Command("omc", "logs", "--all-namespaces", "--field-selector=status.phase!=Running")
-	output, err = cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("omc get logs command failed: %w", err)
+// executionError returns the error of an omc execution whose context is done.
+func executionError(err error) error {
+	if err == context.DeadlineExceeded {
+		return fmt.Errorf("command timed out after %s", execTimeout)
 	}
-	Output = append(Output, output...)
+	return err
+}
 
-	// describe the pod which is not in running state
-	cmd = exec.Command("omc", "describe", "pods", "--all-namespaces", "--field-selector=status.phase!=Running")
-	output, err = cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("omc describe pods command failed: %w", err)
+// useMustGather selects the must-gather found in path for the session of the
+// request, leaving the omc config file and the other sessions untouched. A
+// remote must-gather is downloaded like download_must_gather does, which the
+// principal of the request must be allowed to call, and an archive is
+// extracted in an extraction directory, both within the extraction limits and
+// cleaned up after the retention.
+func (s *Server) useMustGather(ctx context.Context, path string, progressToken mcp.ProgressToken) (string, error) {
+	progress := s.progressNotifier(ctx, progressToken)
+	if use.IsRemoteFile(path) {
+		if !toolAllowed(ctx, downloadTool) {
+			principal := ctx.Value(principalKey{}).(*Principal)
+			return "", fmt.Errorf("downloading must-gathers requires the %s tool, which is not allowed for %s", downloadTool, principal.Name)
+		}
+		destDir, err := s.downloadMustGather(ctx, path, progress)
+		if err != nil {
+			return "", err
+		}
+		return s.selectExtraction(ctx, destDir)
 	}
-	Output = append(Output, output...)
-
-	// get all cluster operators in yaml format
-	cmd = exec.Command("omc", "get", "clusteroperators", "-o", "yaml")
-	output, err = cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("omc get clusteroperators command failed: %w", err)
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+		destDir, err := s.extractMustGather(path, progress)
+		if err != nil {
+			return "", err
+		}
+		return s.selectExtraction(ctx, destDir)
 	}
-	Output = append(Output, output...)
-
-	return NewTextResult(string(Output), err), nil
+	return s.selectMustGather(ctx, path)
 }
 
-// AnalyzeNodeLogs implements the "analyze_node_logs" tool.
-func (s *Server) AnalyzeNodeLogs(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var Output []byte
-	cmd := exec.Command("omc", "node-logs", "kubelet")
-	output, err := cmd.Output()
+// selectMustGather selects the must-gather found in the directory path for
+// the session of the request.
+func (s *Server) selectMustGather(ctx context.Context, path string) (string, error) {
+	mgContext, err := use.Resolve(log.Writer(), path)
 	if err != nil {
-		return nil, fmt.Errorf("omc get logs command failed: %w", err)
+		return "", err
 	}
-	Output = append(Output, output...)
-
-	// get the journal logs
-	cmd = exec.Command("omc", "node-logs", "journal")
-	output, err = cmd.Output()
+	s.setSessionContext(ctx, mgContext)
+	s.addBundle(ctx, mgContext)
+	output, err := s.executeOMCCommand(ctx, []string{"use"})
 	if err != nil {
-		return nil, fmt.Errorf("omc get journal logs command failed: %w", err)
+		return output, err
 	}
-	Output = append(Output, output...)
-
-	// get Networkmanager logs
-	cmd = exec.Command("omc", "node-logs", "networkmanager")
-	output, err = cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("omc get networkmanager logs command failed: %w", err)
-	}
-	Output = append(Output, output...)
-
-	return NewTextResult(string(Output), err), nil
+	return output + fmt.Sprintf("\nFiles available as resources under %s%s/\n", resourceScheme, mgContext.Id), nil
 }
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecuteOMCCommandWaitsForLockUntilDone(t *testing.T) {
+	s := newTestServer(t, Configuration{})
+	unlock, err := lockOMC(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.executeOMCCommand(ctx, []string{"version"})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "command timed out") {
+			t.Errorf("got error %v, want a timeout", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a command waiting for a running omc execution outlived its context")
+	}
+}
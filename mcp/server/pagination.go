@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// DefaultMaxResponseBytes is the byte budget of a tool response when none is
+// configured.
+const DefaultMaxResponseBytes = 256 * 1024
+
+const cursorPrefix = "offset:"
+
+// page selects the lines of a tool output.
+type page struct {
+	offset int
+	limit  int
+	tail   int
+}
+
+// pageInfo describes the lines returned by a tool.
+type pageInfo struct {
+	offset     int
+	lines      int
+	totalLines int
+	totalBytes int
+	nextCursor string
+}
+
+// WithPagination adds the arguments selecting the lines of the text output of
+// a tool, the tools declaring them get their output paginated.
+func WithPagination() mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		for _, option := range []mcp.ToolOption{
+			mcp.WithNumber("limit", mcp.Description("Maximum number of lines to return"), mcp.Min(1)),
+			mcp.WithNumber("offset", mcp.Description("Number of lines to skip"), mcp.Min(0)),
+			mcp.WithString("cursor", mcp.Description("Continuation token returned by the previous call, to get the next lines")),
+			mcp.WithNumber("tail", mcp.Description("Return the last lines only, e.g. the most recent logs"), mcp.Min(1)),
+		} {
+			option(tool)
+		}
+	}
+}
+
+// paginate wraps the handlers of the tools declaring the pagination arguments
+// so that their output is paginated and fits the byte budget of the server.
+func (s *Server) paginate(tools []server.ServerTool) []server.ServerTool {
+	for i, tool := range tools {
+		if _, ok := tool.Tool.InputSchema.Properties["limit"]; ok {
+			tools[i].Handler = s.paginated(tool.Handler)
+		}
+	}
+	return tools
+}
+
+// paginated returns the lines of the text output of handler selected by the
+// pagination arguments, within the byte budget. The result reports the total
+// size of the output and the cursor of the next lines, nothing is dropped
+// without the client being told.
+func (s *Server) paginated(handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, ctr mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		p, err := pageArguments(ctr)
+		if err != nil {
+			return NewTextResult("", err), nil
+		}
+		result, err := handler(ctx, ctr)
+		if err != nil || result == nil || result.IsError {
+			return result, err
+		}
+		format := formatArgument(ctr)
+		budget := s.configuration.MaxResponseBytes
+		// the JSON document is the last content, it can't be split
+		if format == "json" || format == "both" {
+			last := len(result.Content) - 1
+			if document, ok := result.Content[last].(mcp.TextContent); ok {
+				if len(document.Text) > budget {
+					result.Content[last] = mcp.NewTextContent(fmt.Sprintf(
+						"[the JSON document is %d bytes, above the response budget of %d bytes, narrow the request or use the text format to paginate it]",
+						len(document.Text), budget))
+				}
+				budget -= len(result.Content[last].(mcp.TextContent).Text)
+			}
+		}
+		if format == "json" {
+			return result, nil
+		}
+		text, ok := result.Content[0].(mcp.TextContent)
+		if !ok {
+			return result, nil
+		}
+		output, info := p.apply(text.Text, max(budget, 0))
+		if info.lines < info.totalLines {
+			footer := fmt.Sprintf("[no lines after line %d of %d, %d bytes in total", info.offset, info.totalLines, info.totalBytes)
+			if info.lines > 0 {
+				footer = fmt.Sprintf("[lines %d-%d of %d, %d bytes in total", info.offset+1, info.offset+info.lines, info.totalLines, info.totalBytes)
+			}
+			if info.nextCursor != "" {
+				footer += fmt.Sprintf(", continue with cursor %q", info.nextCursor)
+			}
+			output += footer + "]\n"
+		}
+		result.Content[0] = mcp.NewTextContent(output)
+		meta := map[string]any{
+			"offset":     info.offset,
+			"lines":      info.lines,
+			"totalLines": info.totalLines,
+			"totalBytes": info.totalBytes,
+		}
+		if info.nextCursor != "" {
+			meta["nextCursor"] = info.nextCursor
+		}
+		result.Meta = mcp.NewMetaFromMap(meta)
+		return result, nil
+	}
+}
+
+// pageArguments returns the page selected by the arguments of a tool call,
+// the cursor takes precedence over offset and tail.
+func pageArguments(ctr mcp.CallToolRequest) (page, error) {
+	var p page
+	arguments := ctr.GetArguments()
+	for name, value := range map[string]*int{"limit": &p.limit, "offset": &p.offset, "tail": &p.tail} {
+		if number, ok := arguments[name].(float64); ok {
+			if number < 0 {
+				return p, fmt.Errorf("%s must not be negative", name)
+			}
+			*value = int(number)
+		}
+	}
+	if cursor, ok := arguments["cursor"].(string); ok && cursor != "" {
+		offset, err := decodeCursor(cursor)
+		if err != nil {
+			return p, err
+		}
+		p.offset, p.tail = offset, 0
+	}
+	return p, nil
+}
+
+// apply returns the lines of output selected by the page that fit in budget
+// bytes. The output is returned unchanged when it is selected as a whole.
+func (p page) apply(output string, budget int) (string, pageInfo) {
+	lines := splitLines(output)
+	info := pageInfo{totalLines: len(lines), totalBytes: len(output)}
+	start := min(p.offset, len(lines))
+	if p.tail > 0 {
+		start = max(len(lines)-p.tail, 0)
+	}
+	end := len(lines)
+	if p.limit > 0 {
+		end = min(start+p.limit, len(lines))
+	}
+	if start == 0 && end == len(lines) && len(output) <= budget {
+		info.lines = len(lines)
+		return output, info
+	}
+	var sb strings.Builder
+	next := start
+	for ; next < end; next++ {
+		line := lines[next]
+		if sb.Len()+len(line)+1 > budget {
+			if next == start {
+				// a single line above the budget, it is cut to return something
+				sb.WriteString(truncateUTF8(line, budget/2) + fmt.Sprintf(" [line truncated from %d bytes]\n", len(line)))
+				next++
+			}
+			break
+		}
+		sb.WriteString(line + "\n")
+	}
+	info.offset, info.lines = start, next-start
+	if next < len(lines) {
+		info.nextCursor = encodeCursor(next)
+	}
+	return sb.String(), info
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		if offset, ok := strings.CutPrefix(string(decoded), cursorPrefix); ok {
+			if n, err := strconv.Atoi(offset); err == nil && n >= 0 {
+				return n, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid cursor %q", cursor)
+}
+
+// truncateUTF8 returns the first n bytes of s, without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
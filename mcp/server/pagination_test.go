@@ -0,0 +1,199 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// toolRequest returns the call of a tool with the arguments.
+func toolRequest(name string, arguments map[string]any) mcp.CallToolRequest {
+	return mcp.CallToolRequest{Params: mcp.CallToolParams{Name: name, Arguments: arguments}}
+}
+
+func TestPageApply(t *testing.T) {
+	tests := []struct {
+		name   string
+		page   page
+		output string
+		budget int
+		want   string
+		info   pageInfo
+	}{
+		{
+			name:   "whole output",
+			output: "a\nb\nc\nd\n",
+			budget: 100,
+			want:   "a\nb\nc\nd\n",
+			info:   pageInfo{lines: 4, totalLines: 4, totalBytes: 8},
+		},
+		{
+			name:   "offset and limit",
+			page:   page{offset: 1, limit: 2},
+			output: "a\nb\nc\nd\n",
+			budget: 100,
+			want:   "b\nc\n",
+			info:   pageInfo{offset: 1, lines: 2, totalLines: 4, totalBytes: 8, nextCursor: encodeCursor(3)},
+		},
+		{
+			name:   "tail",
+			page:   page{tail: 1},
+			output: "a\nb\nc\nd\n",
+			budget: 100,
+			want:   "d\n",
+			info:   pageInfo{offset: 3, lines: 1, totalLines: 4, totalBytes: 8},
+		},
+		{
+			name:   "byte budget",
+			output: "a\nb\nc\nd\n",
+			budget: 4,
+			want:   "a\nb\n",
+			info:   pageInfo{lines: 2, totalLines: 4, totalBytes: 8, nextCursor: encodeCursor(2)},
+		},
+		{
+			name:   "offset after the last line",
+			page:   page{offset: 10},
+			output: "a\nb\nc\nd\n",
+			budget: 100,
+			want:   "",
+			info:   pageInfo{offset: 4, totalLines: 4, totalBytes: 8},
+		},
+		{
+			name:   "line above the byte budget",
+			output: "abcdefghij\nk\n",
+			budget: 6,
+			want:   "abc [line truncated from 10 bytes]\n",
+			info:   pageInfo{lines: 1, totalLines: 2, totalBytes: 13, nextCursor: encodeCursor(1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, info := tt.page.apply(tt.output, tt.budget)
+			if got != tt.want {
+				t.Errorf("got output %q, want %q", got, tt.want)
+			}
+			if info != tt.info {
+				t.Errorf("got info %+v, want %+v", info, tt.info)
+			}
+		})
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	for _, offset := range []int{0, 1, 12345} {
+		if got, err := decodeCursor(encodeCursor(offset)); err != nil || got != offset {
+			t.Errorf("decodeCursor(encodeCursor(%d)) = %d, %v", offset, got, err)
+		}
+	}
+	for _, cursor := range []string{"", "not base64!", encodeCursor(0)[1:], "b2Zmc2V0Oi0x", "b2Zmc2V0Ong"} {
+		if _, err := decodeCursor(cursor); err == nil {
+			t.Errorf("decodeCursor(%q) didn't fail", cursor)
+		}
+	}
+}
+
+func TestPageArguments(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments map[string]any
+		want      page
+		wantErr   string
+	}{
+		{
+			name:      "offset limit and tail",
+			arguments: map[string]any{"offset": float64(2), "limit": float64(3), "tail": float64(4)},
+			want:      page{offset: 2, limit: 3, tail: 4},
+		},
+		{
+			name:      "cursor over offset and tail",
+			arguments: map[string]any{"offset": float64(2), "limit": float64(3), "tail": float64(4), "cursor": encodeCursor(7)},
+			want:      page{offset: 7, limit: 3},
+		},
+		{
+			name:      "negative limit",
+			arguments: map[string]any{"limit": float64(-1)},
+			wantErr:   "limit must not be negative",
+		},
+		{
+			name:      "invalid cursor",
+			arguments: map[string]any{"cursor": "bogus"},
+			wantErr:   `invalid cursor "bogus"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pageArguments(toolRequest("test", tt.arguments))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got page %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginatedCursorRoundTrip(t *testing.T) {
+	var lines []string
+	for i := range 50 {
+		lines = append(lines, fmt.Sprintf("line-%02d", i))
+	}
+	s := &Server{configuration: Configuration{MaxResponseBytes: 64}}
+	handler := s.paginated(func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return NewTextResult(strings.Join(lines, "\n")+"\n", nil), nil
+	})
+
+	var read []string
+	arguments := map[string]any{}
+	for calls := 0; ; calls++ {
+		if calls > len(lines) {
+			t.Fatalf("still paginating after %d calls", calls)
+		}
+		result, err := handler(context.Background(), toolRequest("test", arguments))
+		if err != nil || result.IsError {
+			t.Fatalf("got result %+v, error %v", result, err)
+		}
+		for _, line := range splitLines(result.Content[0].(mcp.TextContent).Text) {
+			if !strings.HasPrefix(line, "[") {
+				read = append(read, line)
+			}
+		}
+		if result.Meta.AdditionalFields["totalLines"] != len(lines) {
+			t.Fatalf("got totalLines %v, want %d", result.Meta.AdditionalFields["totalLines"], len(lines))
+		}
+		cursor, ok := result.Meta.AdditionalFields["nextCursor"].(string)
+		if !ok {
+			break
+		}
+		arguments = map[string]any{"cursor": cursor}
+	}
+	if !reflect.DeepEqual(read, lines) {
+		t.Errorf("got lines %v, want %v", read, lines)
+	}
+}
+
+func TestPaginatedJSONAboveBudget(t *testing.T) {
+	s := &Server{configuration: Configuration{MaxResponseBytes: 64}}
+	document := `{"items":["` + strings.Repeat("x", 100) + `"]}`
+	handler := s.paginated(func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(document)}}, nil
+	})
+	result, err := handler(context.Background(), toolRequest("test", map[string]any{"format": "json"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if text == document || !strings.Contains(text, "above the response budget of 64 bytes") {
+		t.Errorf("got content %q for a JSON document above the budget", text)
+	}
+}
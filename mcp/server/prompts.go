@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxCompletionValues is the number of values a completion can return.
+const maxCompletionValues = 100
+
+const promptPreamble = `You are investigating an OpenShift cluster from a must-gather, a read only snapshot of the cluster.
+Only use the mustgather_* tools to read it, if no must-gather is selected ask for its location and call mustgather_use first.
+Quote the tool output backing each finding, and finish with a short summary listing the root cause candidates ordered by likelihood and the next steps.`
+
+// Register the triage prompts
+func (s *Server) initPrompts() []server.ServerPrompt {
+	return []server.ServerPrompt{
+		{Prompt: mcp.NewPrompt("triage_degraded_clusteroperators",
+			mcp.WithPromptDescription("Find the cluster operators which are degraded, unavailable or progressing and why"),
+			mcp.WithArgument("operator", mcp.ArgumentDescription("Cluster operator to focus on, all of them by default")),
+		), Handler: func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			operator := request.Params.Arguments["operator"]
+			target := "every cluster operator which is not Available, or is Degraded or Progressing"
+			if operator != "" {
+				target = fmt.Sprintf("the %s cluster operator", operator)
+			}
+			return promptResult("Triage of degraded cluster operators",
+				fmt.Sprintf(`Triage %s:
+1. Call mustgather_get with kind "clusteroperators" and read the AVAILABLE, PROGRESSING and DEGRADED columns.
+2. For each operator to triage call mustgather_get with kind "clusteroperators/<name>" and output "yaml", note the message and reason of its conditions and its related objects.
+3. Call mustgather_get with kind "pods" in the operator namespaces found in the related objects, look for pods which are not Running or have restarts.
+4. Call mustgather_events in those namespaces for the warnings around the time of the condition transitions.
+5. Call mustgather_logs for the operator pod and look for errors matching the condition message.
+6. When the message points to another operator or to nodes, follow it with the same steps or with mustgather_describe on the nodes.`, target)), nil
+		}},
+
+		{Prompt: mcp.NewPrompt("investigate_crashlooping_pod",
+			mcp.WithPromptDescription("Find why a pod is crash looping or not ready"),
+			mcp.WithArgument("namespace", mcp.ArgumentDescription("Namespace of the pod"), mcp.RequiredArgument()),
+			mcp.WithArgument("pod", mcp.ArgumentDescription("Name of the pod"), mcp.RequiredArgument()),
+		), Handler: func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			namespace := request.Params.Arguments["namespace"]
+			pod := request.Params.Arguments["pod"]
+			if namespace == "" || pod == "" {
+				return nil, fmt.Errorf("namespace and pod arguments are required")
+			}
+			return promptResult("Investigation of a crash looping pod",
+				fmt.Sprintf(`Investigate why the pod %[2]s in namespace %[1]s is crash looping:
+1. Call mustgather_describe with kind "pods", name "%[2]s" and namespace "%[1]s", note the state, last state, exit code and restart count of each container.
+2. For each container which restarted or is not ready call mustgather_logs with pod_name "%[2]s", namespace "%[1]s" and the container name, look at the last lines before the exit.
+3. Call mustgather_events with namespace "%[1]s" and for "pod/%[2]s" for probe failures, OOM kills, image pull or scheduling errors.
+4. Call mustgather_get with kind "pods/%[2]s", namespace "%[1]s" and output "yaml" to check the resources, probes and volumes of the failing containers.
+5. When the errors point to the node, call mustgather_describe with kind "nodes" and check its conditions and pressure, and mustgather_node_logs for kubelet and crio.`, namespace, pod)), nil
+		}},
+
+		{Prompt: mcp.NewPrompt("etcd_health_review",
+			mcp.WithPromptDescription("Review the health and performance of etcd"),
+		), Handler: func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			return promptResult("Review of etcd health",
+				`Review the health of etcd:
+1. Call mustgather_etcd_health and mustgather_etcd_status, check every member is healthy, has the same raft term and index, and the database size and leader.
+2. Call mustgather_get with kind "clusteroperators/etcd" and output "yaml" and read its conditions.
+3. Call mustgather_get with kind "pods" and namespace "openshift-etcd", check the etcd pods are Running on every control plane node without restarts.
+4. Call mustgather_logs for the etcd container of each etcd pod and look for "apply request took too long", "leader changed", "slow fdatasync" and "database space exceeded".
+5. Call mustgather_events with namespace "openshift-etcd" for the warnings.
+6. Conclude whether the disks, the network or the database size explain the findings.`), nil
+		}},
+
+		{Prompt: mcp.NewPrompt("upgrade_stuck",
+			mcp.WithPromptDescription("Find why a cluster upgrade is not progressing"),
+			mcp.WithArgument("operator", mcp.ArgumentDescription("Cluster operator suspected of blocking the upgrade")),
+		), Handler: func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			focus := ""
+			if operator := request.Params.Arguments["operator"]; operator != "" {
+				focus = fmt.Sprintf("\nThe %s cluster operator is suspected, check it first.", operator)
+			}
+			return promptResult("Investigation of a stuck upgrade",
+				`Find why the cluster upgrade is not progressing:
+1. Call mustgather_get with kind "clusterversion" and output "yaml", note the desired version, the history and the Failing and Progressing conditions.
+2. Call mustgather_get with kind "clusteroperators" and list the operators whose version is not the desired one or which are Degraded.
+3. Call mustgather_get with kind "machineconfigpools" and check the pools are updated, a degraded or paused pool blocks the node updates.
+4. Call mustgather_get with kind "nodes" and look for nodes NotReady or SchedulingDisabled, describe them with mustgather_describe.
+5. Call mustgather_events with namespace "openshift-cluster-version" and mustgather_logs for the cluster-version-operator pod.
+6. For the blocking operators follow the triage_degraded_clusteroperators steps.`+focus), nil
+		}},
+	}
+}
+
+// promptResult returns a prompt made of the investigation context and of the
+// steps to follow.
+func promptResult(description string, steps string) *mcp.GetPromptResult {
+	return mcp.NewGetPromptResult(description, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(promptPreamble)),
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(steps)),
+	})
+}
+
+// CompletePromptArgument completes the namespace, pod and operator arguments
+// of the prompts from the must-gather of the session.
+func (s *Server) CompletePromptArgument(ctx context.Context, promptName string, argument mcp.CompleteArgument, completeContext mcp.CompleteContext) (*mcp.Completion, error) {
+	root, ok := s.currentMustGather(ctx)
+	if !ok {
+		return &mcp.Completion{Values: []string{}}, nil
+	}
+	var names []string
+	switch argument.Name {
+	case "namespace":
+		names = listNames(filepath.Join(root, "namespaces"), "")
+	case "pod":
+		namespace := completeContext.Arguments["namespace"]
+		if namespace == "" {
+			break
+		}
+		// the namespace is a directory name, it can't point elsewhere
+		if !filepath.IsLocal(namespace) || filepath.Base(namespace) != namespace {
+			return nil, fmt.Errorf("invalid namespace %q", namespace)
+		}
+		names = listNames(filepath.Join(root, "namespaces", namespace, "pods"), "")
+	case "operator":
+		names = listNames(filepath.Join(root, "cluster-scoped-resources", "config.openshift.io", "clusteroperators"), ".yaml")
+	}
+	values := []string{}
+	for _, name := range names {
+		if strings.HasPrefix(name, argument.Value) {
+			values = append(values, name)
+		}
+	}
+	completion := &mcp.Completion{Values: values, Total: len(values)}
+	if len(values) > maxCompletionValues {
+		completion.Values = values[:maxCompletionValues]
+		completion.HasMore = true
+	}
+	return completion, nil
+}
+
+// listNames returns the sorted names of the entries of dir, the entries
+// ending with suffix when it is not empty, without the suffix.
+func listNames(dir string, suffix string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if suffix != "" {
+			if !strings.HasSuffix(name, suffix) {
+				continue
+			}
+			name = strings.TrimSuffix(name, suffix)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
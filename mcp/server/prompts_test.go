@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gmeghnag/omc/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestGetPrompt(t *testing.T) {
+	s := newTestServer(t, Configuration{})
+	prompts := map[string]func(context.Context, mcp.GetPromptRequest) (*mcp.GetPromptResult, error){}
+	for _, prompt := range s.initPrompts() {
+		prompts[prompt.Prompt.Name] = prompt.Handler
+	}
+
+	tests := []struct {
+		name      string
+		prompt    string
+		arguments map[string]string
+		want      []string
+		wantErr   string
+	}{
+		{name: "all cluster operators", prompt: "triage_degraded_clusteroperators", want: []string{"every cluster operator which is not Available"}},
+		{name: "one cluster operator", prompt: "triage_degraded_clusteroperators", arguments: map[string]string{"operator": "etcd"}, want: []string{"Triage the etcd cluster operator"}},
+		{
+			name:      "crash looping pod",
+			prompt:    "investigate_crashlooping_pod",
+			arguments: map[string]string{"namespace": "test-namespace", "pod": "test-pod"},
+			want:      []string{"the pod test-pod in namespace test-namespace", `pod_name "test-pod", namespace "test-namespace"`},
+		},
+		{name: "crash looping pod without pod", prompt: "investigate_crashlooping_pod", arguments: map[string]string{"namespace": "test-namespace"}, wantErr: "namespace and pod arguments are required"},
+		{name: "etcd", prompt: "etcd_health_review", want: []string{"mustgather_etcd_health"}},
+		{name: "stuck upgrade", prompt: "upgrade_stuck", arguments: map[string]string{"operator": "network"}, want: []string{"The network cluster operator is suspected"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := mcp.GetPromptRequest{Params: mcp.GetPromptParams{Name: tt.prompt, Arguments: tt.arguments}}
+			result, err := prompts[tt.prompt](context.Background(), request)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %+v, error %v, want %q", result, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Messages) != 2 {
+				t.Fatalf("got %d messages, want 2", len(result.Messages))
+			}
+			preamble, ok := result.Messages[0].Content.(mcp.TextContent)
+			if !ok || result.Messages[0].Role != mcp.RoleUser || preamble.Text != promptPreamble {
+				t.Errorf("got first message %+v, want the preamble", result.Messages[0])
+			}
+			steps, ok := result.Messages[1].Content.(mcp.TextContent)
+			if !ok || result.Messages[1].Role != mcp.RoleUser {
+				t.Fatalf("got second message %+v, want the steps", result.Messages[1])
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(steps.Text, want) {
+					t.Errorf("got steps %q, want %q in them", steps.Text, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCompletePromptArgument(t *testing.T) {
+	s := newTestServer(t, Configuration{})
+	files := map[string]string{
+		"cluster-scoped-resources/config.openshift.io/clusteroperators/etcd.yaml":        "kind: ClusterOperator\n",
+		"cluster-scoped-resources/config.openshift.io/clusteroperators/etcd-backup.yaml": "kind: ClusterOperator\n",
+		"cluster-scoped-resources/config.openshift.io/clusteroperators/network.yaml":     "kind: ClusterOperator\n",
+		"namespaces/openshift-etcd/pods/etcd-master-0/etcd-master-0.yaml":                "kind: Pod\n",
+		"namespaces/openshift-etcd/pods/etcd-master-1/etcd-master-1.yaml":                "kind: Pod\n",
+		"namespaces/openshift-etcd/pods/guard-master-0/guard-master-0.yaml":              "kind: Pod\n",
+		"namespaces/openshift-network/pods/network-operator-0/network-operator-0.yaml":   "kind: Pod\n",
+		"namespaces/openshift-network/core/pods.yaml":                                    "kind: PodList\n",
+		"secret/pods/credentials/credentials.yaml":                                       "kind: Secret\n",
+		"namespaces/many/pods/pod-000/pod-000.yaml":                                      "kind: Pod\n",
+	}
+	for i := 1; i <= maxCompletionValues; i++ {
+		files[fmt.Sprintf("namespaces/many/pods/pod-%03d/pod-%03d.yaml", i, i)] = "kind: Pod\n"
+	}
+	root := writeMustGather(t, files)
+	ctx := s.server.WithContext(context.Background(), testSession{id: "session"})
+	s.setSessionContext(ctx, types.Context{Id: "test", Path: root})
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		argument  string
+		value     string
+		namespace string
+		want      []string
+		total     int
+		hasMore   bool
+		wantErr   string
+	}{
+		{name: "namespaces", ctx: ctx, argument: "namespace", value: "openshift-", want: []string{"openshift-etcd", "openshift-network"}, total: 2},
+		{name: "pods", ctx: ctx, argument: "pod", value: "etcd", namespace: "openshift-etcd", want: []string{"etcd-master-0", "etcd-master-1"}, total: 2},
+		{name: "pods without namespace", ctx: ctx, argument: "pod", value: "etcd", want: []string{}},
+		{name: "operators", ctx: ctx, argument: "operator", value: "etcd", want: []string{"etcd", "etcd-backup"}, total: 2},
+		{name: "no match", ctx: ctx, argument: "operator", value: "dns", want: []string{}},
+		{name: "unknown argument", ctx: ctx, argument: "container", want: []string{}},
+		{name: "without must-gather", ctx: context.Background(), argument: "namespace", want: []string{}},
+		{name: "more values than returned", ctx: ctx, argument: "pod", value: "pod-", namespace: "many", total: maxCompletionValues + 1, hasMore: true},
+		{name: "parent directory", ctx: ctx, argument: "pod", namespace: "..", wantErr: `invalid namespace ".."`},
+		{name: "path traversal", ctx: ctx, argument: "pod", namespace: "../secret", wantErr: `invalid namespace "../secret"`},
+		{name: "nested path", ctx: ctx, argument: "pod", namespace: "openshift-etcd/pods/etcd-master-0", wantErr: "invalid namespace"},
+		{name: "absolute path", ctx: ctx, argument: "pod", namespace: root + "/secret", wantErr: "invalid namespace"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completeContext := mcp.CompleteContext{}
+			if tt.namespace != "" {
+				completeContext.Arguments = map[string]string{"namespace": tt.namespace}
+			}
+			completion, err := s.CompletePromptArgument(tt.ctx, "investigate_crashlooping_pod", mcp.CompleteArgument{Name: tt.argument, Value: tt.value}, completeContext)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %+v, error %v, want %q", completion, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if completion.Total != tt.total || completion.HasMore != tt.hasMore {
+				t.Errorf("got total %d and has more %t, want %d and %t", completion.Total, completion.HasMore, tt.total, tt.hasMore)
+			}
+			if tt.hasMore {
+				if len(completion.Values) != maxCompletionValues || completion.Values[0] != "pod-000" {
+					t.Errorf("got %d values starting with %v, want the first %d", len(completion.Values), completion.Values[:1], maxCompletionValues)
+				}
+				return
+			}
+			if strings.Join(completion.Values, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", completion.Values, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gmeghnag/omc/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	resourceScheme = "mustgather://"
+	// currentBundle is the bundle name resolving to the must-gather selected
+	// by the session reading the resource.
+	currentBundle = "current"
+	// maxResourceSize is the size above which files are not returned as
+	// resource contents, the omc tools should be used for them instead.
+	maxResourceSize = 16 << 20
+	uriListMIMEType = "text/uri-list"
+)
+
+// bundle is a must-gather exposed as MCP resources.
+type bundle struct {
+	id   string
+	path string
+
+	mu sync.Mutex
+	// owners holds the owners, see owner, of the requests which selected the
+	// must-gather. Only they can read it.
+	owners map[string]bool
+	// watcher watches the directories of the resources subscribed to, it is
+	// created by the first subscription.
+	watcher *fsnotify.Watcher
+	// subscriptions holds the URI each session subscribed to, keyed on the
+	// path of the resource and then on the session ID.
+	subscriptions map[string]map[string]string
+}
+
+// Register the must-gather resource templates
+func (s *Server) initResources() {
+	templates := []mcp.ResourceTemplate{
+		mcp.NewResourceTemplate(resourceScheme+"{bundle}/{+path}",
+			"Must-gather file",
+			mcp.WithTemplateDescription("Any file or directory of a must-gather, directories are returned as a text/uri-list. "+
+				"Use \"current\" as bundle to read the must-gather selected with mustgather_use."),
+		),
+		mcp.NewResourceTemplate(resourceScheme+"{bundle}/namespaces/{namespace}/{group}/{resource}.yaml",
+			"Namespaced resources",
+			mcp.WithTemplateDescription("List of the resources of a kind in a namespace, e.g. namespaces/openshift-etcd/core/pods.yaml"),
+			mcp.WithTemplateMIMEType("application/yaml"),
+		),
+		mcp.NewResourceTemplate(resourceScheme+"{bundle}/cluster-scoped-resources/{group}/{resource}/{name}.yaml",
+			"Cluster scoped resource",
+			mcp.WithTemplateDescription("A cluster scoped resource, e.g. cluster-scoped-resources/config.openshift.io/clusteroperators/etcd.yaml"),
+			mcp.WithTemplateMIMEType("application/yaml"),
+		),
+		mcp.NewResourceTemplate(resourceScheme+"{bundle}/namespaces/{namespace}/pods/{pod}/{container}/{container}/logs/{log}.log",
+			"Container logs",
+			mcp.WithTemplateDescription("Logs of a container, current.log, previous.log or previous.insecure.log"),
+			mcp.WithTemplateMIMEType("text/plain"),
+		),
+	}
+	for _, template := range templates {
+		s.server.AddResourceTemplate(template, s.readResource)
+	}
+}
+
+// owner returns who owns the must-gathers selected by a request: its
+// authenticated principal, so that the principal keeps them across its
+// sessions, or else its session.
+func owner(ctx context.Context) string {
+	if principal, ok := ctx.Value(principalKey{}).(*Principal); ok {
+		return "principal/" + principal.Name
+	}
+	return "session/" + sessionID(ctx)
+}
+
+// addBundle exposes the must-gather of mgContext as resources to the owner of
+// the request and lists its root.
+func (s *Server) addBundle(ctx context.Context, mgContext types.Context) {
+	b := &bundle{
+		id:            mgContext.Id,
+		path:          mgContext.Path,
+		owners:        make(map[string]bool),
+		subscriptions: make(map[string]map[string]string),
+	}
+	value, loaded := s.bundles.LoadOrStore(b.id, b)
+	b = value.(*bundle)
+	b.mu.Lock()
+	b.owners[owner(ctx)] = true
+	b.mu.Unlock()
+	if loaded {
+		return
+	}
+	s.server.AddResource(mcp.NewResource(resourceScheme+b.id+"/",
+		"must-gather "+filepath.Base(b.path),
+		mcp.WithResourceDescription("Root of the must-gather "+b.path),
+		mcp.WithMIMEType(uriListMIMEType),
+	), s.readResource)
+}
+
+// removeBundles stops exposing the must-gathers in dir as resources.
+func (s *Server) removeBundles(dir string) {
+	s.bundles.Range(func(key, value any) bool {
+		b := value.(*bundle)
+		if !isWithin(b.path, dir) {
+			return true
+		}
+		s.bundles.Delete(key)
+		s.server.DeleteResources(resourceScheme + b.id + "/")
+		b.close()
+		return true
+	})
+}
+
+// forgetOwner stops exposing the bundles to the session, its principal keeps
+// them.
+func (s *Server) forgetOwner(session string) {
+	s.bundles.Range(func(_, value any) bool {
+		b := value.(*bundle)
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.owners, "session/"+session)
+		return true
+	})
+}
+
+// ownedBy tells whether the owner of the request selected the bundle.
+func (b *bundle) ownedBy(ctx context.Context) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.owners[owner(ctx)]
+}
+
+// filterResources leaves the roots of the bundles which the owner of the
+// request did not select out of a resources/list result.
+func (s *Server) filterResources(ctx context.Context, _ any, _ *mcp.ListResourcesRequest, result *mcp.ListResourcesResult) {
+	if result == nil {
+		return
+	}
+	owned := make([]mcp.Resource, 0, len(result.Resources))
+	for _, resource := range result.Resources {
+		id, _, _ := strings.Cut(strings.TrimPrefix(resource.URI, resourceScheme), "/")
+		if _, err := s.lookupBundle(ctx, id); err == nil {
+			owned = append(owned, resource)
+		}
+	}
+	result.Resources = owned
+}
+
+// lookupBundle returns the bundle a resource URI refers to, the bundles which
+// the owner of the request did not select are unknown.
+func (s *Server) lookupBundle(ctx context.Context, id string) (*bundle, error) {
+	if id == currentBundle {
+		mgContext, ok := s.sessionContext(ctx)
+		if !ok {
+			return nil, fmt.Errorf("no must-gather selected in this session, use mustgather_use first")
+		}
+		id = mgContext.Id
+	}
+	b, ok := s.bundles.Load(id)
+	if !ok || !b.(*bundle).ownedBy(ctx) {
+		return nil, fmt.Errorf("unknown must-gather %q", id)
+	}
+	return b.(*bundle), nil
+}
+
+// resourcePath returns the bundle a resource URI refers to and the path of the
+// resource within it.
+func (s *Server) resourcePath(ctx context.Context, uri string) (*bundle, string, error) {
+	id, rel, ok := strings.Cut(strings.TrimPrefix(uri, resourceScheme), "/")
+	if !strings.HasPrefix(uri, resourceScheme) || !ok {
+		return nil, "", fmt.Errorf("invalid must-gather resource URI %q", uri)
+	}
+	b, err := s.lookupBundle(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	path, err := b.resolve(rel)
+	if err != nil {
+		return nil, "", err
+	}
+	return b, path, nil
+}
+
+// readResource implements the resources of the must-gather bundles.
+func (s *Server) readResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	uri := request.Params.URI
+	_, path, err := s.resourcePath(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("resource %q not found", uri)
+	}
+	if info.IsDir() {
+		return listDirectory(uri, path)
+	}
+	return readFile(uri, path, info.Size())
+}
+
+// resolve returns the path of rel within the bundle, refusing anything that
+// would escape the must-gather directory.
+func (b *bundle) resolve(rel string) (string, error) {
+	root, err := filepath.EvalSymlinks(b.path)
+	if err != nil {
+		return "", err
+	}
+	path, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return "", fmt.Errorf("resource %q not found", rel)
+	}
+	if path != root && !strings.HasPrefix(path, root+string(filepath.Separator)) {
+		return "", fmt.Errorf("resource %q is outside of the must-gather", rel)
+	}
+	return path, nil
+}
+
+// listDirectory returns the URIs of the entries of dir, directories end with
+// a slash.
+func listDirectory(uri string, dir string) ([]mcp.ResourceContents, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	uri = strings.TrimSuffix(uri, "/") + "/"
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		line := uri + entry.Name()
+		if entry.IsDir() {
+			line += "/"
+		}
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{URI: uri, MIMEType: uriListMIMEType, Text: strings.Join(lines, "\r\n")},
+	}, nil
+}
+
+// readFile returns the content of a must-gather file, as text when possible.
+func readFile(uri string, path string, size int64) ([]mcp.ResourceContents, error) {
+	if size > maxResourceSize {
+		return nil, fmt.Errorf("resource %q is too large (%d bytes), use the mustgather_* tools to read it", uri, size)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	mimeType := mimeTypeOf(path)
+	if utf8.Valid(data) && !strings.HasPrefix(mimeType, "application/gzip") {
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{URI: uri, MIMEType: mimeType, Text: string(data)},
+		}, nil
+	}
+	return []mcp.ResourceContents{
+		mcp.BlobResourceContents{URI: uri, MIMEType: mimeType, Blob: base64.StdEncoding.EncodeToString(data)},
+	}, nil
+}
+
+// mimeTypeOf returns the MIME type of a must-gather file from its name.
+func mimeTypeOf(path string) string {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		return "application/yaml"
+	case ".json":
+		return "application/json"
+	case ".log", ".txt", "":
+		return "text/plain"
+	case ".gz":
+		return "application/gzip"
+	default:
+		if mimeType := mime.TypeByExtension(ext); mimeType != "" {
+			return mimeType
+		}
+		return "application/octet-stream"
+	}
+}
@@ -0,0 +1,241 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gmeghnag/omc/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestBundleResolve(t *testing.T) {
+	outside := writeMustGather(t, map[string]string{"secret.txt": "secret"})
+	root := writeMustGather(t, map[string]string{
+		"namespaces/test-namespace/core/pods.yaml": "kind: PodList\n",
+		"timestamp": "2024-01-02\n",
+	})
+	for link, target := range map[string]string{
+		"inside":      filepath.Join(root, "namespaces"),
+		"relative":    "timestamp",
+		"outside":     outside,
+		"outside.txt": filepath.Join(outside, "secret.txt"),
+		"escape":      "../" + filepath.Base(outside),
+	} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a bundle selected through a symlink resolves within its target
+	linked := filepath.Join(t.TempDir(), "must-gather")
+	if err := os.Symlink(root, linked); err != nil {
+		t.Fatal(err)
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		rel     string
+		want    string
+		wantErr string
+	}{
+		{name: "root", rel: "", want: realRoot},
+		{name: "file", rel: "namespaces/test-namespace/core/pods.yaml", want: filepath.Join(realRoot, "namespaces/test-namespace/core/pods.yaml")},
+		{name: "symlink within the must-gather", rel: "inside/test-namespace", want: filepath.Join(realRoot, "namespaces/test-namespace")},
+		{name: "relative symlink", rel: "relative", want: filepath.Join(realRoot, "timestamp")},
+		{name: "missing file", rel: "missing.yaml", wantErr: `resource "missing.yaml" not found`},
+		{name: "parent directory", rel: "..", wantErr: `resource ".." is outside of the must-gather`},
+		{name: "dot dot path", rel: "namespaces/../../" + filepath.Base(outside) + "/secret.txt", wantErr: "is outside of the must-gather"},
+		{name: "symlink to a directory outside", rel: "outside/secret.txt", wantErr: `resource "outside/secret.txt" is outside of the must-gather`},
+		{name: "symlink to a file outside", rel: "outside.txt", wantErr: `resource "outside.txt" is outside of the must-gather`},
+		{name: "relative symlink outside", rel: "escape", wantErr: `resource "escape" is outside of the must-gather`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, path := range []string{root, linked} {
+				got, err := (&bundle{id: "test", path: path}).resolve(tt.rel)
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("got %q, error %v, want %q", got, err, tt.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != tt.want {
+					t.Errorf("got %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadResource(t *testing.T) {
+	s := newTestServer(t, Configuration{})
+	root := writeMustGather(t, map[string]string{
+		"namespaces/test-namespace/core/pods.yaml": "kind: PodList\n",
+		"timestamp": "2024-01-02\n",
+	})
+	session := s.server.WithContext(context.Background(), testSession{id: "session"})
+	s.setSessionContext(session, types.Context{Id: "test", Path: root})
+	s.addBundle(session, types.Context{Id: "test", Path: root})
+	other := s.server.WithContext(context.Background(), testSession{id: "other"})
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		uri      string
+		mimeType string
+		text     string
+		wantErr  string
+	}{
+		{
+			name:     "root",
+			ctx:      session,
+			uri:      "mustgather://test/",
+			mimeType: uriListMIMEType,
+			text:     "mustgather://test/namespaces/\r\nmustgather://test/timestamp",
+		},
+		{
+			name:     "file",
+			ctx:      session,
+			uri:      "mustgather://test/namespaces/test-namespace/core/pods.yaml",
+			mimeType: "application/yaml",
+			text:     "kind: PodList\n",
+		},
+		{
+			name:     "current bundle",
+			ctx:      session,
+			uri:      "mustgather://current/timestamp",
+			mimeType: "text/plain",
+			text:     "2024-01-02\n",
+		},
+		{name: "current bundle without must-gather", ctx: other, uri: "mustgather://current/timestamp", wantErr: "no must-gather selected in this session"},
+		{name: "unknown bundle", ctx: session, uri: "mustgather://unknown/timestamp", wantErr: `unknown must-gather "unknown"`},
+		{name: "bundle of another session", ctx: other, uri: "mustgather://test/timestamp", wantErr: `unknown must-gather "test"`},
+		{name: "outside of the must-gather", ctx: session, uri: "mustgather://test/..", wantErr: `resource ".." is outside of the must-gather`},
+		{name: "invalid URI", ctx: session, uri: "file:///etc/passwd", wantErr: "invalid must-gather resource URI"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := mcp.ReadResourceRequest{Params: mcp.ReadResourceParams{URI: tt.uri}}
+			contents, err := s.readResource(tt.ctx, request)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got contents %+v, error %v, want %q", contents, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			text, ok := contents[0].(mcp.TextResourceContents)
+			if len(contents) != 1 || !ok || text.MIMEType != tt.mimeType || text.Text != tt.text {
+				t.Errorf("got contents %+v, want %s %q", contents, tt.mimeType, tt.text)
+			}
+		})
+	}
+}
+
+func TestRemoveBundles(t *testing.T) {
+	s := newTestServer(t, Configuration{})
+	extraction := t.TempDir()
+	s.addBundle(context.Background(), types.Context{Id: "extracted", Path: filepath.Join(extraction, "must-gather")})
+	s.addBundle(context.Background(), types.Context{Id: "local", Path: t.TempDir()})
+
+	s.removeBundles(extraction)
+	if _, ok := s.bundles.Load("extracted"); ok {
+		t.Error("the bundle of a removed extraction is still exposed")
+	}
+	if _, ok := s.bundles.Load("local"); !ok {
+		t.Error("a bundle outside of the removed extraction is no longer exposed")
+	}
+}
+
+func TestBundleOwnership(t *testing.T) {
+	s := newTestServer(t, Configuration{})
+	root := writeMustGather(t, map[string]string{"timestamp": "2024-01-02\n"})
+	first := s.server.WithContext(context.Background(), testSession{id: "first"})
+	second := s.server.WithContext(context.Background(), testSession{id: "second"})
+	s.addBundle(first, types.Context{Id: "first", Path: root})
+	alice := &Principal{Name: "alice"}
+	aliceFirst := context.WithValue(s.server.WithContext(context.Background(), testSession{id: "alice-first"}), principalKey{}, alice)
+	aliceSecond := context.WithValue(s.server.WithContext(context.Background(), testSession{id: "alice-second"}), principalKey{}, alice)
+	bob := context.WithValue(s.server.WithContext(context.Background(), testSession{id: "bob"}), principalKey{}, &Principal{Name: "bob"})
+	s.addBundle(aliceFirst, types.Context{Id: "alice", Path: root})
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		listed []string
+		read   map[string]bool
+	}{
+		{name: "owner session", ctx: first, listed: []string{"mustgather://first/"}, read: map[string]bool{"first": true, "alice": false}},
+		{name: "other session", ctx: second, listed: []string{}, read: map[string]bool{"first": false, "alice": false}},
+		{name: "owner principal", ctx: aliceFirst, listed: []string{"mustgather://alice/"}, read: map[string]bool{"first": false, "alice": true}},
+		{name: "other session of the owner principal", ctx: aliceSecond, listed: []string{"mustgather://alice/"}, read: map[string]bool{"first": false, "alice": true}},
+		{name: "other principal", ctx: bob, listed: []string{}, read: map[string]bool{"first": false, "alice": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &mcp.ListResourcesResult{Resources: []mcp.Resource{
+				mcp.NewResource("mustgather://alice/", "must-gather alice"),
+				mcp.NewResource("mustgather://first/", "must-gather first"),
+			}}
+			s.filterResources(tt.ctx, nil, nil, result)
+			listed := []string{}
+			for _, resource := range result.Resources {
+				listed = append(listed, resource.URI)
+			}
+			if strings.Join(listed, ",") != strings.Join(tt.listed, ",") {
+				t.Errorf("got resources %v, want %v", listed, tt.listed)
+			}
+			for id, want := range tt.read {
+				request := mcp.ReadResourceRequest{Params: mcp.ReadResourceParams{URI: "mustgather://" + id + "/timestamp"}}
+				if _, err := s.readResource(tt.ctx, request); (err == nil) != want {
+					t.Errorf("got error %v reading %s, want read %t", err, id, want)
+				}
+				if _, err := s.bundlePath(tt.ctx, id); (err == nil) != want {
+					t.Errorf("got error %v comparing %s, want compared %t", err, id, want)
+				}
+			}
+		})
+	}
+
+	s.forgetOwner("first")
+	request := mcp.ReadResourceRequest{Params: mcp.ReadResourceParams{URI: "mustgather://first/timestamp"}}
+	if _, err := s.readResource(first, request); err == nil {
+		t.Error("the bundle of an ended session can still be read")
+	}
+}
+
+func TestMustGatherRows(t *testing.T) {
+	s := newTestServer(t, Configuration{})
+	session := s.server.WithContext(context.Background(), testSession{id: "session"})
+	other := s.server.WithContext(context.Background(), testSession{id: "other"})
+	first := types.Context{Id: "first", Path: "/must-gathers/first", Project: "test-namespace"}
+	second := types.Context{Id: "second", Path: "/must-gathers/second", Project: "other-namespace"}
+	s.addBundle(session, second)
+	s.addBundle(session, first)
+	s.setSessionContext(session, first)
+	s.addBundle(other, types.Context{Id: "other", Path: "/must-gathers/other"})
+
+	got := s.mustGatherRows(session)
+	want := [][]string{
+		{"*", "first", "/must-gathers/first", "test-namespace"},
+		{"", "second", "/must-gathers/second", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := s.mustGatherRows(context.Background()); len(got) != 0 {
+		t.Errorf("got %v without session, want no must-gather", got)
+	}
+}
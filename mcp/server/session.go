@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gmeghnag/omc/types"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// sessionID returns the ID of the MCP session the request context belongs to,
+// or an empty string when the request is not bound to a session.
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+// sessionContext returns the must-gather context selected by the session of
+// the request, if any.
+func (s *Server) sessionContext(ctx context.Context) (types.Context, bool) {
+	state, ok := s.contexts.Load(sessionID(ctx))
+	if !ok {
+		return types.Context{}, false
+	}
+	s.lastUsed.Store(sessionID(ctx), time.Now())
+	return state.(types.Context), true
+}
+
+// setSessionContext makes mgContext the must-gather used by the tools called
+// from the session of the request.
+func (s *Server) setSessionContext(ctx context.Context, mgContext types.Context) {
+	s.contexts.Store(sessionID(ctx), mgContext)
+	s.lastUsed.Store(sessionID(ctx), time.Now())
+}
+
+// expireSessions forgets the must-gather of the sessions idle for longer than
+// the extraction retention, so that it can be cleaned up. The Streamable HTTP
+// sessions are only unregistered when their client deletes them, which many
+// clients never do.
+func (s *Server) expireSessions() {
+	s.lastUsed.Range(func(id, lastUsed any) bool {
+		if time.Since(lastUsed.(time.Time)) > s.configuration.ExtractionRetention {
+			log.Printf("Releasing the must-gather of the idle session %q\n", id)
+			s.contexts.Delete(id)
+			s.lastUsed.Delete(id)
+		}
+		return true
+	})
+}
+
+// currentMustGather returns the root directory of the must-gather used by the
+// session of the request, falling back to the current context of the omc
+// config file like the tools do.
+func (s *Server) currentMustGather(ctx context.Context) (string, bool) {
+	if mgContext, ok := s.sessionContext(ctx); ok {
+		return mgContext.Path, true
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", false
+	}
+	file, err := os.ReadFile(filepath.Join(home, ".omc", "omc.json"))
+	if err != nil {
+		return "", false
+	}
+	config := types.Config{}
+	if err := json.Unmarshal(file, &config); err != nil {
+		return "", false
+	}
+	for _, mgContext := range config.Contexts {
+		if mgContext.Current != "*" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(mgContext.Path, "namespaces")); err == nil {
+			return mgContext.Path, true
+		}
+		// the context can point to the directory holding the must-gather
+		entries, err := os.ReadDir(mgContext.Path)
+		if err != nil {
+			return "", false
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				return filepath.Join(mgContext.Path, entry.Name()), true
+			}
+		}
+	}
+	return "", false
+}
+
+// mustGatherRoot returns the root directory of the must-gather used by the
+// session of the request, or an error when there is none.
+func (s *Server) mustGatherRoot(ctx context.Context) (string, error) {
+	root, ok := s.currentMustGather(ctx)
+	if !ok {
+		return "", fmt.Errorf("no must-gather selected, use mustgather_use first")
+	}
+	return root, nil
+}
+
+// sessionDocument returns the JSON document describing the must-gather
+// selected by the session of the request.
+func (s *Server) sessionDocument(ctx context.Context) map[string]any {
+	mgContext, _ := s.sessionContext(ctx)
+	return map[string]any{
+		"id":        mgContext.Id,
+		"path":      mgContext.Path,
+		"project":   mgContext.Project,
+		"resources": resourceScheme + mgContext.Id + "/",
+	}
+}
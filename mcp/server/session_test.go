@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gmeghnag/omc/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// testSession is an MCP session which drops its notifications.
+type testSession struct {
+	id string
+}
+
+func (s testSession) Initialize()       {}
+func (s testSession) Initialized() bool { return true }
+func (s testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return make(chan mcp.JSONRPCNotification, 10)
+}
+func (s testSession) SessionID() string { return s.id }
+
+// writeMustGather writes the files, keyed on their path, of a must-gather and
+// returns its root directory.
+func writeMustGather(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// podMustGather returns the root of a must-gather holding a single pod.
+func podMustGather(t *testing.T, pod string) string {
+	return writeMustGather(t, map[string]string{
+		"namespaces/test-namespace/core/pods.yaml": `apiVersion: v1
+kind: PodList
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: ` + pod + `
+    namespace: test-namespace
+`,
+	})
+}
+
+// newTestServer returns a server whose omc config file, in a new home
+// directory, selects no must-gather.
+func newTestServer(t *testing.T, configuration Configuration) *Server {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	s, err := NewSever(configuration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestSessionContextIsolation(t *testing.T) {
+	s := newTestServer(t, Configuration{})
+	first := s.server.WithContext(context.Background(), testSession{id: "first"})
+	second := s.server.WithContext(context.Background(), testSession{id: "second"})
+	other := s.server.WithContext(context.Background(), testSession{id: "other"})
+	s.setSessionContext(first, types.Context{Id: "first", Path: podMustGather(t, "first-pod"), Project: "test-namespace"})
+	s.setSessionContext(second, types.Context{Id: "second", Path: podMustGather(t, "second-pod"), Project: "test-namespace"})
+
+	for ctx, want := range map[context.Context]string{first: "pod/first-pod", second: "pod/second-pod"} {
+		output, err := s.executeOMCCommand(ctx, []string{"get", "pods", "-o", "name"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimSpace(output) != want {
+			t.Errorf("got %q in session %s, want %q", output, sessionID(ctx), want)
+		}
+	}
+	if mgContext, ok := s.sessionContext(other); ok {
+		t.Errorf("got context %+v in a session which didn't select one", mgContext)
+	}
+	if _, err := s.mustGatherRoot(other); err == nil {
+		t.Error("got a must-gather root in a session which didn't select one")
+	}
+}
+
+func TestSessionContextForgottenOnUnregister(t *testing.T) {
+	s := newTestServer(t, Configuration{})
+	session := testSession{id: "ended"}
+	ctx := s.server.WithContext(context.Background(), session)
+	if err := s.server.RegisterSession(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	s.setSessionContext(ctx, types.Context{Id: "ended", Path: podMustGather(t, "pod")})
+	s.server.UnregisterSession(ctx, session.SessionID())
+	if _, ok := s.sessionContext(ctx); ok {
+		t.Error("the context of an unregistered session is still selected")
+	}
+	if _, ok := s.lastUsed.Load(session.SessionID()); ok {
+		t.Error("the last use of an unregistered session is still recorded")
+	}
+}
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// mcp-go doesn't handle the resources/subscribe and resources/unsubscribe
+// requests, the transports answer them with handleSubscription and pass the
+// other messages to mcp-go.
+const (
+	methodSubscribe   = "resources/subscribe"
+	methodUnsubscribe = "resources/unsubscribe"
+	// stdioSessionID is the ID mcp-go gives to the stdio session.
+	stdioSessionID = "stdio"
+)
+
+// subscriptionRequest is a resources/subscribe or resources/unsubscribe
+// request.
+type subscriptionRequest struct {
+	ID     mcp.RequestId       `json:"id"`
+	Method string              `json:"method"`
+	Params mcp.SubscribeParams `json:"params"`
+}
+
+// subscriber is a session to notify of the changes of a resource.
+type subscriber struct {
+	session string
+	uri     string
+}
+
+// parseSubscription returns the subscription request in message, false when
+// it is another message.
+func parseSubscription(message []byte) (subscriptionRequest, bool) {
+	request := subscriptionRequest{}
+	if err := json.Unmarshal(message, &request); err != nil || request.ID.IsNil() {
+		return request, false
+	}
+	return request, request.Method == methodSubscribe || request.Method == methodUnsubscribe
+}
+
+// handleSubscription subscribes the session of the request to a resource or
+// unsubscribes it, and returns the response.
+func (s *Server) handleSubscription(ctx context.Context, request subscriptionRequest) mcp.JSONRPCMessage {
+	var err error
+	if request.Method == methodSubscribe {
+		err = s.subscribe(ctx, request.Params.URI)
+	} else {
+		s.unsubscribe(sessionID(ctx), request.Params.URI)
+	}
+	if err != nil {
+		return mcp.NewJSONRPCError(request.ID, mcp.INVALID_PARAMS, err.Error(), nil)
+	}
+	return mcp.NewJSONRPCResponse(request.ID, mcp.Result{})
+}
+
+// subscribe sends a notifications/resources/updated notification to the
+// session of the request each time the resource at uri changes, or one of its
+// entries when it is a directory.
+func (s *Server) subscribe(ctx context.Context, uri string) error {
+	session := sessionID(ctx)
+	if session == "" {
+		return fmt.Errorf("subscribing to resources requires a session")
+	}
+	b, path, err := s.resourcePath(ctx, uri)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("resource %q not found", uri)
+	}
+	dir := path
+	if !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.watcher == nil {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("unable to watch must-gather %s: %w", b.path, err)
+		}
+		b.watcher = watcher
+		go s.notifyChanges(b, watcher)
+	}
+	if err := b.watcher.Add(dir); err != nil {
+		return fmt.Errorf("unable to watch %s: %w", uri, err)
+	}
+	if b.subscriptions[path] == nil {
+		b.subscriptions[path] = make(map[string]string)
+	}
+	b.subscriptions[path][session] = uri
+	return nil
+}
+
+// unsubscribe stops notifying the session of the changes of the resource at
+// uri, or of every resource when uri is empty.
+func (s *Server) unsubscribe(session string, uri string) {
+	s.bundles.Range(func(_, value any) bool {
+		b := value.(*bundle)
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for path, sessions := range b.subscriptions {
+			if subscribed, ok := sessions[session]; ok && (uri == "" || subscribed == uri) {
+				delete(sessions, session)
+				if len(sessions) == 0 {
+					delete(b.subscriptions, path)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// notifyChanges sends the notifications/resources/updated notifications of the
+// changes seen by the watcher of the bundle to the sessions subscribed to them.
+func (s *Server) notifyChanges(b *bundle, watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			for _, subscriber := range b.subscribers(event.Name) {
+				err := s.server.SendNotificationToSpecificClient(subscriber.session, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": subscriber.uri})
+				if err != nil {
+					log.Printf("unable to notify session %q of the change of %s: %v", subscriber.session, subscriber.uri, err)
+				}
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("error watching must-gather %s: %v", b.path, err)
+		}
+	}
+}
+
+// subscribers returns the sessions to notify of a change of path: those
+// subscribed to it and to its directory.
+func (b *bundle) subscribers(path string) []subscriber {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var subscribers []subscriber
+	for _, subscribed := range []string{path, filepath.Dir(path)} {
+		for session, uri := range b.subscriptions[subscribed] {
+			subscribers = append(subscribers, subscriber{session: session, uri: uri})
+		}
+	}
+	return subscribers
+}
+
+// close stops watching the bundle.
+func (b *bundle) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.watcher != nil {
+		_ = b.watcher.Close()
+		b.watcher = nil
+	}
+}
+
+// withSession returns ctx bound to the registered session id, ctx itself when
+// there is none.
+func (s *Server) withSession(ctx context.Context, id string) context.Context {
+	if session, ok := s.sessions.Load(id); ok {
+		return s.server.WithContext(ctx, session.(server.ClientSession))
+	}
+	return ctx
+}
+
+// subscriptions answers the subscription requests posted to next. session
+// returns the session ID of a request, and reply sends the response to the
+// client of the session.
+func (s *Server) subscriptions(next http.Handler, session func(r *http.Request) string, reply func(w http.ResponseWriter, session string, response mcp.JSONRPCMessage)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("read request body error: %v", err), http.StatusBadRequest)
+			return
+		}
+		request, ok := parseSubscription(body)
+		if !ok {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+			return
+		}
+		id := session(r)
+		reply(w, id, s.handleSubscription(s.withSession(r.Context(), id), request))
+	})
+}
+
+// stdioSubscriptions returns the messages read from in but the subscription
+// requests, which are answered on out.
+func (s *Server) stdioSubscriptions(ctx context.Context, in io.Reader, out io.Writer) io.Reader {
+	messages, writer := io.Pipe()
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if request, ok := parseSubscription(line); ok {
+				response, _ := json.Marshal(s.handleSubscription(s.withSession(ctx, stdioSessionID), request))
+				if _, err := out.Write(append(response, '\n')); err != nil {
+					log.Printf("unable to write the response of %s: %v", request.Method, err)
+				}
+			} else if len(line) > 0 {
+				if _, err := writer.Write(line); err != nil {
+					return
+				}
+			}
+			if err != nil {
+				_ = writer.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return messages
+}
+
+// syncWriter serializes the writes of the stdio server and of the
+// subscription responses.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
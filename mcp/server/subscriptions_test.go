@@ -0,0 +1,211 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gmeghnag/omc/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// notifiedSession is an MCP session which keeps its notifications.
+type notifiedSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+}
+
+func newNotifiedSession(id string) notifiedSession {
+	return notifiedSession{id: id, notifications: make(chan mcp.JSONRPCNotification, 10)}
+}
+
+func (s notifiedSession) Initialize()       {}
+func (s notifiedSession) Initialized() bool { return true }
+func (s notifiedSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+func (s notifiedSession) SessionID() string { return s.id }
+
+// registerSession registers session with the server and returns the context
+// of its requests.
+func registerSession(t *testing.T, s *Server, session server.ClientSession) context.Context {
+	t.Helper()
+	if err := s.server.RegisterSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.server.UnregisterSession(context.Background(), session.SessionID()) })
+	return s.withSession(context.Background(), session.SessionID())
+}
+
+// subscriptionMessage returns a resources/subscribe or resources/unsubscribe
+// request of uri.
+func subscriptionMessage(method string, uri string) []byte {
+	return []byte(`{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":{"uri":"` + uri + `"}}`)
+}
+
+func TestParseSubscription(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    bool
+		uri     string
+	}{
+		{name: "subscribe", message: string(subscriptionMessage(methodSubscribe, "mustgather://test/")), want: true, uri: "mustgather://test/"},
+		{name: "unsubscribe", message: string(subscriptionMessage(methodUnsubscribe, "mustgather://test/")), want: true, uri: "mustgather://test/"},
+		{name: "other method", message: `{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"mustgather://test/"}}`},
+		{name: "notification", message: `{"jsonrpc":"2.0","method":"resources/subscribe","params":{"uri":"mustgather://test/"}}`},
+		{name: "invalid JSON", message: `{"jsonrpc"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, ok := parseSubscription([]byte(tt.message))
+			if ok != tt.want || (ok && request.Params.URI != tt.uri) {
+				t.Errorf("got %+v, %t, want %t %q", request, ok, tt.want, tt.uri)
+			}
+		})
+	}
+}
+
+func TestSubscriptionNotifiesOnlySubscribedSessions(t *testing.T) {
+	s := newTestServer(t, Configuration{})
+	root := writeMustGather(t, map[string]string{"namespaces/test-namespace/core/pods.yaml": "kind: PodList\n"})
+	subscribed := newNotifiedSession("subscribed")
+	other := newNotifiedSession("other")
+	// both sessions select the must-gather before registering, so that they
+	// are not notified of the new bundle
+	s.addBundle(s.server.WithContext(context.Background(), subscribed), types.Context{Id: "test", Path: root})
+	s.addBundle(s.server.WithContext(context.Background(), other), types.Context{Id: "test", Path: root})
+	ctx := registerSession(t, s, subscribed)
+	registerSession(t, s, other)
+
+	uri := "mustgather://test/namespaces/test-namespace/core/pods.yaml"
+	request, _ := parseSubscription(subscriptionMessage(methodSubscribe, uri))
+	if response, ok := s.handleSubscription(ctx, request).(mcp.JSONRPCResponse); !ok {
+		t.Fatalf("got %+v, want a response", response)
+	}
+	pods := filepath.Join(root, "namespaces/test-namespace/core/pods.yaml")
+	if err := os.WriteFile(pods, []byte("kind: PodList\nitems: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case notification := <-subscribed.notifications:
+		if notification.Method != mcp.MethodNotificationResourceUpdated || notification.Params.AdditionalFields["uri"] != uri {
+			t.Errorf("got notification %+v, want %s of %s", notification, mcp.MethodNotificationResourceUpdated, uri)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the subscribed session was not notified of the change")
+	}
+	select {
+	case notification := <-other.notifications:
+		t.Errorf("got notification %+v in a session which did not subscribe", notification)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// a write is seen as several changes, their notifications are left out
+	for len(subscribed.notifications) > 0 {
+		<-subscribed.notifications
+	}
+
+	request, _ = parseSubscription(subscriptionMessage(methodUnsubscribe, uri))
+	s.handleSubscription(ctx, request)
+	if err := os.WriteFile(pods, []byte("kind: PodList\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case notification := <-subscribed.notifications:
+		t.Errorf("got notification %+v after unsubscribing", notification)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSubscribeErrors(t *testing.T) {
+	s := newTestServer(t, Configuration{})
+	ctx := registerSession(t, s, newNotifiedSession("session"))
+	s.addBundle(ctx, types.Context{Id: "test", Path: writeMustGather(t, map[string]string{"timestamp": "2024-01-02\n"})})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		uri     string
+		wantErr string
+	}{
+		{name: "without session", ctx: context.Background(), uri: "mustgather://test/timestamp", wantErr: "subscribing to resources requires a session"},
+		{name: "missing resource", ctx: ctx, uri: "mustgather://test/missing", wantErr: `resource "missing" not found`},
+		{name: "unknown bundle", ctx: ctx, uri: "mustgather://unknown/timestamp", wantErr: `unknown must-gather "unknown"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, _ := parseSubscription(subscriptionMessage(methodSubscribe, tt.uri))
+			response, ok := s.handleSubscription(tt.ctx, request).(mcp.JSONRPCError)
+			if !ok || response.Error.Code != mcp.INVALID_PARAMS || !strings.Contains(response.Error.Message, tt.wantErr) {
+				t.Errorf("got %+v, want error %q", response, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStdioSubscriptions(t *testing.T) {
+	s := newTestServer(t, Configuration{})
+	ctx := registerSession(t, s, newNotifiedSession(stdioSessionID))
+	s.addBundle(ctx, types.Context{Id: "test", Path: writeMustGather(t, map[string]string{"timestamp": "2024-01-02\n"})})
+
+	ping := `{"jsonrpc":"2.0","id":2,"method":"ping"}` + "\n"
+	in := strings.NewReader(string(subscriptionMessage(methodSubscribe, "mustgather://test/timestamp")) + "\n" + ping)
+	out := &bytes.Buffer{}
+	messages, err := io.ReadAll(s.stdioSubscriptions(context.Background(), in, out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(messages) != ping {
+		t.Errorf("got messages %q, want %q", messages, ping)
+	}
+	if want := `{"jsonrpc":"2.0","id":1,"result":{}}` + "\n"; out.String() != want {
+		t.Errorf("got response %q, want %q", out.String(), want)
+	}
+}
+
+func TestSubscriptionsHandler(t *testing.T) {
+	s := newTestServer(t, Configuration{})
+	ctx := registerSession(t, s, newNotifiedSession("session"))
+	s.addBundle(ctx, types.Context{Id: "test", Path: writeMustGather(t, map[string]string{"timestamp": "2024-01-02\n"})})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(append([]byte("next: "), body...))
+	})
+	reply := func(w http.ResponseWriter, session string, response mcp.JSONRPCMessage) {
+		_ = json.NewEncoder(w).Encode(response)
+	}
+	handler := s.subscriptions(next, func(r *http.Request) string { return r.Header.Get(server.HeaderKeySessionID) }, reply)
+
+	ping := `{"jsonrpc":"2.0","id":2,"method":"ping"}`
+	tests := []struct {
+		name    string
+		session string
+		body    string
+		want    string
+	}{
+		{name: "other request", session: "session", body: ping, want: "next: " + ping},
+		{name: "subscription", session: "session", body: string(subscriptionMessage(methodSubscribe, "mustgather://test/timestamp")), want: `{"jsonrpc":"2.0","id":1,"result":{}}`},
+		{name: "unknown session", session: "unknown", body: string(subscriptionMessage(methodSubscribe, "mustgather://test/timestamp")), want: "subscribing to resources requires a session"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(tt.body))
+			r.Header.Set(server.HeaderKeySessionID, tt.session)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("got %q, want %q", w.Body.String(), tt.want)
+			}
+		})
+	}
+}
@@ -16,8 +16,6 @@ limitations under the License.
 package certs
 
 import (
-	"os"
-
 	"github.com/gmeghnag/omc/vars"
 	"github.com/spf13/cobra"
 )
@@ -27,9 +25,8 @@ var listNonCerts, showParseFailure bool
 var Certs = &cobra.Command{
 	Use:   "certs",
 	Short: "Inspect cluster certificates.",
-	Run: func(cmd *cobra.Command, args []string) {
-		cmd.Help()
-		os.Exit(0)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
 	},
 }
 
@@ -40,5 +37,6 @@ func init() {
 	Certs.PersistentFlags().BoolVarP(&vars.AllNamespaceBoolVar, "all-namespaces", "A", false, "If present, list the requested object(s) across all namespaces.")
 	Certs.PersistentFlags().BoolVarP(&listNonCerts, "list-non-certs", "", false, "If present, list resources regardless if it contains a certificate.")
 	Certs.PersistentFlags().BoolVarP(&showParseFailure, "show-parse-failure", "", false, "If present, list the output of parse attempts for resources.")
+	Certs.PersistentFlags().StringVarP(&vars.LabelSelectorStringVar, "selector", "l", "", "selector (label query) to filter on, supports '=', '==', '!=', 'in', 'notin' and existence.(e.g. -l key1=value1,key2 in (value2,value3),!key3)")
 	Certs.PersistentFlags().StringVarP(&vars.OutputStringVar, "output", "o", "", "Output format. One of: json|yaml|wide")
 }
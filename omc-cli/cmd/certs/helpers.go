@@ -3,7 +3,6 @@ package certs
 import (
 	"fmt"
 	"io/ioutil"
-	"os"
 
 	"github.com/gmeghnag/omc/cmd/helpers"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -16,7 +15,7 @@ type ResourcesItems struct {
 	Items      []*unstructured.Unstructured `json:"items"`
 }
 
-func GetSecrets(currentContextPath string, namespace string, resourceName string, allNamespacesFlag bool, out *[]*unstructured.Unstructured) {
+func GetSecrets(currentContextPath string, namespace string, resourceName string, allNamespacesFlag bool, out *[]*unstructured.Unstructured) error {
 	var namespaces []string
 	if allNamespacesFlag == true {
 		namespace = "all"
@@ -36,17 +35,17 @@ func GetSecrets(currentContextPath string, namespace string, resourceName string
 			continue
 		}
 		if err := yaml.Unmarshal([]byte(_file), &_Items); err != nil {
-			fmt.Fprintln(os.Stderr, "Error when trying to unmarshal file "+CurrentNamespacePath+"/core/secrets.yaml")
-			os.Exit(1)
+			return fmt.Errorf("Error when trying to unmarshal file %s/core/secrets.yaml", CurrentNamespacePath)
 		}
 
 		for _, Secret := range _Items.Items {
 			*out = append(*out, Secret)
 		}
 	}
+	return nil
 }
 
-func GetConfigMaps(currentContextPath string, namespace string, resourceName string, allNamespacesFlag bool, out *[]*unstructured.Unstructured) {
+func GetConfigMaps(currentContextPath string, namespace string, resourceName string, allNamespacesFlag bool, out *[]*unstructured.Unstructured) error {
 	var namespaces []string
 	if allNamespacesFlag == true {
 		namespace = "all"
@@ -66,28 +65,31 @@ func GetConfigMaps(currentContextPath string, namespace string, resourceName str
 			continue
 		}
 		if err := yaml.Unmarshal([]byte(_file), &_Items); err != nil {
-			fmt.Fprintln(os.Stderr, "Error when trying to unmarshal file "+CurrentNamespacePath+"/core/configmaps.yaml")
-			os.Exit(1)
+			return fmt.Errorf("Error when trying to unmarshal file %s/core/configmaps.yaml", CurrentNamespacePath)
 		}
 		for _, ConfigMap := range _Items.Items {
 			*out = append(*out, ConfigMap)
 		}
 	}
+	return nil
 }
 
-func GetCertificateSigningRequests(currentContextPath string, namespace string, resourceName string, allNamespacesFlag bool, out *[]unstructured.Unstructured) {
+func GetCertificateSigningRequests(currentContextPath string, namespace string, resourceName string, allNamespacesFlag bool, out *[]unstructured.Unstructured) error {
 
 	certificatesigningrequestsFolderPath := currentContextPath + "/cluster-scoped-resources/certificates.k8s.io/certificatesigningrequests/"
 	_certificatesigningrequests, _ := ioutil.ReadDir(certificatesigningrequestsFolderPath)
 
 	for _, f := range _certificatesigningrequests {
 		certificatesigningrequestYamlPath := certificatesigningrequestsFolderPath + f.Name()
-		_file := helpers.ReadYaml(certificatesigningrequestYamlPath)
+		_file, err := helpers.ReadYaml(certificatesigningrequestYamlPath)
+		if err != nil {
+			return err
+		}
 		CertificateSigningRequest := unstructured.Unstructured{}
 		if err := yaml.Unmarshal([]byte(_file), &CertificateSigningRequest); err != nil {
-			fmt.Fprintln(os.Stderr, "Error when trying to unmarshal file: "+certificatesigningrequestYamlPath)
-			os.Exit(1)
+			return fmt.Errorf("Error when trying to unmarshal file: %s", certificatesigningrequestYamlPath)
 		}
 		*out = append(*out, CertificateSigningRequest)
 	}
+	return nil
 }
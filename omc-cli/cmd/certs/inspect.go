@@ -20,7 +20,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 
 	"github.com/gmeghnag/omc/cmd/helpers"
@@ -29,6 +28,7 @@ import (
 	certificatesv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/util/cert"
 )
@@ -47,12 +47,12 @@ func getSupportedCaKeyNames() []string {
 var Inspect = &cobra.Command{
 	Use:   "inspect",
 	Short: "certificate inspect",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		resourceTypes := []string{"cm", "secret", "csr"}
 		if len(args) == 1 {
 			resourceTypes = strings.Split(strings.ToLower(args[0]), ",")
 		}
-		inspectResources(resourceTypes)
+		return inspectResources(cmd.OutOrStdout(), resourceTypes)
 	},
 }
 
@@ -178,29 +178,48 @@ func printParseFailure(w io.Writer, f string) {
 	}
 }
 
-func inspectResources(resourceTypes []string) {
+func inspectResources(w io.Writer, resourceTypes []string) error {
 	var data [][]string
 	var resources []*CertDetail
 	_headers := []string{"namespace", "name", "kind", "age", "certtype", "subject", "notbefore", "notafter", "validfor", "issuer", "groups", "usages"}
+	selector, err := labels.Parse(vars.LabelSelectorStringVar)
+	if err != nil {
+		return fmt.Errorf("invalid label selector %q: %w", vars.LabelSelectorStringVar, err)
+	}
 	for _, resourceType := range resourceTypes {
 		switch resourceType {
 		case "cm", "configmap", "configmaps":
 			var configmaps []*unstructured.Unstructured
-			GetConfigMaps(vars.MustGatherRootPath, vars.Namespace, "", vars.AllNamespaceBoolVar, &configmaps)
+			if err := GetConfigMaps(vars.MustGatherRootPath, vars.Namespace, "", vars.AllNamespaceBoolVar, &configmaps); err != nil {
+				return err
+			}
 			for _, r := range configmaps {
-				resources = append(resources, inspectConfigMap(os.Stdout, r)...)
+				if !selector.Matches(labels.Set(r.GetLabels())) {
+					continue
+				}
+				resources = append(resources, inspectConfigMap(w, r)...)
 			}
 		case "secret", "secrets":
 			var secrets []*unstructured.Unstructured
-			GetSecrets(vars.MustGatherRootPath, vars.Namespace, "", vars.AllNamespaceBoolVar, &secrets)
+			if err := GetSecrets(vars.MustGatherRootPath, vars.Namespace, "", vars.AllNamespaceBoolVar, &secrets); err != nil {
+				return err
+			}
 			for _, r := range secrets {
-				resources = append(resources, inspectSecret(os.Stdout, r)...)
+				if !selector.Matches(labels.Set(r.GetLabels())) {
+					continue
+				}
+				resources = append(resources, inspectSecret(w, r)...)
 			}
 		case "csr", "certificatesigningrequest", "certificatesigningrequests":
 			var csrs []unstructured.Unstructured
-			GetCertificateSigningRequests(vars.MustGatherRootPath, vars.Namespace, "", vars.AllNamespaceBoolVar, &csrs)
+			if err := GetCertificateSigningRequests(vars.MustGatherRootPath, vars.Namespace, "", vars.AllNamespaceBoolVar, &csrs); err != nil {
+				return err
+			}
 			for _, r := range csrs {
-				resources = append(resources, inspectCSR(os.Stdout, &r)...)
+				if !selector.Matches(labels.Set(r.GetLabels())) {
+					continue
+				}
+				resources = append(resources, inspectCSR(w, &r)...)
 			}
 		}
 	}
@@ -222,7 +241,7 @@ func inspectResources(resourceTypes []string) {
 		}
 		data = helpers.GetData(data, vars.AllNamespaceBoolVar, false, "", vars.OutputStringVar, 8, _list)
 	}
-	helpers.PrintOutput(resources, 8, vars.OutputStringVar, "", vars.AllNamespaceBoolVar, false, _headers, data, "")
+	return helpers.FprintOutput(w, resources, 8, vars.OutputStringVar, vars.AllNamespaceBoolVar, false, _headers, data, "")
 }
 
 func inspectConfigMap(w io.Writer, obj *unstructured.Unstructured) []*CertDetail {
@@ -17,40 +17,228 @@ package describe
 
 import (
 	"fmt"
-	//"github.com/gmeghnag/omc/cmd/describe/apps"
-	"os"
+	"io"
 	"strings"
 
-	"github.com/gmeghnag/omc/cmd/describe/core"
+	"github.com/gmeghnag/omc/cmd/get"
+	"github.com/gmeghnag/omc/vars"
 
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+	desc "k8s.io/kubectl/pkg/describe"
 )
 
 // DescribeCmd represents the describe command
 var DescribeCmd = &cobra.Command{
-	Use: "describe",
+	Use:   "describe (TYPE [NAME...] | TYPE/NAME...)",
 	Short: "Show details of a specific resource or group of resources",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
-			cmd.Help()
-			os.Exit(0)
+			return cmd.Help()
 		}
-		fmt.Fprintln(os.Stderr, "Invalid object type:", args[0])
-		os.Exit(1)
+		requests, err := describeArgs(args)
+		if err != nil {
+			return err
+		}
+		first := true
+		for _, request := range requests {
+			if err := describeResources(cmd.OutOrStdout(), request.alias, request.names, &first); err != nil {
+				return err
+			}
+		}
+		return nil
 	},
 }
 
+// describeRequest names the objects of a resource type to describe, all of
+// them when names is empty.
+type describeRequest struct {
+	alias string
+	names []string
+}
+
+// describeArgs returns the objects requested by the arguments, either a type
+// followed by names or type/name pairs like kubectl.
+func describeArgs(args []string) ([]describeRequest, error) {
+	if !strings.Contains(args[0], "/") {
+		for _, name := range args[1:] {
+			if strings.Contains(name, "/") {
+				return nil, fmt.Errorf("there is no need to specify a resource type as a separate argument when passing arguments in resource/name form (e.g. 'omc describe resource/<resource_name>' instead of 'omc describe resource resource/<resource_name>'")
+			}
+		}
+		return []describeRequest{{alias: args[0], names: args[1:]}}, nil
+	}
+	var requests []describeRequest
+	for _, arg := range args {
+		alias, name, found := strings.Cut(arg, "/")
+		if !found || name == "" {
+			return nil, fmt.Errorf("arguments in resource/name form must have a single resource and name")
+		}
+		if len(requests) > 0 && requests[len(requests)-1].alias == alias {
+			requests[len(requests)-1].names = append(requests[len(requests)-1].names, name)
+		} else {
+			requests = append(requests, describeRequest{alias: alias, names: []string{name}})
+		}
+	}
+	return requests, nil
+}
+
+// relatedResources lists, by kind, the resources the kubectl describers read
+// besides the described object, like the pods of a controller, and the ones a
+// description refers to, like the owner, claims and node of a pod.
+var relatedResources = map[schema.GroupKind][]string{
+	{Kind: "Pod"}:                                 {"replicasets", "persistentvolumeclaims", "nodes"},
+	{Group: "apps", Kind: "Deployment"}:           {"replicasets"},
+	{Group: "apps", Kind: "ReplicaSet"}:           {"pods"},
+	{Group: "apps", Kind: "StatefulSet"}:          {"pods"},
+	{Group: "apps", Kind: "DaemonSet"}:            {"pods"},
+	{Group: "batch", Kind: "Job"}:                 {"pods"},
+	{Kind: "ReplicationController"}:               {"pods"},
+	{Kind: "PersistentVolumeClaim"}:               {"pods"},
+	{Kind: "Node"}:                                {"pods", "leases"},
+	{Kind: "Service"}:                             {"endpointslices"},
+	{Kind: "Namespace"}:                           {"resourcequotas", "limitranges"},
+	{Kind: "ServiceAccount"}:                      {"secrets"},
+	{Group: "networking.k8s.io", Kind: "Ingress"}: {"services", "endpointslices"},
+}
+
+// describeResources describes the objects of the resource type alias with the
+// kubectl describer of their kind, or with the generic describer for the kinds
+// kubectl doesn't know like the custom resources. The objects are separated by
+// blank lines, first tells whether an object was described already.
+func describeResources(w io.Writer, alias string, names []string, first *bool) error {
+	resource, _, _, namespaced, err := get.KindGroupNamespaced(strings.ToLower(alias))
+	if err != nil {
+		return fmt.Errorf("resource type \"%s\" not known.", alias)
+	}
+	objects, err := get.Objects(alias, names)
+	if err != nil {
+		return err
+	}
+	if len(objects) == 0 && len(names) == 0 {
+		if namespaced && vars.Namespace != "" {
+			fmt.Fprintf(w, "No resources found in %s namespace.\n", vars.Namespace)
+		} else {
+			fmt.Fprintln(w, "No resources found")
+		}
+		return nil
+	}
+
+	server := newObjectServer()
+	for _, obj := range objects {
+		server.add(schema.GroupResource{Group: obj.GroupVersionKind().Group, Resource: resource}, obj)
+	}
+	addRelated(server, resource, objects)
+	config := server.config()
+	describers := make(map[schema.GroupKind]desc.ResourceDescriber)
+	found := make(map[string]bool)
+	for _, obj := range objects {
+		found[obj.GetName()] = true
+		describer, ok := describers[obj.GroupVersionKind().GroupKind()]
+		if !ok {
+			describer, err = describerFor(obj, resource, namespaced, config)
+			if err != nil {
+				return err
+			}
+			describers[obj.GroupVersionKind().GroupKind()] = describer
+		}
+		out, err := describer.Describe(obj.GetNamespace(), obj.GetName(), desc.DescriberSettings{ShowEvents: true})
+		if err != nil {
+			return err
+		}
+		if !*first {
+			fmt.Fprint(w, "\n\n")
+		}
+		*first = false
+		fmt.Fprint(w, out)
+	}
+	for _, name := range names {
+		if !found[name] {
+			return fmt.Errorf("%s \"%s\" not found", resource, name)
+		}
+	}
+	return nil
+}
+
+// addRelated adds to the server the events and the related objects of the
+// objects, read from their namespaces, from the namespace itself for the
+// namespaces and from all of them for the other cluster scoped objects like the
+// nodes.
+func addRelated(server *objectServer, resource string, objects []unstructured.Unstructured) {
+	namespaces := make(map[string]bool)
+	allNamespaces := false
+	aliases := map[string]bool{"events": true}
+	for _, obj := range objects {
+		switch {
+		case obj.GetNamespace() != "":
+			namespaces[obj.GetNamespace()] = true
+		case obj.GetKind() == "Namespace":
+			namespaces[obj.GetName()] = true
+		default:
+			allNamespaces = true
+		}
+		for _, alias := range relatedResources[obj.GroupVersionKind().GroupKind()] {
+			aliases[alias] = true
+		}
+	}
+	for alias := range aliases {
+		related, _, _, relatedNamespaced, err := get.KindGroupNamespaced(alias)
+		if err != nil || related == resource {
+			continue
+		}
+		// the cluster scoped ones, like the node of a pod, are read once
+		objects, err := namespacedObjects(alias, namespaces, allNamespaces || !relatedNamespaced)
+		if err != nil {
+			// the description goes on without them, like on a cluster
+			// where they can't be read
+			klog.V(3).ErrorS(err, "Unable to read the related "+alias)
+			continue
+		}
+		for _, obj := range objects {
+			server.add(schema.GroupResource{Group: obj.GroupVersionKind().Group, Resource: related}, obj)
+		}
+	}
+}
+
+// namespacedObjects returns the objects of the namespaced resource type alias
+// in the namespaces, or in all of them when allNamespaces is set.
+func namespacedObjects(alias string, namespaces map[string]bool, allNamespaces bool) ([]unstructured.Unstructured, error) {
+	namespace, allNamespaceBoolVar, showNamespace := vars.Namespace, vars.AllNamespaceBoolVar, vars.ShowNamespace
+	defer func() {
+		vars.Namespace, vars.AllNamespaceBoolVar, vars.ShowNamespace = namespace, allNamespaceBoolVar, showNamespace
+	}()
+	if allNamespaces {
+		vars.Namespace, vars.AllNamespaceBoolVar = "", true
+		return get.Objects(alias, nil)
+	}
+	vars.AllNamespaceBoolVar = false
+	var objects []unstructured.Unstructured
+	for ns := range namespaces {
+		vars.Namespace = ns
+		nsObjects, err := get.Objects(alias, nil)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, nsObjects...)
+	}
+	return objects, nil
+}
+
+// describerFor returns the kubectl describer of the kind of obj, or the generic
+// one for the kinds without describer.
+func describerFor(obj unstructured.Unstructured, resource string, namespaced bool, config *rest.Config) (desc.ResourceDescriber, error) {
+	if describer, ok := desc.DescriberFor(obj.GroupVersionKind().GroupKind(), config); ok {
+		return describer, nil
+	}
+	if describer, ok := desc.GenericDescriberFor(restMapping(obj, resource, namespaced), config); ok {
+		return describer, nil
+	}
+	return nil, fmt.Errorf("unable to describe %s", obj.GroupVersionKind().Kind)
+}
+
 func init() {
-	if len(os.Args) > 2 && os.Args[1] == "describe" {
-		if strings.Contains(os.Args[2], "/") {
-			seg := strings.Split(os.Args[2], "/")
-			resource, name := seg[0], seg[1]
-			os.Args = append([]string{os.Args[0], "describe", resource, name}, os.Args[3:]...)
-		}
-	}
-	DescribeCmd.AddCommand(
-		//apps.Deployment,
-		core.Node,
-		core.Pod,
-	)
+	DescribeCmd.Flags().BoolVarP(&vars.AllNamespaceBoolVar, "all-namespaces", "A", false, "If present, describe the requested object(s) across all namespaces.")
 }
@@ -0,0 +1,365 @@
+package describe
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gmeghnag/omc/cmd/get"
+	"github.com/gmeghnag/omc/vars"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const testDeployments = `apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: test-deployment
+    namespace: test-namespace
+    uid: 0b9c3f1e-1d2a-4a63-9bd5-6a1f0c7d2e11
+    labels:
+      app: test
+  spec:
+    replicas: 2
+    selector:
+      matchLabels:
+        app: test
+    template:
+      metadata:
+        labels:
+          app: test
+      spec:
+        containers:
+        - name: test-container
+          image: quay.io/test/image:latest
+`
+
+const testReplicaSets = `apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: ReplicaSet
+  metadata:
+    name: test-deployment-5d9c
+    namespace: test-namespace
+    labels:
+      app: test
+    annotations:
+      deployment.kubernetes.io/revision: "1"
+    ownerReferences:
+    - apiVersion: apps/v1
+      kind: Deployment
+      name: test-deployment
+      uid: 0b9c3f1e-1d2a-4a63-9bd5-6a1f0c7d2e11
+      controller: true
+  spec:
+    replicas: 2
+    selector:
+      matchLabels:
+        app: test
+    template:
+      metadata:
+        labels:
+          app: test
+      spec:
+        containers:
+        - name: test-container
+          image: quay.io/test/image:latest
+`
+
+const testEvents = `apiVersion: v1
+kind: EventList
+items:
+- apiVersion: v1
+  kind: Event
+  metadata:
+    name: test-deployment.17a1
+    namespace: test-namespace
+  involvedObject:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: test-deployment
+    namespace: test-namespace
+    uid: 0b9c3f1e-1d2a-4a63-9bd5-6a1f0c7d2e11
+  reason: ScalingReplicaSet
+  message: Scaled up replica set test-deployment-5d9c to 2
+  source:
+    component: deployment-controller
+  type: Normal
+  count: 1
+- apiVersion: v1
+  kind: Event
+  metadata:
+    name: other-deployment.17a2
+    namespace: test-namespace
+  involvedObject:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: other-deployment
+    namespace: test-namespace
+  reason: ScalingReplicaSet
+  message: Scaled up replica set other-deployment-7f6b to 1
+  type: Normal
+  count: 1
+- apiVersion: v1
+  kind: Event
+  metadata:
+    name: test-deployment-5d9c-x7k2p.17a3
+    namespace: test-namespace
+  involvedObject:
+    apiVersion: v1
+    kind: Pod
+    name: test-deployment-5d9c-x7k2p
+    namespace: test-namespace
+    uid: 5c1a8f2e-7b3d-4e9a-8c6f-2d4b1e0a9f33
+  reason: BackOff
+  message: Back-off restarting failed container test-container
+  type: Warning
+  count: 3
+`
+
+const testPods = `apiVersion: v1
+kind: PodList
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: test-deployment-5d9c-x7k2p
+    namespace: test-namespace
+    uid: 5c1a8f2e-7b3d-4e9a-8c6f-2d4b1e0a9f33
+    labels:
+      app: test
+    ownerReferences:
+    - apiVersion: apps/v1
+      kind: ReplicaSet
+      name: test-deployment-5d9c
+      controller: true
+  spec:
+    nodeName: test-node
+    containers:
+    - name: test-container
+      image: quay.io/test/image:latest
+    volumes:
+    - name: data
+      persistentVolumeClaim:
+        claimName: test-claim
+  status:
+    phase: Running
+`
+
+const testClaims = `apiVersion: v1
+kind: PersistentVolumeClaimList
+items:
+- apiVersion: v1
+  kind: PersistentVolumeClaim
+  metadata:
+    name: test-claim
+    namespace: test-namespace
+  spec:
+    accessModes:
+    - ReadWriteOnce
+    resources:
+      requests:
+        storage: 1Gi
+  status:
+    phase: Bound
+`
+
+const testNode = `apiVersion: v1
+kind: Node
+metadata:
+  name: test-node
+  labels:
+    node-role.kubernetes.io/worker: ""
+`
+
+const testCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    listKind: WidgetList
+    plural: widgets
+    singular: widget
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+`
+
+const testWidget = `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: test-widget
+  namespace: test-namespace
+spec:
+  size: large
+`
+
+func writeMustGather(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		"namespaces/test-namespace/apps/deployments.yaml":                                      testDeployments,
+		"namespaces/test-namespace/apps/replicasets.yaml":                                      testReplicaSets,
+		"namespaces/test-namespace/core/events.yaml":                                           testEvents,
+		"namespaces/test-namespace/core/pods.yaml":                                             testPods,
+		"namespaces/test-namespace/core/persistentvolumeclaims.yaml":                           testClaims,
+		"cluster-scoped-resources/core/nodes/test-node.yaml":                                   testNode,
+		"namespaces/test-namespace/example.com/widgets/test-widget.yaml":                       testWidget,
+		"cluster-scoped-resources/apiextensions.k8s.io/customresourcedefinitions/widgets.yaml": testCRD,
+	} {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestDescribeResources(t *testing.T) {
+	vars.MustGatherRootPath = writeMustGather(t)
+	vars.Namespace = "test-namespace"
+	defer func() {
+		vars.MustGatherRootPath = ""
+		vars.Namespace = ""
+	}()
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		skip    []string
+		wantErr string
+	}{
+		{
+			name: "kind with a kubectl describer",
+			args: []string{"deployments"},
+			want: []string{"Name:", "test-deployment", "Replicas:", "2 desired", "test-container"},
+		},
+		{
+			name: "events and related objects",
+			args: []string{"deployment/test-deployment"},
+			want: []string{"NewReplicaSet:", "test-deployment-5d9c", "Events:", "ScalingReplicaSet", "Scaled up replica set test-deployment-5d9c to 2"},
+			skip: []string{"other-deployment"},
+		},
+		{
+			name: "pod events and related objects",
+			args: []string{"pod/test-deployment-5d9c-x7k2p"},
+			want: []string{"Node:", "test-node", "Controlled By:", "ReplicaSet/test-deployment-5d9c", "ClaimName:", "test-claim", "Events:", "BackOff", "Back-off restarting failed container test-container"},
+			skip: []string{"ScalingReplicaSet"},
+		},
+		{
+			name: "custom resource described generically",
+			args: []string{"widget/test-widget"},
+			want: []string{"Name:", "test-widget", "Spec:", "Size:", "large"},
+		},
+		{
+			name:    "missing object",
+			args:    []string{"deployment", "missing"},
+			wantErr: `deployments "missing" not found`,
+		},
+		{
+			name:    "unknown kind",
+			args:    []string{"gizmos"},
+			wantErr: `resource type "gizmos" not known.`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			DescribeCmd.SetOut(&output)
+			defer DescribeCmd.SetOut(nil)
+			err := DescribeCmd.RunE(DescribeCmd, tt.args)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(output.String(), want) {
+					t.Errorf("output doesn't contain %q:\n%s", want, output.String())
+				}
+			}
+			for _, skip := range tt.skip {
+				if strings.Contains(output.String(), skip) {
+					t.Errorf("output contains %q:\n%s", skip, output.String())
+				}
+			}
+		})
+	}
+}
+
+func TestAddRelatedPod(t *testing.T) {
+	vars.MustGatherRootPath = writeMustGather(t)
+	vars.Namespace = "test-namespace"
+	defer func() {
+		vars.MustGatherRootPath = ""
+		vars.Namespace = ""
+	}()
+
+	pods, err := get.Objects("pods", []string{"test-deployment-5d9c-x7k2p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newObjectServer()
+	addRelated(server, "pods", pods)
+	for resource, want := range map[schema.GroupResource]string{
+		{Resource: "events"}:                     "test-deployment-5d9c-x7k2p.17a3",
+		{Group: "apps", Resource: "replicasets"}: "test-deployment-5d9c",
+		{Resource: "persistentvolumeclaims"}:     "test-claim",
+		{Resource: "nodes"}:                      "test-node",
+	} {
+		var names []string
+		for _, obj := range server.objects[resource] {
+			names = append(names, obj.GetName())
+		}
+		if !slices.Contains(names, want) {
+			t.Errorf("related %s = %v, want %s among them", resource, names, want)
+		}
+	}
+	if nodes := server.objects[schema.GroupResource{Resource: "nodes"}]; len(nodes) != 1 {
+		t.Errorf("got %d related nodes, want the node read once", len(nodes))
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path      string
+		resource  string
+		namespace string
+		name      string
+		ok        bool
+	}{
+		{path: "/api/v1/namespaces/ns/pods", resource: "pods", namespace: "ns", ok: true},
+		{path: "/api/v1/namespaces/ns/pods/p", resource: "pods", namespace: "ns", name: "p", ok: true},
+		{path: "/api/v1/namespaces/ns", resource: "namespaces", name: "ns", ok: true},
+		{path: "/api/v1/nodes", resource: "nodes", ok: true},
+		{path: "/apis/apps/v1/namespaces/ns/deployments/d", resource: "deployments", namespace: "ns", name: "d", ok: true},
+		{path: "/apis/apps/v1/namespaces/ns/deployments/d/scale", ok: false},
+		{path: "/version", ok: false},
+	}
+	for _, tt := range tests {
+		gvr, namespace, name, ok := parsePath(tt.path)
+		if ok != tt.ok || (ok && (gvr.Resource != tt.resource || namespace != tt.namespace || name != tt.name)) {
+			t.Errorf("parsePath(%q) = %v, %q, %q, %v", tt.path, gvr, namespace, name, ok)
+		}
+	}
+}
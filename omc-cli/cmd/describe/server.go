@@ -0,0 +1,161 @@
+package describe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gmeghnag/omc/cmd/helpers"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// objectServer answers the read requests of the kubectl describers with the
+// objects of the must-gather it holds, like an API server would. The
+// describers run unchanged against it.
+type objectServer struct {
+	objects map[schema.GroupResource][]unstructured.Unstructured
+}
+
+func newObjectServer() *objectServer {
+	return &objectServer{objects: make(map[schema.GroupResource][]unstructured.Unstructured)}
+}
+
+// add makes the objects available under the resource.
+func (s *objectServer) add(resource schema.GroupResource, objects ...unstructured.Unstructured) {
+	s.objects[resource] = append(s.objects[resource], objects...)
+}
+
+// config returns the client configuration of the server.
+func (s *objectServer) config() *rest.Config {
+	return &rest.Config{
+		Host:        "http://must-gather",
+		Transport:   s,
+		RateLimiter: flowcontrol.NewFakeAlwaysRateLimiter(),
+	}
+}
+
+// RoundTrip serves the get and list requests, the requests of the resources
+// the server doesn't hold get empty lists and not found errors.
+func (s *objectServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return s.status(req, http.StatusMethodNotAllowed, metav1.StatusReasonMethodNotAllowed, "the must-gather is read only")
+	}
+	gvr, namespace, name, ok := parsePath(req.URL.Path)
+	if !ok {
+		return s.status(req, http.StatusNotFound, metav1.StatusReasonNotFound, fmt.Sprintf("the server could not find the requested resource %s", req.URL.Path))
+	}
+	apiVersion := gvr.GroupVersion().String()
+	candidates := s.objects[gvr.GroupResource()]
+	if name != "" {
+		for _, obj := range candidates {
+			if obj.GetName() == name && obj.GetNamespace() == namespace {
+				obj = *obj.DeepCopy()
+				// the describers ask for the version they know
+				obj.SetAPIVersion(apiVersion)
+				return s.respond(req, http.StatusOK, obj.Object)
+			}
+		}
+		return s.status(req, http.StatusNotFound, metav1.StatusReasonNotFound, fmt.Sprintf("%s %q not found", gvr.GroupResource(), name))
+	}
+	labelSelector, err := labels.Parse(req.URL.Query().Get("labelSelector"))
+	if err != nil {
+		return s.status(req, http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error())
+	}
+	fieldSelector, err := fields.ParseSelector(req.URL.Query().Get("fieldSelector"))
+	if err != nil {
+		return s.status(req, http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error())
+	}
+	items := []any{}
+	for _, obj := range candidates {
+		if namespace != "" && obj.GetNamespace() != namespace {
+			continue
+		}
+		if !labelSelector.Matches(labels.Set(obj.GetLabels())) || !helpers.MatchFields(obj.Object, fieldSelector) {
+			continue
+		}
+		obj = *obj.DeepCopy()
+		obj.SetAPIVersion(apiVersion)
+		items = append(items, obj.Object)
+	}
+	// the kind of the list is left out, the clients default it to the kind
+	// they expect
+	return s.respond(req, http.StatusOK, map[string]any{"metadata": map[string]any{}, "items": items})
+}
+
+func (s *objectServer) respond(req *http.Request, code int, body any) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+		Request:    req,
+	}, nil
+}
+
+func (s *objectServer) status(req *http.Request, code int, reason metav1.StatusReason, message string) (*http.Response, error) {
+	return s.respond(req, code, metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Reason:   reason,
+		Code:     int32(code),
+		Message:  message,
+	})
+}
+
+// parsePath returns the resource, namespace and name requested by the path of
+// an API request, /api/v1/... for the core group and
+// /apis/<group>/<version>/... for the others.
+func parsePath(path string) (schema.GroupVersionResource, string, string, bool) {
+	var gvr schema.GroupVersionResource
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		gvr.Version, parts = parts[1], parts[2:]
+	case len(parts) >= 4 && parts[0] == "apis":
+		gvr.Group, gvr.Version, parts = parts[1], parts[2], parts[3:]
+	default:
+		return gvr, "", "", false
+	}
+	namespace := ""
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		namespace, parts = parts[1], parts[2:]
+	}
+	switch len(parts) {
+	case 1:
+		gvr.Resource = parts[0]
+		return gvr, namespace, "", true
+	case 2:
+		gvr.Resource = parts[0]
+		return gvr, namespace, parts[1], true
+	}
+	return gvr, "", "", false
+}
+
+// restMapping returns the mapping of the resource of obj for the generic
+// describer.
+func restMapping(obj unstructured.Unstructured, resource string, namespaced bool) *meta.RESTMapping {
+	gvk := obj.GroupVersionKind()
+	scope := meta.RESTScopeRoot
+	if namespaced {
+		scope = meta.RESTScopeNamespace
+	}
+	return &meta.RESTMapping{
+		Resource:         gvk.GroupVersion().WithResource(resource),
+		GroupVersionKind: gvk,
+		Scope:            scope,
+	}
+}
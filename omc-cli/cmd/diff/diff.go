@@ -0,0 +1,459 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gmeghnag/omc/cmd/get"
+	"github.com/gmeghnag/omc/cmd/use"
+	"github.com/gmeghnag/omc/types"
+	"github.com/gmeghnag/omc/vars"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+var DiffCmd = &cobra.Command{
+	Use:   "diff <must-gather> <must-gather> [TYPE[/NAME] | TYPE NAME]",
+	Short: "Compare the resources of two must-gathers.",
+	Long: `Compare the resources of two must-gathers, given by their id (see "omc mg get") or their path,
+and report the resources added, removed and changed in the second one. The changes are listed field
+by field, the managed fields, the resource version and the status fields which change all the time,
+like the heartbeat and probe times and the observed generation, left out.
+All the resources but the events are compared when no type is given, from every namespace unless
+--namespace is set.`,
+	Example: `  omc diff before after
+  omc diff before after deployments -n openshift-apiserver
+  omc diff before after clusteroperator/authentication -o json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 || len(args) > 4 {
+			return fmt.Errorf("error: two must-gathers expected, optionally followed by a resource type and name, found %d arguments", len(args))
+		}
+		alias, names, err := resourceArgs(args[2:])
+		if err != nil {
+			return err
+		}
+		// the namespace defaults to the project of the current context, only
+		// the one given explicitly restricts the comparison
+		namespace := ""
+		if flag := cmd.Flag("namespace"); flag != nil && flag.Changed {
+			namespace = vars.Namespace
+		}
+		before, err := contextPath(args[0])
+		if err != nil {
+			return err
+		}
+		after, err := contextPath(args[1])
+		if err != nil {
+			return err
+		}
+		result, err := compare(before, after, alias, names, namespace)
+		if err != nil {
+			return err
+		}
+		return printReport(cmd.OutOrStdout(), result, vars.OutputStringVar)
+	},
+}
+
+// report lists the resources added, removed and changed in a must-gather
+// compared to another one.
+type report struct {
+	Added   []reference `json:"added"`
+	Removed []reference `json:"removed"`
+	Changed []change    `json:"changed"`
+}
+
+// reference identifies a resource across must-gathers.
+type reference struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+func (r reference) String() string {
+	kind := r.Kind
+	if r.Group != "" {
+		kind += "." + r.Group
+	}
+	if r.Namespace != "" {
+		return kind + " " + r.Namespace + "/" + r.Name
+	}
+	return kind + " " + r.Name
+}
+
+func (r reference) less(other reference) bool {
+	if r.Group != other.Group {
+		return r.Group < other.Group
+	}
+	if r.Kind != other.Kind {
+		return r.Kind < other.Kind
+	}
+	if r.Namespace != other.Namespace {
+		return r.Namespace < other.Namespace
+	}
+	return r.Name < other.Name
+}
+
+// change holds the fields of a resource which differ between the
+// must-gathers, a field missing from one of them has no value there.
+type change struct {
+	reference
+	Fields []fieldChange `json:"fields"`
+}
+
+type fieldChange struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// resourceArgs returns the resource type and the names given by the
+// arguments, either TYPE, TYPE NAME or TYPE/NAME.
+func resourceArgs(args []string) (string, []string, error) {
+	switch len(args) {
+	case 0:
+		return "", nil, nil
+	case 1:
+		alias, name, found := strings.Cut(args[0], "/")
+		if !found {
+			return alias, nil, nil
+		}
+		if name == "" {
+			return "", nil, fmt.Errorf("arguments in resource/name form must have a single resource and name")
+		}
+		return alias, []string{name}, nil
+	}
+	if strings.Contains(args[0], "/") || strings.Contains(args[1], "/") {
+		return "", nil, fmt.Errorf("there is no need to specify a resource type as a separate argument when passing arguments in resource/name form (e.g. 'omc diff <must-gather> <must-gather> resource/<resource_name>' instead of 'omc diff <must-gather> <must-gather> resource resource/<resource_name>'")
+	}
+	return args[0], args[1:], nil
+}
+
+// contextPath returns the root directory of the must-gather given by its id
+// in the omc config file, or by its path.
+func contextPath(mustGather string) (string, error) {
+	path := ""
+	home, err := os.UserHomeDir()
+	if err == nil {
+		file, _ := os.ReadFile(filepath.Join(home, ".omc", "omc.json"))
+		omcConfigJson := types.Config{}
+		_ = json.Unmarshal(file, &omcConfigJson)
+		for _, c := range omcConfigJson.Contexts {
+			if c.Id == mustGather {
+				path = c.Path
+				break
+			}
+		}
+	}
+	if path == "" {
+		if info, err := os.Stat(mustGather); err != nil || !info.IsDir() {
+			return "", fmt.Errorf("must-gather %q not found, expected the id of a must-gather listed by \"omc mg get\" or the path of a must-gather", mustGather)
+		}
+		path = mustGather
+	}
+	return use.MustGatherRoot(path)
+}
+
+// compare returns the differences between the resources of the must-gathers
+// found in the before and after directories.
+func compare(before string, after string, alias string, names []string, namespace string) (report, error) {
+	result := report{Added: []reference{}, Removed: []reference{}, Changed: []change{}}
+	beforeObjects, err := loadObjects(before, alias, names, namespace)
+	if err != nil {
+		return result, err
+	}
+	afterObjects, err := loadObjects(after, alias, names, namespace)
+	if err != nil {
+		return result, err
+	}
+	for _, name := range names {
+		if !hasName(beforeObjects, name) && !hasName(afterObjects, name) {
+			return result, fmt.Errorf("%s \"%s\" not found", alias, name)
+		}
+	}
+	for ref, obj := range afterObjects {
+		beforeObj, ok := beforeObjects[ref]
+		if !ok {
+			result.Added = append(result.Added, ref)
+			continue
+		}
+		if fields := diffObjects(beforeObj, obj); len(fields) > 0 {
+			result.Changed = append(result.Changed, change{reference: ref, Fields: fields})
+		}
+	}
+	for ref := range beforeObjects {
+		if _, ok := afterObjects[ref]; !ok {
+			result.Removed = append(result.Removed, ref)
+		}
+	}
+	sort.Slice(result.Added, func(i, j int) bool { return result.Added[i].less(result.Added[j]) })
+	sort.Slice(result.Removed, func(i, j int) bool { return result.Removed[i].less(result.Removed[j]) })
+	sort.Slice(result.Changed, func(i, j int) bool { return result.Changed[i].less(result.Changed[j].reference) })
+	return result, nil
+}
+
+func hasName(objects map[reference]unstructured.Unstructured, name string) bool {
+	for ref := range objects {
+		if ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// loadObjects reads the resources of the must-gather in root, the ones of the
+// type alias when it is set, or all of them.
+func loadObjects(root string, alias string, names []string, namespace string) (map[reference]unstructured.Unstructured, error) {
+	objects := make(map[reference]unstructured.Unstructured)
+	add := func(obj unstructured.Unstructured) {
+		if obj.GetKind() == "" || obj.GetName() == "" {
+			return
+		}
+		ref := reference{
+			Group:     obj.GroupVersionKind().Group,
+			Kind:      obj.GetKind(),
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+		}
+		objects[ref] = obj
+	}
+	if alias != "" {
+		typeObjects, err := typeObjects(root, alias, names, namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range typeObjects {
+			add(obj)
+		}
+		return objects, nil
+	}
+	dirs := []string{filepath.Join(root, "namespaces"), filepath.Join(root, "cluster-scoped-resources")}
+	if namespace != "" {
+		dirs = []string{filepath.Join(root, "namespaces", namespace)}
+	}
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if entry.IsDir() {
+				// the pods are listed in core/pods.yaml, their directories
+				// hold the logs
+				if entry.Name() == "pods" && filepath.Base(filepath.Dir(filepath.Dir(path))) == "namespaces" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(entry.Name(), ".yaml") {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			var obj unstructured.Unstructured
+			if err := yaml.Unmarshal(data, &obj); err != nil {
+				klog.V(3).ErrorS(err, "Unable to parse "+path)
+				return nil
+			}
+			if !obj.IsList() {
+				if obj.GetKind() != "Event" {
+					add(obj)
+				}
+				return nil
+			}
+			list, err := obj.ToList()
+			if err != nil {
+				klog.V(3).ErrorS(err, "Unable to parse "+path)
+				return nil
+			}
+			for _, item := range list.Items {
+				if item.GetKind() != "Event" {
+					add(item)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return objects, nil
+}
+
+// typeObjects returns the resources of the type alias in the must-gather in
+// root, read like "omc get" does.
+func typeObjects(root string, alias string, names []string, namespace string) ([]unstructured.Unstructured, error) {
+	mustGatherRootPath, currentNamespace, allNamespaces, showNamespace := vars.MustGatherRootPath, vars.Namespace, vars.AllNamespaceBoolVar, vars.ShowNamespace
+	defer func() {
+		vars.MustGatherRootPath, vars.Namespace, vars.AllNamespaceBoolVar, vars.ShowNamespace = mustGatherRootPath, currentNamespace, allNamespaces, showNamespace
+	}()
+	vars.MustGatherRootPath, vars.Namespace, vars.AllNamespaceBoolVar = root, namespace, namespace == ""
+	if _, _, _, _, err := get.KindGroupNamespaced(strings.ToLower(alias)); err != nil {
+		return nil, fmt.Errorf("resource type \"%s\" not known.", alias)
+	}
+	return get.Objects(alias, names)
+}
+
+// diffObjects returns the fields which differ between the objects, the noisy
+// ones left out.
+func diffObjects(before unstructured.Unstructured, after unstructured.Unstructured) []fieldChange {
+	beforeFields, afterFields := map[string]interface{}{}, map[string]interface{}{}
+	flatten("", normalize(before).Object, beforeFields)
+	flatten("", normalize(after).Object, afterFields)
+	paths := make([]string, 0, len(afterFields))
+	for path := range afterFields {
+		paths = append(paths, path)
+	}
+	for path := range beforeFields {
+		if _, ok := afterFields[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	var fields []fieldChange
+	for _, path := range paths {
+		beforeValue, afterValue := beforeFields[path], afterFields[path]
+		if !reflect.DeepEqual(beforeValue, afterValue) {
+			fields = append(fields, fieldChange{Path: path, Before: beforeValue, After: afterValue})
+		}
+	}
+	return fields
+}
+
+// statusNoise are the status fields, at any depth, which change without the
+// state of the resource changing.
+var statusNoise = map[string]bool{
+	"lastHeartbeatTime":  true,
+	"lastProbeTime":      true,
+	"lastUpdateTime":     true,
+	"observedGeneration": true,
+}
+
+// normalize returns a copy of obj without the fields which change without
+// the resource being changed.
+func normalize(obj unstructured.Unstructured) unstructured.Unstructured {
+	obj = *obj.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "resourceVersion")
+	if status, ok := obj.Object["status"]; ok {
+		removeNoise(status)
+	}
+	return obj
+}
+
+// removeNoise removes the statusNoise fields from value and its descendants.
+func removeNoise(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if statusNoise[key] {
+				delete(v, key)
+			} else {
+				removeNoise(item)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			removeNoise(item)
+		}
+	}
+}
+
+// flatten adds the leaf values of value to fields, by their JSON path like
+// spec.template.spec.containers[0].image.
+func flatten(path string, value interface{}, fields map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 && path != "" {
+			fields[path] = v
+		}
+		for key, item := range v {
+			switch {
+			case path == "":
+				flatten(key, item, fields)
+			case strings.Contains(key, "."):
+				flatten(fmt.Sprintf("%s['%s']", path, key), item, fields)
+			default:
+				flatten(path+"."+key, item, fields)
+			}
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			fields[path] = v
+		}
+		for i, item := range v {
+			flatten(fmt.Sprintf("%s[%d]", path, i), item, fields)
+		}
+	default:
+		fields[path] = v
+	}
+}
+
+func printReport(w io.Writer, result report, output string) error {
+	switch output {
+	case "json":
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", data)
+		return nil
+	case "yaml":
+		data, err := yaml.Marshal(result)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s", data)
+		return nil
+	case "":
+	default:
+		return fmt.Errorf("unsupported output format %q, expected one of: json|yaml", output)
+	}
+	if len(result.Added) == 0 && len(result.Removed) == 0 && len(result.Changed) == 0 {
+		fmt.Fprintln(w, "No differences found.")
+		return nil
+	}
+	for _, ref := range result.Added {
+		fmt.Fprintf(w, "+ %s\n", ref)
+	}
+	for _, ref := range result.Removed {
+		fmt.Fprintf(w, "- %s\n", ref)
+	}
+	for _, c := range result.Changed {
+		fmt.Fprintf(w, "~ %s\n", c.reference)
+		for _, field := range c.Fields {
+			fmt.Fprintf(w, "    %s: %s -> %s\n", field.Path, fieldValue(field.Before), fieldValue(field.After))
+		}
+	}
+	fmt.Fprintf(w, "\n%d added, %d removed, %d changed\n", len(result.Added), len(result.Removed), len(result.Changed))
+	return nil
+}
+
+// fieldValue formats a field value on a single line.
+func fieldValue(value interface{}) string {
+	if value == nil {
+		return "<none>"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func init() {
+	DiffCmd.Flags().StringVarP(&vars.OutputStringVar, "output", "o", "", "Output format. One of: json|yaml")
+}
@@ -0,0 +1,227 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const beforeDeployments = `apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: changed
+    namespace: test-namespace
+    resourceVersion: "1"
+    labels:
+      app.kubernetes.io/name: changed
+  spec:
+    replicas: 3
+  status:
+    readyReplicas: 3
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: removed
+    namespace: test-namespace
+  spec:
+    replicas: 1
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: unchanged
+    namespace: test-namespace
+    resourceVersion: "1"
+  spec:
+    replicas: 1
+  status:
+    observedGeneration: 1
+    readyReplicas: 1
+    conditions:
+    - type: Available
+      status: "True"
+      lastUpdateTime: "2024-01-01T00:00:00Z"
+`
+
+const afterDeployments = `apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: changed
+    namespace: test-namespace
+    resourceVersion: "2"
+  spec:
+    replicas: 2
+    paused: true
+  status:
+    readyReplicas: 2
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: added
+    namespace: test-namespace
+  spec:
+    replicas: 1
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: unchanged
+    namespace: test-namespace
+    resourceVersion: "2"
+  spec:
+    replicas: 1
+  status:
+    observedGeneration: 2
+    readyReplicas: 1
+    conditions:
+    - type: Available
+      status: "True"
+      lastUpdateTime: "2024-01-02T00:00:00Z"
+`
+
+const events = `apiVersion: v1
+kind: EventList
+items:
+- apiVersion: v1
+  kind: Event
+  metadata:
+    name: event
+    namespace: test-namespace
+  reason: Created
+`
+
+func writeMustGather(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestCompare(t *testing.T) {
+	before := writeMustGather(t, map[string]string{
+		"namespaces/test-namespace/apps/deployments.yaml": beforeDeployments,
+	})
+	after := writeMustGather(t, map[string]string{
+		"namespaces/test-namespace/apps/deployments.yaml": afterDeployments,
+		"namespaces/test-namespace/core/events.yaml":      events,
+	})
+	changed := reference{Group: "apps", Kind: "Deployment", Namespace: "test-namespace", Name: "changed"}
+	want := report{
+		Added:   []reference{{Group: "apps", Kind: "Deployment", Namespace: "test-namespace", Name: "added"}},
+		Removed: []reference{{Group: "apps", Kind: "Deployment", Namespace: "test-namespace", Name: "removed"}},
+		Changed: []change{{reference: changed, Fields: []fieldChange{
+			{Path: "metadata.labels['app.kubernetes.io/name']", Before: "changed"},
+			{Path: "spec.paused", After: true},
+			{Path: "spec.replicas", Before: int64(3), After: int64(2)},
+			{Path: "status.readyReplicas", Before: int64(3), After: int64(2)},
+		}}},
+	}
+
+	tests := []struct {
+		name  string
+		alias string
+		names []string
+		want  report
+	}{
+		{name: "all resources", want: want},
+		{name: "resource type", alias: "deployments", want: want},
+		{name: "named resource", alias: "deployment", names: []string{"changed"}, want: report{Added: []reference{}, Removed: []reference{}, Changed: want.Changed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := compare(before, after, tt.alias, tt.names, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := compare(before, after, "deployment", []string{"missing"}, ""); err == nil || err.Error() != `deployment "missing" not found` {
+		t.Errorf("got error %v for a missing resource", err)
+	}
+	if _, err := compare(before, after, "gizmos", nil, ""); err == nil || err.Error() != `resource type "gizmos" not known.` {
+		t.Errorf("got error %v for an unknown resource type", err)
+	}
+}
+
+func node(ready string, heartbeat string) string {
+	return `apiVersion: v1
+kind: Node
+metadata:
+  name: worker-0
+status:
+  conditions:
+  - type: MemoryPressure
+    status: "False"
+    lastTransitionTime: "2024-01-01T00:00:00Z"
+    lastHeartbeatTime: "` + heartbeat + `"
+  - type: Ready
+    status: "` + ready + `"
+    lastTransitionTime: "2024-01-01T00:00:00Z"
+    lastHeartbeatTime: "` + heartbeat + `"
+`
+}
+
+func TestCompareReportsConditionChanges(t *testing.T) {
+	path := "cluster-scoped-resources/core/nodes/worker-0.yaml"
+	before := writeMustGather(t, map[string]string{path: node("True", "2024-01-01T00:00:00Z")})
+	after := writeMustGather(t, map[string]string{path: node("False", "2024-01-02T00:00:00Z")})
+	got, err := compare(before, after, "node", []string{"worker-0"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []change{{
+		reference: reference{Kind: "Node", Name: "worker-0"},
+		Fields:    []fieldChange{{Path: "status.conditions[1].status", Before: "True", After: "False"}},
+	}}
+	if !reflect.DeepEqual(got.Changed, want) {
+		t.Errorf("got changes %+v, want %+v", got.Changed, want)
+	}
+
+	after = writeMustGather(t, map[string]string{path: node("True", "2024-01-02T00:00:00Z")})
+	got, err = compare(before, after, "node", []string{"worker-0"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Changed) != 0 {
+		t.Errorf("got changes %+v for heartbeats only", got.Changed)
+	}
+}
+
+func TestResourceArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		alias   string
+		names   []string
+		wantErr bool
+	}{
+		{},
+		{args: []string{"pods"}, alias: "pods"},
+		{args: []string{"pod/p"}, alias: "pod", names: []string{"p"}},
+		{args: []string{"pod", "p"}, alias: "pod", names: []string{"p"}},
+		{args: []string{"pod/"}, wantErr: true},
+		{args: []string{"pod", "pod/p"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		alias, names, err := resourceArgs(tt.args)
+		if (err != nil) != tt.wantErr || alias != tt.alias || !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("resourceArgs(%q) = %q, %q, %v", tt.args, alias, names, err)
+		}
+	}
+}
@@ -16,8 +16,6 @@ limitations under the License.
 package etcd
 
 import (
-	"os"
-
 	"github.com/spf13/cobra"
 )
 
@@ -26,9 +24,8 @@ var Etcd = &cobra.Command{
 	Use:     "etcd",
 	Aliases: []string{"etcdctl"},
 	Short:   "Shows etcd health and status.",
-	Run: func(cmd *cobra.Command, args []string) {
-		cmd.Help()
-		os.Exit(0)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
 	},
 }
 
@@ -3,8 +3,8 @@ package etcd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"os"
 	"strings"
 
 	"github.com/dustin/go-humanize"
@@ -17,19 +17,39 @@ type Endpoint struct {
 	Resp     etcdserverpb.StatusResponse `json:"Status"`
 }
 
-type epHealth struct {
+type EpHealth struct {
 	Ep     string `json:"endpoint"`
 	Health bool   `json:"health"`
 	Took   string `json:"took"`
 	Error  string `json:"error,omitempty"`
 }
 
-func EndpointStatus(etcdFolderPath string) {
+// ReadEndpointStatus returns the status of the etcd endpoints collected in
+// etcdFolderPath.
+func ReadEndpointStatus(etcdFolderPath string) ([]Endpoint, error) {
 	_file, _ := ioutil.ReadFile(etcdFolderPath + "endpoint_status.json")
 	var Endpoints []Endpoint
 	if err := json.Unmarshal([]byte(_file), &Endpoints); err != nil {
-		fmt.Fprintln(os.Stderr, "Error when trying to unmarshal file \""+etcdFolderPath+"endpoint_status.json\":", err.Error())
-		os.Exit(1)
+		return nil, fmt.Errorf("Error when trying to unmarshal file \"%sendpoint_status.json\": %s", etcdFolderPath, err.Error())
+	}
+	return Endpoints, nil
+}
+
+// ReadEndpointHealth returns the health of the etcd endpoints collected in
+// etcdFolderPath.
+func ReadEndpointHealth(etcdFolderPath string) ([]EpHealth, error) {
+	_file, _ := ioutil.ReadFile(etcdFolderPath + "endpoint_health.json")
+	var healthList []EpHealth
+	if err := json.Unmarshal([]byte(_file), &healthList); err != nil {
+		return nil, fmt.Errorf("Error when trying to unmarshal file \"%sendpoint_health.json\": %s", etcdFolderPath, err.Error())
+	}
+	return healthList, nil
+}
+
+func EndpointStatus(w io.Writer, etcdFolderPath string) error {
+	Endpoints, err := ReadEndpointStatus(etcdFolderPath)
+	if err != nil {
+		return err
 	}
 	var rows [][]string
 	var hdr = []string{"endpoint", "ID", "version", "db size/in use", "not used", "is leader", "is learner", "raft term",
@@ -49,18 +69,17 @@ func EndpointStatus(etcdFolderPath string) {
 			fmt.Sprint(strings.Join(status.Resp.Errors, ", ")),
 		})
 	}
-	table := tablewriter.NewWriter(os.Stdout)
+	table := tablewriter.NewWriter(w)
 	table.SetHeader(hdr)
 	table.AppendBulk(rows)
 	table.Render()
+	return nil
 }
 
-func EndpointHealth(etcdFolderPath string) {
-	_file, _ := ioutil.ReadFile(etcdFolderPath + "endpoint_health.json")
-	var healthList []epHealth
-	if err := json.Unmarshal([]byte(_file), &healthList); err != nil {
-		fmt.Fprintln(os.Stderr, "Error when trying to unmarshal file \""+etcdFolderPath+"endpoint_status.json\":", err.Error())
-		os.Exit(1)
+func EndpointHealth(w io.Writer, etcdFolderPath string) error {
+	healthList, err := ReadEndpointHealth(etcdFolderPath)
+	if err != nil {
+		return err
 	}
 	var rows [][]string
 	var hdr = []string{"endpoint", "health", "took", "error"}
@@ -72,8 +91,9 @@ func EndpointHealth(etcdFolderPath string) {
 			h.Error,
 		})
 	}
-	table := tablewriter.NewWriter(os.Stdout)
+	table := tablewriter.NewWriter(w)
 	table.SetHeader(hdr)
 	table.AppendBulk(rows)
 	table.Render()
+	return nil
 }
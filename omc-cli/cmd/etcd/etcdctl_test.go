@@ -5,7 +5,6 @@ import (
     "os"
     "bytes"
     "fmt"
-    "io"
     "strconv"
 )
 
@@ -32,16 +31,10 @@ func TestEndpointStatus(t *testing.T) {
         t.Fatal(err)
     }
 
-    // Redirect stdout to a buffer
-    old := os.Stdout
-    r, w, _ := os.Pipe()
-    os.Stdout = w
-    EndpointStatus("/tmp/")
-    w.Close()
-    os.Stdout = old
     var output bytes.Buffer
-    io.Copy(&output, r)
-    r.Close()
+    if err := EndpointStatus(&output, "/tmp/"); err != nil {
+        t.Fatal(err)
+    }
 
     for _, endpoint := range endpoints {
         if !bytes.Contains(output.Bytes(), []byte(endpoint)) {
@@ -73,16 +66,10 @@ func TestEndpointHealth(t *testing.T) {
         t.Fatal(err)
     }
 
-    // Redirect stdout to a buffer
-    old := os.Stdout
-    r, w, _ := os.Pipe()
-    os.Stdout = w
-    EndpointHealth("/tmp/")
-    w.Close()
-    os.Stdout = old
     var output bytes.Buffer
-    io.Copy(&output, r)
-    r.Close()
+    if err := EndpointHealth(&output, "/tmp/"); err != nil {
+        t.Fatal(err)
+    }
 
     for _, endpoint := range endpoints {
         if !bytes.Contains(output.Bytes(), []byte(endpoint)) {
@@ -16,21 +16,23 @@ limitations under the License.
 package etcd
 
 import (
+	"io"
+
 	"github.com/gmeghnag/omc/vars"
 
 	"github.com/spf13/cobra"
 )
 
-func etcdHealthCommand(currentContextPath string) {
+func etcdHealthCommand(w io.Writer, currentContextPath string) error {
 	etcdFolderPath := currentContextPath + "/etcd_info/"
-	EndpointHealth(etcdFolderPath)
+	return EndpointHealth(w, etcdFolderPath)
 }
 
 // etcdCmd represents the etcd command
 var Health = &cobra.Command{
 	Use:   "health",
 	Short: "Etcd health",
-	Run: func(cmd *cobra.Command, args []string) {
-		etcdHealthCommand(vars.MustGatherRootPath)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return etcdHealthCommand(cmd.OutOrStdout(), vars.MustGatherRootPath)
 	},
 }
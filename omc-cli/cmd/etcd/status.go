@@ -16,21 +16,23 @@ limitations under the License.
 package etcd
 
 import (
+	"io"
+
 	"github.com/gmeghnag/omc/vars"
 
 	"github.com/spf13/cobra"
 )
 
-func etcdStatusCommand(currentContextPath string) {
+func etcdStatusCommand(w io.Writer, currentContextPath string) error {
 	etcdFolderPath := currentContextPath + "/etcd_info/"
-	EndpointStatus(etcdFolderPath)
+	return EndpointStatus(w, etcdFolderPath)
 }
 
 // etcdCmd represents the etcd command
 var Status = &cobra.Command{
 	Use:   "status",
 	Short: "Etcd status",
-	Run: func(cmd *cobra.Command, args []string) {
-		etcdStatusCommand(vars.MustGatherRootPath)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return etcdStatusCommand(cmd.OutOrStdout(), vars.MustGatherRootPath)
 	},
 }
@@ -17,6 +17,7 @@ package events
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"slices"
 	"strings"
@@ -38,16 +39,19 @@ import (
 var EventsCmd = &cobra.Command{
 	Use:   "events",
 	Short: "Display events that are sorted by time.",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		err := Validate()
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			return err
+		}
+		eventList, err := GetEventList(vars.MustGatherRootPath, vars.Namespace, vars.AllNamespaceBoolVar)
+		if err != nil {
+			return err
 		}
-		eventList := GetEventList(vars.MustGatherRootPath, vars.Namespace, vars.AllNamespaceBoolVar)
 		FilterEventList(&eventList, vars.EventTypes, vars.ForResource)
 		SortEventList(&eventList)
-		PrintEventList(&eventList, vars.MustGatherRootPath, vars.OutputStringVar, vars.Namespace, vars.AllNamespaceBoolVar)
+		PrintEventList(cmd.OutOrStdout(), &eventList, vars.MustGatherRootPath, vars.OutputStringVar, vars.Namespace, vars.AllNamespaceBoolVar)
+		return nil
 	},
 }
 
@@ -65,20 +69,19 @@ func Validate() error {
 	return nil
 }
 
-func GetEventList(context string, selectedNs string, allNamespaces bool) (eventList corev1.EventList) {
+func GetEventList(context string, selectedNs string, allNamespaces bool) (eventList corev1.EventList, err error) {
 	eventsLocation := "/core/events.yaml"
 	nsFolder := context + "/namespaces/"
 	var namespaces []string
 	if allNamespaces {
 		fileObj, err := os.Open(nsFolder)
 		if err != nil {
-			klog.ErrorS(err, "Unable to read "+nsFolder)
-			os.Exit(1)
+			return eventList, fmt.Errorf("Unable to read %s: %w", nsFolder, err)
 		}
+		defer fileObj.Close()
 		namespaces, err = fileObj.Readdirnames(0)
 		if err != nil {
-			klog.ErrorS(err, "Unable to list directories in "+nsFolder)
-			os.Exit(1)
+			return eventList, fmt.Errorf("Unable to list directories in %s: %w", nsFolder, err)
 		}
 	} else {
 		namespaces = append(namespaces, selectedNs)
@@ -102,7 +105,7 @@ func GetEventList(context string, selectedNs string, allNamespaces bool) (eventL
 		}
 		eventList.Items = append(eventList.Items, nsEvents.Items...)
 	}
-	return eventList
+	return eventList, nil
 }
 
 func FilterEventList(eventList *corev1.EventList, types []string, forResource string) {
@@ -149,12 +152,12 @@ func SortEventList(eventList *corev1.EventList) {
 	})
 }
 
-func PrintEventList(eventList *corev1.EventList, context string, output string, selectedNs string, allNamespaces bool) {
+func PrintEventList(w io.Writer, eventList *corev1.EventList, context string, output string, selectedNs string, allNamespaces bool) {
 	if len(eventList.Items) == 0 {
 		if allNamespaces {
-			fmt.Printf("No events found.\n")
+			fmt.Fprintf(w, "No events found.\n")
 		} else {
-			fmt.Printf("No events found in %s namespace.\n", selectedNs)
+			fmt.Fprintf(w, "No events found in %s namespace.\n", selectedNs)
 		}
 	}
 
@@ -162,20 +165,20 @@ func PrintEventList(eventList *corev1.EventList, context string, output string,
 	if output == "name" {
 		cliPrinter := cliprint.NamePrinter{ShortOutput: true}
 		for _, event := range eventList.Items {
-			err := cliPrinter.PrintObj(&event, os.Stdout)
+			err := cliPrinter.PrintObj(&event, w)
 			if err != nil {
 				klog.V(3).ErrorS(err, "Error when outputting names of events")
 			}
 		}
 	} else if output == "yaml" {
 		cliPrinter := cliprint.YAMLPrinter{}
-		err := cliPrinter.PrintObj(eventList, os.Stdout)
+		err := cliPrinter.PrintObj(eventList, w)
 		if err != nil {
 			klog.V(3).ErrorS(err, "Error when outputting YAML of events")
 		}
 	} else if output == "json" {
 		cliPrinter := cliprint.JSONPrinter{}
-		err := cliPrinter.PrintObj(eventList, os.Stdout)
+		err := cliPrinter.PrintObj(eventList, w)
 		if err != nil {
 			klog.V(3).ErrorS(err, "Error when outputting JSON of events")
 		}
@@ -204,7 +207,7 @@ func PrintEventList(eventList *corev1.EventList, context string, output string,
 		}
 
 		cliPrinter = cliprint.NewTablePrinter(cliprint.PrintOptions{})
-		err = cliPrinter.PrintObj(table, os.Stdout)
+		err = cliPrinter.PrintObj(table, w)
 		if err != nil {
 			klog.V(3).ErrorS(err, "Error when outputting table of events")
 		}
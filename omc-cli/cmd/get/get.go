@@ -26,8 +26,11 @@ import (
 	goyaml "gopkg.in/yaml.v2"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	cliprint "k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/util/jsonpath"
 	"k8s.io/klog/v2"
 	"k8s.io/kube-aggregator/pkg/apis/apiregistration"
 	"k8s.io/kubernetes/pkg/printers"
@@ -76,48 +79,121 @@ var yamlData []byte
 var GetCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Get kubernetes/openshift object in tabular format or wide|yaml|json|jsonpath|custom-columns.",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
-			cmd.Help()
-			os.Exit(0)
+			return cmd.Help()
 		}
 		if vars.OutputStringVar == "wide" {
 			vars.Wide = true
 		}
-		err := validateArgs(args)
+		output, err := outputFromFile(vars.OutputStringVar)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "%s", err.Error())
-			os.Exit(1)
+			return err
+		}
+		vars.OutputStringVar = output
+		if _, err := labels.Parse(vars.LabelSelectorStringVar); err != nil {
+			return fmt.Errorf("invalid label selector %q: %w", vars.LabelSelectorStringVar, err)
+		}
+		if _, err := fields.ParseSelector(vars.FieldSelectorStringVar); err != nil {
+			return fmt.Errorf("invalid field selector %q: %w", vars.FieldSelectorStringVar, err)
+		}
+		var sortBy *jsonpath.JSONPath
+		if vars.SortByStringVar != "" {
+			parser, err := sortParser(vars.SortByStringVar)
+			if err != nil {
+				return err
+			}
+			sortBy = parser
+		}
+		err = validateArgs(args)
+		if err != nil {
+			return err
 		}
 		for resource := range vars.GetArgs {
 			resourceNamePlural, resourceGroup, _, namespaced, err := KindGroupNamespaced(resource)
 			if err != nil {
 				klog.V(1).ErrorS(err, "ERROR")
-				os.Exit(1)
+				return err
 			}
-			// namespaces and projects resources
-			// are exceptions to must-gather resources structure
-			if resourceNamePlural == "namespaces" || resourceNamePlural == "projects" {
-				getNamespacesResources(resourceNamePlural, resourceGroup, vars.GetArgs[resourceNamePlural+"."+resourceGroup])
-			} else if resourceNamePlural == "podnetworkconnectivitychecks" {
-				getPodNetworkConnectivityChecksResources(resourceNamePlural, resourceGroup, vars.GetArgs[resourceNamePlural+"."+resourceGroup])
-			} else if namespaced {
-				getNamespacedResources(resourceNamePlural, resourceGroup, vars.GetArgs[resourceNamePlural+"."+resourceGroup])
-			} else {
-				getClusterScopedResources(resourceNamePlural, resourceGroup, vars.GetArgs[resourceNamePlural+"."+resourceGroup])
+			resources := vars.GetArgs[resourceNamePlural+"."+resourceGroup]
+			if sortBy == nil {
+				err = getResources(resourceNamePlural, resourceGroup, namespaced, resources, handleObject)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			// the objects are sorted before being handled, by type so that
+			// each type keeps its own table
+			var objects []unstructured.Unstructured
+			err = getResources(resourceNamePlural, resourceGroup, namespaced, resources, func(obj unstructured.Unstructured) error {
+				objects = append(objects, obj)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if err := sortObjects(objects, sortBy); err != nil {
+				return err
+			}
+			for _, obj := range objects {
+				if err := handleObject(obj); err != nil {
+					return err
+				}
 			}
 		}
-		handleOutput(os.Stdout)
+		return handleOutput(cmd.OutOrStdout())
 	},
 }
 
+// getResources reads the resources of a type from the must-gather, and passes
+// the ones named in resources, or all of them when it is empty, to handle.
+func getResources(resourceNamePlural string, resourceGroup string, namespaced bool, resources map[string]struct{}, handle func(unstructured.Unstructured) error) error {
+	// namespaces and projects resources
+	// are exceptions to must-gather resources structure
+	if resourceNamePlural == "namespaces" || resourceNamePlural == "projects" {
+		return getNamespacesResources(resourceNamePlural, resourceGroup, resources, handle)
+	} else if resourceNamePlural == "podnetworkconnectivitychecks" {
+		return getPodNetworkConnectivityChecksResources(resourceNamePlural, resourceGroup, resources, handle)
+	} else if namespaced {
+		return getNamespacedResources(resourceNamePlural, resourceGroup, resources, handle)
+	}
+	return getClusterScopedResources(resourceNamePlural, resourceGroup, resources, handle)
+}
+
+// Objects returns the objects of the resource type alias named in names, or
+// all of them when names is empty. The namespaced objects are read from
+// vars.Namespace, or from all the namespaces when vars.AllNamespaceBoolVar is
+// set.
+func Objects(alias string, names []string) ([]unstructured.Unstructured, error) {
+	resourceNamePlural, resourceGroup, _, namespaced, err := KindGroupNamespaced(strings.ToLower(alias))
+	if err != nil {
+		return nil, err
+	}
+	resources := make(map[string]struct{})
+	for _, name := range names {
+		resources[name] = struct{}{}
+	}
+	var objects []unstructured.Unstructured
+	err = getResources(resourceNamePlural, resourceGroup, namespaced, resources, func(obj unstructured.Unstructured) error {
+		if vars.Namespace != "" && obj.GetNamespace() != "" && vars.Namespace != obj.GetNamespace() {
+			return nil
+		}
+		objects = append(objects, obj)
+		return nil
+	})
+	return objects, err
+}
+
 func init() {
 	GetCmd.PersistentFlags().BoolVarP(&vars.AllNamespaceBoolVar, "all-namespaces", "A", false, "If present, list the requested object(s) across all namespaces.")
 	GetCmd.PersistentFlags().BoolVar(&vars.NoHeaders, "no-headers", false, "When using the default or custom-column output format, don't print headers (default print headers).")
 	GetCmd.PersistentFlags().BoolVar(&vars.ShowManagedFields, "show-managed-fields", false, "If true, show the managedFields when printing objects in JSON or YAML format.")
 	GetCmd.PersistentFlags().BoolVarP(&vars.ShowLabelsBoolVar, "show-labels", "", false, "When printing, show all labels as the last column (default hide labels column)")
-	GetCmd.PersistentFlags().StringVarP(&vars.OutputStringVar, "output", "o", "", "Output format. One of: json|yaml|wide|jsonpath|custom-columns=...")
-	GetCmd.PersistentFlags().StringVarP(&vars.LabelSelectorStringVar, "selector", "l", "", "selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	GetCmd.PersistentFlags().StringVarP(&vars.OutputStringVar, "output", "o", "", "Output format. One of: json|yaml|wide|name|jsonpath=...|go-template=...|go-template-file=...|custom-columns=...|custom-columns-file=...")
+	GetCmd.PersistentFlags().StringVarP(&vars.LabelSelectorStringVar, "selector", "l", "", "selector (label query) to filter on, supports '=', '==', '!=', 'in', 'notin' and existence.(e.g. -l key1=value1,key2 in (value2,value3),!key3)")
+	GetCmd.PersistentFlags().StringVar(&vars.SortByStringVar, "sort-by", "", "If non-empty, sort list types using this field specification. The field specification is expressed as a JSONPath expression (e.g. '{.metadata.name}'). The field in the API resource specified by this JSONPath expression must be an integer or a string.")
+	GetCmd.PersistentFlags().StringVar(&vars.FieldSelectorStringVar, "field-selector", "", "Selector (field query) to filter on, supports '=', '==', and '!=' on any field of the objects.(e.g. --field-selector status.phase!=Running,spec.nodeName=worker-0)")
 }
 
 func init() {
@@ -188,7 +264,7 @@ func init() {
 	templateprinters.AddTemplateOpenShiftHandlers(vars.TableGenerator)
 }
 
-func getNamespacedResources(resourceNamePlural string, resourceGroup string, resources map[string]struct{}) {
+func getNamespacedResources(resourceNamePlural string, resourceGroup string, resources map[string]struct{}, handle func(unstructured.Unstructured) error) error {
 	var namespaces []string
 	if vars.AllNamespaceBoolVar {
 		vars.Namespace = ""
@@ -222,31 +298,28 @@ func getNamespacedResources(resourceNamePlural string, resourceGroup string, res
 						podPath := fmt.Sprintf("%s/%s/%s.yaml", podsDir, podName, podName)
 						_file, err := os.ReadFile(podPath)
 						if err != nil {
-							fmt.Fprintf(os.Stderr, "error reading %s: %s\n", podPath, err)
-							os.Exit(1)
+							return fmt.Errorf("error reading %s: %s", podPath, err)
 						}
 						var podItem unstructured.Unstructured
 						if err := yaml.Unmarshal(_file, &podItem); err != nil {
-							fmt.Fprintln(os.Stderr, "Error when trying to unmarshal file "+podPath)
-							os.Exit(1)
+							return fmt.Errorf("Error when trying to unmarshal file %s", podPath)
 						}
 						if podItem.Object != nil {
 							UnstructuredItems.Items = append(UnstructuredItems.Items, podItem)
 						}
 					}
 				} else {
-					fmt.Fprintln(os.Stderr, err)
-					os.Exit(1)
+					return err
 				}
 			}
 			for _, item := range UnstructuredItems.Items {
 				if len(resources) > 0 {
 					_, ok := resources[item.GetName()]
 					if ok {
-						handleObject(item)
+						handle(item)
 					}
 				} else {
-					handleObject(item)
+					handle(item)
 				}
 			}
 		} else { // the resources are customresources so, stored in a single file per resource
@@ -262,24 +335,24 @@ func getNamespacedResources(resourceNamePlural string, resourceGroup string, res
 					_file, _ := os.ReadFile(resourceYamlPath)
 					item := unstructured.Unstructured{}
 					if err := yaml.Unmarshal(_file, &item); err != nil {
-						fmt.Fprintln(os.Stderr, "Error when trying to unmarshal file: "+resourceYamlPath)
-						os.Exit(1)
+						return fmt.Errorf("Error when trying to unmarshal file: %s", resourceYamlPath)
 					}
 					if len(resources) > 0 {
 						_, ok := resources[item.GetName()]
 						if ok {
-							handleObject(item)
+							handle(item)
 						}
 					} else {
-						handleObject(item)
+						handle(item)
 					}
 				}
 			}
 		}
 	}
+	return nil
 }
 
-func getNamespacesResources(resourceNamePlural string, resourceGroup string, resources map[string]struct{}) {
+func getNamespacesResources(resourceNamePlural string, resourceGroup string, resources map[string]struct{}, handle func(unstructured.Unstructured) error) error {
 	if len(resources) > 0 {
 		for namespace := range resources {
 			resourceYamlPath := fmt.Sprintf("%s/namespaces/%s/%s.yaml", vars.MustGatherRootPath, namespace, namespace)
@@ -287,10 +360,9 @@ func getNamespacesResources(resourceNamePlural string, resourceGroup string, res
 			if err == nil {
 				item := unstructured.Unstructured{}
 				if err := yaml.Unmarshal(_file, &item); err != nil {
-					fmt.Fprintln(os.Stderr, "Error when trying to unmarshal file: "+resourceYamlPath)
-					os.Exit(1)
+					return fmt.Errorf("Error when trying to unmarshal file: %s", resourceYamlPath)
 				}
-				handleObject(item)
+				handle(item)
 			}
 		}
 	} else {
@@ -301,16 +373,16 @@ func getNamespacesResources(resourceNamePlural string, resourceGroup string, res
 			if err == nil {
 				item := unstructured.Unstructured{}
 				if err := yaml.Unmarshal(_file, &item); err != nil {
-					fmt.Fprintln(os.Stderr, "Error when trying to unmarshal file: "+resourceYamlPath)
-					os.Exit(1)
+					return fmt.Errorf("Error when trying to unmarshal file: %s", resourceYamlPath)
 				}
-				handleObject(item)
+				handle(item)
 			}
 		}
 	}
+	return nil
 }
 
-func getClusterScopedResources(resourceNamePlural string, resourceGroup string, resources map[string]struct{}) {
+func getClusterScopedResources(resourceNamePlural string, resourceGroup string, resources map[string]struct{}, handle func(unstructured.Unstructured) error) error {
 	UnstructuredItems := types.UnstructuredList{ApiVersion: "v1", Kind: "List"}
 	resourcePath := fmt.Sprintf("%s/cluster-scoped-resources/%s/%s.yaml", vars.MustGatherRootPath, resourceGroup, resourceNamePlural)
 	_file, err := os.ReadFile(resourcePath)
@@ -325,39 +397,37 @@ func getClusterScopedResources(resourceNamePlural string, resourceGroup string,
 			_file, _ := os.ReadFile(resourceYamlPath)
 			item := unstructured.Unstructured{}
 			if err := yaml.Unmarshal(_file, &item); err != nil {
-				fmt.Fprintln(os.Stderr, "Error when trying to unmarshal file: "+resourceYamlPath)
-				os.Exit(1)
+				return fmt.Errorf("Error when trying to unmarshal file: %s", resourceYamlPath)
 			}
 			if item.IsList() {
-				fmt.Fprintln(os.Stderr, "error: file \""+resourceYamlPath+"\" contains a \"List\" objectKind, while it should contain a single resource.")
-				os.Exit(1)
+				return fmt.Errorf("error: file \"%s\" contains a \"List\" objectKind, while it should contain a single resource.", resourceYamlPath)
 			}
 			if len(resources) > 0 {
 				_, ok := resources[item.GetName()]
 				if ok {
-					handleObject(item)
+					handle(item)
 				}
 			} else {
-				handleObject(item)
+				handle(item)
 			}
 		}
 	} else {
 		if err := yaml.Unmarshal(_file, &UnstructuredItems); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+			return err
 		}
 		for _, item := range UnstructuredItems.Items {
 			if len(resources) > 0 {
 				_, ok := resources[item.GetName()]
 				if ok {
-					handleObject(item)
+					handle(item)
 				}
 			} else {
-				handleObject(item)
+				handle(item)
 			}
 		}
 	}
 
+	return nil
 }
 
 func handleObject(obj unstructured.Unstructured) error {
@@ -368,6 +438,10 @@ func handleObject(obj unstructured.Unstructured) error {
 	if !labelsOk {
 		return nil
 	}
+	fieldsOk, _ := helpers.MatchFieldsFromObject(obj.Object, vars.FieldSelectorStringVar)
+	if !fieldsOk {
+		return nil
+	}
 	vars.LastKind = obj.GetKind()
 	if vars.OutputStringVar == "yaml" || vars.OutputStringVar == "json" {
 		if !vars.ShowManagedFields {
@@ -376,7 +450,7 @@ func handleObject(obj unstructured.Unstructured) error {
 		vars.UnstructuredList.Items = append(vars.UnstructuredList.Items, obj)
 		return nil
 	}
-	if strings.HasPrefix(vars.OutputStringVar, "jsonpath=") {
+	if strings.HasPrefix(vars.OutputStringVar, "jsonpath=") || strings.HasPrefix(vars.OutputStringVar, "go-template=") {
 		if !vars.ShowManagedFields {
 			obj.SetManagedFields(nil)
 		}
@@ -446,7 +520,7 @@ func handleObject(obj unstructured.Unstructured) error {
 	return nil
 }
 
-func handleOutput(w io.Writer) {
+func handleOutput(w io.Writer) error {
 	printer := cliprint.NewTablePrinter(cliprint.PrintOptions{NoHeaders: vars.NoHeaders, Wide: vars.Wide, WithNamespace: false, ShowLabels: false})
 	_resources := make([]string, 0, len(vars.GetArgs))
 	var includesClusterScoped bool
@@ -477,11 +551,39 @@ func handleOutput(w io.Writer) {
 			}
 		}
 	} else if strings.HasPrefix(vars.OutputStringVar, "jsonpath=") {
-		jsonPathTemplate := helpers.GetJsonTemplate(vars.OutputStringVar)
+		jsonPathTemplate, err := helpers.GetJsonTemplate(vars.OutputStringVar)
+		if err != nil {
+			return err
+		}
 		if vars.SingleResource && len(vars.UnstructuredList.Items) == 1 {
-			helpers.ExecuteJsonPath(vars.UnstructuredList.Items[0].Object, jsonPathTemplate)
+			return helpers.ExecuteJsonPath(w, vars.UnstructuredList.Items[0].Object, jsonPathTemplate)
 		} else if !vars.SingleResource && len(vars.UnstructuredList.Items) > 0 {
-			helpers.ExecuteJsonPath(vars.JsonPathList, jsonPathTemplate)
+			return helpers.ExecuteJsonPath(w, vars.JsonPathList, jsonPathTemplate)
+		} else {
+			if vars.Namespace != "" {
+				fmt.Fprintf(w, "No resources %s found in %s namespace.\n", resources, vars.Namespace)
+			} else {
+				fmt.Fprintf(w, "No resources %s found.\n", resources)
+			}
+		}
+	} else if strings.HasPrefix(vars.OutputStringVar, "go-template=") {
+		goTemplate := strings.TrimPrefix(vars.OutputStringVar, "go-template=")
+		if goTemplate == "" {
+			return fmt.Errorf("error: template format specified but no template given")
+		}
+		templatePrinter, err := cliprint.NewGoTemplatePrinter([]byte(goTemplate))
+		if err != nil {
+			return fmt.Errorf("error parsing template %s, %w", goTemplate, err)
+		}
+		templatePrinter.AllowMissingKeys(true)
+		if vars.SingleResource && len(vars.UnstructuredList.Items) == 1 {
+			return templatePrinter.PrintObj(&vars.UnstructuredList.Items[0], w)
+		} else if !vars.SingleResource && len(vars.UnstructuredList.Items) > 0 {
+			items := make([]interface{}, 0, len(vars.JsonPathList.Items))
+			for _, item := range vars.JsonPathList.Items {
+				items = append(items, item)
+			}
+			return templatePrinter.PrintObj(&unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": items}}, w)
 		} else {
 			if vars.Namespace != "" {
 				fmt.Fprintf(w, "No resources %s found in %s namespace.\n", resources, vars.Namespace)
@@ -522,22 +624,23 @@ func handleOutput(w io.Writer) {
 			vars.Output.WriteTo(w)
 		}
 	}
+	return nil
 }
 
-func getPodNetworkConnectivityChecksResources(resourceNamePlural string, resourceGroup string, resources map[string]struct{}) {
+func getPodNetworkConnectivityChecksResources(resourceNamePlural string, resourceGroup string, resources map[string]struct{}, handle func(unstructured.Unstructured) error) error {
 	resourcesYamlPath := vars.MustGatherRootPath + "/pod_network_connectivity_check/podnetworkconnectivitychecks.yaml"
 	_file, err := ioutil.ReadFile(resourcesYamlPath)
 	if err == nil {
 		UnstructuredItems := types.UnstructuredList{ApiVersion: "v1", Kind: "List"}
 		if err := yaml.Unmarshal(_file, &UnstructuredItems); err != nil {
-			fmt.Fprintln(os.Stderr, "Error when trying to unmarshal file: "+resourcesYamlPath)
-			os.Exit(1)
+			return fmt.Errorf("Error when trying to unmarshal file: %s", resourcesYamlPath)
 		}
 		for _, item := range UnstructuredItems.Items {
 			_, ok := resources[item.GetName()]
 			if ok || len(resources) == 0 {
-				handleObject(item)
+				handle(item)
 			}
 		}
 	}
+	return nil
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gmeghnag/omc/types"
 	"github.com/gmeghnag/omc/vars"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 func TestHandleEmptyWideOutput(t *testing.T) {
@@ -69,3 +70,139 @@ func TestHandleEmptyWideOutput(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleObjectSelectors(t *testing.T) {
+	pods := []unstructured.Unstructured{
+		{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]interface{}{"name": "running", "labels": map[string]interface{}{"app": "a", "tier": "web"}}, "spec": map[string]interface{}{"nodeName": "worker-0"}, "status": map[string]interface{}{"phase": "Running"}}},
+		{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]interface{}{"name": "pending", "labels": map[string]interface{}{"app": "b", "canary": "true"}}, "status": map[string]interface{}{"phase": "Pending"}}},
+		{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]interface{}{"name": "failed", "labels": map[string]interface{}{"app": "c", "tier": "db"}}, "spec": map[string]interface{}{"nodeName": "worker-1"}, "status": map[string]interface{}{"phase": "Failed"}}},
+	}
+	tests := []struct {
+		labelSelector string
+		fieldSelector string
+		want          string
+	}{
+		{want: "pod/running\npod/pending\npod/failed\n"},
+		{fieldSelector: "status.phase!=Running", want: "pod/pending\npod/failed\n"},
+		{fieldSelector: "spec.nodeName=worker-1", want: "pod/failed\n"},
+		{fieldSelector: "spec.nodeName==", want: "pod/pending\n"},
+		{fieldSelector: "metadata.name=running,status.phase=Running", want: "pod/running\n"},
+		{labelSelector: "app=a", want: "pod/running\n"},
+		{labelSelector: "app in (a,b)", want: "pod/running\npod/pending\n"},
+		{labelSelector: "!canary", want: "pod/running\npod/failed\n"},
+		{labelSelector: "tier notin (db)", want: "pod/running\npod/pending\n"},
+		{labelSelector: "tier,app!=a", want: "pod/failed\n"},
+		{labelSelector: "app in (a,c)", fieldSelector: "status.phase!=Running", want: "pod/failed\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.labelSelector+" "+tt.fieldSelector, func(t *testing.T) {
+			vars.Reset()
+			defer vars.Reset()
+			vars.OutputStringVar = "name"
+			vars.LabelSelectorStringVar = tt.labelSelector
+			vars.FieldSelectorStringVar = tt.fieldSelector
+			for _, pod := range pods {
+				if err := handleObject(pod); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := vars.Output.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortObjects(t *testing.T) {
+	pod := func(name string, restarts interface{}, created string, cpu string, memory string) unstructured.Unstructured {
+		obj := unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]interface{}{"name": name, "creationTimestamp": created}}}
+		obj.Object["spec"] = map[string]interface{}{"containers": []interface{}{map[string]interface{}{"resources": map[string]interface{}{"requests": map[string]interface{}{"cpu": cpu, "memory": memory}}}}}
+		if restarts != nil {
+			obj.Object["status"] = map[string]interface{}{"containerStatuses": []interface{}{map[string]interface{}{"restartCount": restarts}}}
+		}
+		return obj
+	}
+	pods := []unstructured.Unstructured{
+		pod("b", int64(10), "2023-11-02T06:00:00Z", "1", "512Mi"),
+		pod("c", nil, "2023-11-01T06:00:00Z", "500m", "2Gi"),
+		pod("a", int64(2), "2023-11-03T06:00:00Z", "250m", "1Gi"),
+	}
+	tests := []struct {
+		field   string
+		want    []string
+		wantErr bool
+	}{
+		{field: "metadata.name", want: []string{"a", "b", "c"}},
+		{field: "{.metadata.creationTimestamp}", want: []string{"c", "b", "a"}},
+		{field: ".status.containerStatuses[0].restartCount", want: []string{"c", "a", "b"}},
+		{field: ".spec.containers[0].resources.requests.cpu", want: []string{"a", "c", "b"}},
+		{field: ".spec.containers[0].resources.requests.memory", want: []string{"b", "a", "c"}},
+		{field: "{.metadata.name", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			parser, err := sortParser(tt.field)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			objects := append([]unstructured.Unstructured(nil), pods...)
+			if err := sortObjects(objects, parser); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, obj := range objects {
+				got = append(got, obj.GetName())
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleOutputGoTemplate(t *testing.T) {
+	pods := []unstructured.Unstructured{
+		{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]interface{}{"name": "first"}}},
+		{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]interface{}{"name": "second"}}},
+	}
+	tests := []struct {
+		name           string
+		singleResource bool
+		template       string
+		want           string
+	}{
+		{name: "list", template: `{{range .items}}{{.metadata.name}} {{end}}`, want: "first second "},
+		{name: "single resource", singleResource: true, template: `{{.kind}}/{{.metadata.name}}`, want: "Pod/first"},
+		{name: "missing keys", singleResource: true, template: `{{.metadata.missing}}`, want: "<no value>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars.Reset()
+			defer vars.Reset()
+			vars.OutputStringVar = "go-template=" + tt.template
+			vars.SingleResource = tt.singleResource
+			objects := pods
+			if tt.singleResource {
+				objects = pods[:1]
+			}
+			for _, pod := range objects {
+				if err := handleObject(pod); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var output bytes.Buffer
+			if err := handleOutput(&output); err != nil {
+				t.Fatal(err)
+			}
+			if output.String() != tt.want {
+				t.Errorf("got %q, want %q", output.String(), tt.want)
+			}
+		})
+	}
+}
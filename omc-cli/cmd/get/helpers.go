@@ -15,6 +15,40 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
+// outputFromFile returns the output format with the template read from the
+// file of the go-template-file and custom-columns-file formats, in the
+// go-template and custom-columns form. The other formats are returned as is.
+func outputFromFile(output string) (string, error) {
+	format, path, found := strings.Cut(output, "=")
+	if !found || (format != "go-template-file" && format != "custom-columns-file") {
+		return output, nil
+	}
+	if path == "" {
+		return "", fmt.Errorf("error: %s format specified but no file given", format)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading template %s, %w", path, err)
+	}
+	if format == "go-template-file" {
+		return "go-template=" + string(data), nil
+	}
+	// like kubectl, the headers on the first line and the fields on the second
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		return "", fmt.Errorf("unexpected custom-columns template file, expected 2 lines, found %d", len(lines))
+	}
+	headers, fields := strings.Fields(lines[0]), strings.Fields(lines[1])
+	if len(headers) != len(fields) {
+		return "", fmt.Errorf("number of headers (%d) does not match number of spec (%d)", len(headers), len(fields))
+	}
+	columns := make([]string, len(headers))
+	for i := range headers {
+		columns[i] = headers[i] + ":" + fields[i]
+	}
+	return "custom-columns=" + strings.Join(columns, ","), nil
+}
+
 func validateArgs(args []string) error {
 	if len(args) == 1 && args[0] == "all" {
 		args = []string{"pods.core,services.core,daemonsets.apps,deployments.apps,replicasets.apps,statefulsets.apps,replicationcontrollers.core,deploymentconfigs.apps.openshift.io,builds.build.openshift.io,buildconfigs.build.openshift.io,jobs.batch,cronjobs.batch,routes.route.openshift.io,ingresses.networking.k8s.io,"}
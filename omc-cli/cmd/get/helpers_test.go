@@ -2,6 +2,8 @@ package get
 
 import (
 	"io/fs"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"testing"
@@ -84,3 +86,47 @@ func TestReadDirForResources(t *testing.T) {
 		})
 	}
 }
+
+func TestOutputFromFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"template.tmpl": "{{range .items}}{{.metadata.name}}{{end}}",
+		"columns.txt":   "NAME      RESTARTS\n.metadata.name   {.status.containerStatuses[0].restartCount}\n",
+		"invalid.txt":   "NAME RESTARTS\n.metadata.name\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{output: "wide", want: "wide"},
+		{output: "jsonpath={.items[*].metadata.name}", want: "jsonpath={.items[*].metadata.name}"},
+		{output: "go-template-file=" + filepath.Join(dir, "template.tmpl"), want: "go-template={{range .items}}{{.metadata.name}}{{end}}"},
+		{output: "custom-columns-file=" + filepath.Join(dir, "columns.txt"), want: "custom-columns=NAME:.metadata.name,RESTARTS:{.status.containerStatuses[0].restartCount}"},
+		{output: "custom-columns-file=" + filepath.Join(dir, "invalid.txt"), wantErr: true},
+		{output: "go-template-file=" + filepath.Join(dir, "missing.tmpl"), wantErr: true},
+		{output: "go-template-file=", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			got, err := outputFromFile(tt.output)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package get
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// sortFieldFormat matches the forms kubectl accepts for --sort-by, like
+// metadata.name, .metadata.name, {metadata.name} and {.metadata.name}.
+var sortFieldFormat = regexp.MustCompile(`^\{\.?([^{}]+)\}$|^\.?([^{}]+)$`)
+
+// sortParser returns the parser of the --sort-by JSON path expression.
+func sortParser(field string) (*jsonpath.JSONPath, error) {
+	matches := sortFieldFormat.FindStringSubmatch(field)
+	if matches == nil {
+		return nil, fmt.Errorf("unexpected path string %q, expected a 'name1.name2' or '.name1.name2' or '{name1.name2}' or '{.name1.name2}'", field)
+	}
+	expression := matches[1]
+	if expression == "" {
+		expression = matches[2]
+	}
+	parser := jsonpath.New("sort-by").AllowMissingKeys(true)
+	if err := parser.Parse("{." + expression + "}"); err != nil {
+		return nil, fmt.Errorf("error parsing jsonpath %s: %w", field, err)
+	}
+	return parser, nil
+}
+
+// sortObjects sorts the objects by the value the parser finds in them, like
+// kubectl does: the numbers and the quantities, like 100m or 1Gi, by their
+// value and the other strings, timestamps included, in lexical order. The
+// objects without the value come first.
+func sortObjects(objects []unstructured.Unstructured, parser *jsonpath.JSONPath) error {
+	values := make([]interface{}, len(objects))
+	for i, obj := range objects {
+		results, err := parser.FindResults(obj.Object)
+		if err != nil {
+			return err
+		}
+		if len(results) > 0 && len(results[0]) > 0 && results[0][0].CanInterface() {
+			values[i] = results[0][0].Interface()
+		}
+	}
+	indexes := make([]int, len(objects))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return lessValue(values[indexes[i]], values[indexes[j]])
+	})
+	sorted := make([]unstructured.Unstructured, len(objects))
+	for i, index := range indexes {
+		sorted[i] = objects[index]
+	}
+	copy(objects, sorted)
+	return nil
+}
+
+// lessValue compares the values of two objects, the missing values first.
+func lessValue(i, j interface{}) bool {
+	if i == nil || j == nil {
+		return i == nil && j != nil
+	}
+	if iNumber, ok := number(i); ok {
+		if jNumber, ok := number(j); ok {
+			return iNumber < jNumber
+		}
+	}
+	if iQuantity, ok := quantity(i); ok {
+		if jQuantity, ok := quantity(j); ok {
+			return iQuantity.Cmp(jQuantity) < 0
+		}
+	}
+	if iBool, ok := i.(bool); ok {
+		if jBool, ok := j.(bool); ok {
+			return !iBool && jBool
+		}
+	}
+	return fmt.Sprint(i) < fmt.Sprint(j)
+}
+
+// quantity returns the resource quantity a string value holds.
+func quantity(value interface{}) (resource.Quantity, bool) {
+	s, ok := value.(string)
+	if !ok {
+		return resource.Quantity{}, false
+	}
+	q, err := resource.ParseQuantity(s)
+	return q, err == nil
+}
+
+func number(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
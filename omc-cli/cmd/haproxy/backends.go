@@ -37,7 +37,7 @@ var includeOpenShiftNamespaces bool
 var Backends = &cobra.Command{
 	Use:   "backends",
 	Short: "Inspect haproxy configured backends.",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		// in general if omc is not invoked with a specific --namespace / -n
 		// option, it defaults to the user's current context project (see
@@ -50,23 +50,36 @@ var Backends = &cobra.Command{
 		if cmd.Flags().Changed("namespace") {
 			wantedNamespace = vars.Namespace
 		}
-		writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
-		fmt.Fprintln(writer, "NAMESPACE\tNAME\tINGRESSCONTROLLER\tSERVICES\tPORT\tTERMINATION")
-		for _, configfile := range haproxyConfigFiles(vars.MustGatherRootPath) {
-			backends := parseHAProxyConfig(configfile, wantedNamespace)
-			for _, b := range backends {
-				fmt.Fprintln(writer, b)
-			}
+		writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 1, '\t', tabwriter.AlignRight)
+		fmt.Fprintln(writer, strings.Join(BackendsHeaders, "\t"))
+		for _, row := range BackendsRows(vars.MustGatherRootPath, wantedNamespace) {
+			fmt.Fprintln(writer, strings.Join(row, "\t")+"\t")
 		}
-		writer.Flush()
+		return writer.Flush()
 	},
 }
 
+// BackendsHeaders are the columns of the backends table.
+var BackendsHeaders = []string{"NAMESPACE", "NAME", "INGRESSCONTROLLER", "SERVICES", "PORT", "TERMINATION"}
+
+// BackendsRows returns the rows of the backends table for the haproxy config
+// files of the must-gather in root, only the backends in wantedNamespace when
+// it is not empty.
+func BackendsRows(root string, wantedNamespace string) [][]string {
+	var rows [][]string
+	for _, configfile := range haproxyConfigFiles(root) {
+		for _, b := range parseHAProxyConfig(configfile, wantedNamespace) {
+			rows = append(rows, b.fields())
+		}
+	}
+	return rows
+}
+
 func haproxyConfigFiles(root string) []string {
 	pattern := root + haproxy_config_glob
 	files, err := filepath.Glob(pattern)
 	if err != nil {
-		fmt.Println("Error:", err)
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		return nil
 	}
 	return files
@@ -78,7 +91,7 @@ func parseHAProxyConfig(filename string, wantedNamespace string) []*backend {
 	ic := icFromFileName(filename)
 	file, err := os.Open(filename)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		return nil
 	}
 	defer file.Close()
@@ -106,7 +119,7 @@ func parseHAProxyConfig(filename string, wantedNamespace string) []*backend {
 	}
 
 	if err := scanner.Err(); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 	}
 	return backends
 }
@@ -128,6 +141,11 @@ type backend struct {
 }
 
 func (b backend) String() string {
+	return strings.Join(b.fields(), "\t") + "\t"
+}
+
+// fields returns the columns of the backend in the backends table.
+func (b backend) fields() []string {
 	terminationType := func(s string) string {
 		mapping := map[string]string{
 			"be_edge_http": "edge/Redirect",
@@ -137,7 +155,11 @@ func (b backend) String() string {
 		}
 		return mapping[s]
 	}
-	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t", b.namespace, b.routeName, b.ingressController, b.service.serviceName, b.service.port, terminationType(b.termination))
+	serviceName, servicePort := "", ""
+	if b.service != nil {
+		serviceName, servicePort = b.service.serviceName, b.service.port.String()
+	}
+	return []string{b.namespace, b.routeName, b.ingressController, serviceName, servicePort, terminationType(b.termination)}
 }
 
 func newBackendFromLine(raw []string) *backend {
@@ -199,7 +221,7 @@ func serviceFromServerLine(line string) *service {
 	parts := strings.Split(line, ":")
 	portNr, err := strconv.Atoi(parts[4])
 	if err != nil {
-		fmt.Printf("Failed to convert port value (%+v) to an int.\n", parts[4])
+		fmt.Fprintf(os.Stderr, "Failed to convert port value (%+v) to an int.\n", parts[4])
 		return &service{
 			serviceName: parts[1],
 			port:        &port{portName: parts[2]},
@@ -262,3 +262,15 @@ func TestServiceString(t *testing.T) {
 		})
 	}
 }
+
+func TestBackendsRows(t *testing.T) {
+	includeOpenShiftNamespaces = false
+	expected := [][]string{
+		{"testdata", "rails-postgresql-example", "default", "rails-postgresql-example", "web(8080)", "http"},
+		{"testdata", "app.example.com", "default", "hello-node", "8080", "http"},
+	}
+	found := BackendsRows(testdata, "testdata")
+	if !reflect.DeepEqual(found, expected) {
+		t.Fatalf("Expected : %+v, got: %+v", expected, found)
+	}
+}
@@ -17,17 +17,14 @@ limitations under the License.
 package haproxy
 
 import (
-	"os"
-
 	"github.com/spf13/cobra"
 )
 
 var Haproxy = &cobra.Command{
 	Use:   "haproxy",
 	Short: "Inspect haproxy config.",
-	Run: func(cmd *cobra.Command, args []string) {
-		cmd.Help()
-		os.Exit(0)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
 	},
 }
 
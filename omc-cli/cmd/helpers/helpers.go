@@ -8,9 +8,9 @@ import (
 	"io"
 	"io/fs"
 	"io/ioutil"
-	"log"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -21,6 +21,10 @@ import (
 	"github.com/olekukonko/tablewriter"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/client-go/util/jsonpath"
 	"sigs.k8s.io/yaml"
 )
@@ -50,7 +54,11 @@ func RandString(length int) string {
 }
 
 func PrintTable(headers []string, data [][]string) {
-	table := tablewriter.NewWriter(os.Stdout)
+	FprintTable(os.Stdout, headers, data)
+}
+
+func FprintTable(w io.Writer, headers []string, data [][]string) {
+	table := tablewriter.NewWriter(w)
 	table.SetHeader(headers)
 	table.SetAutoWrapText(false)
 	table.SetAutoFormatHeaders(true)
@@ -102,28 +110,24 @@ func FormatDiffTime(diff time.Duration) string {
 	return strconv.Itoa(int(diff.Seconds())) + "s"
 }
 
-func ExecuteJsonPath(data interface{}, jsonPathTemplate string) {
+func ExecuteJsonPath(w io.Writer, data interface{}, jsonPathTemplate string) error {
 	buf := new(bytes.Buffer)
 	jPath := jsonpath.New("out")
 	jPath.AllowMissingKeys(false)
 	jPath.EnableJSONOutput(false)
 	err := jPath.Parse(jsonPathTemplate)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "error: error parsing jsonpath "+jsonPathTemplate+", "+err.Error())
-		os.Exit(1)
+		return fmt.Errorf("error: error parsing jsonpath %s, %s", jsonPathTemplate, err.Error())
 	}
 	jPath.Execute(buf, data)
-	fmt.Print(buf)
+	_, err = buf.WriteTo(w)
+	return err
 }
 
-func CreateConfigFile(cfgFilePath string) {
+func CreateConfigFile(cfgFilePath string) error {
 	config := types.Config{}
 	file, _ := json.MarshalIndent(config, "", " ")
-	err := ioutil.WriteFile(cfgFilePath, file, 0644)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
+	return ioutil.WriteFile(cfgFilePath, file, 0644)
 }
 
 func GetData(data [][]string, allNamespacesFlag bool, showLabels bool, labels string, outputFlag string, column int32, _list []string) [][]string {
@@ -175,11 +179,11 @@ func ExtractLabel(_labels map[string]string, _label string) string {
 }
 
 // doing this because of a bug who append three characthers to the first node yaml file
-func ReadYaml(YamlPath string) []byte {
+func ReadYaml(YamlPath string) ([]byte, error) {
 	var __file []byte
 	_file, err := os.Open(YamlPath)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer _file.Close()
 
@@ -191,9 +195,9 @@ func ReadYaml(YamlPath string) []byte {
 		}
 	}
 	if err := scanner.Err(); err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	return __file
+	return __file, nil
 }
 
 func GetAge(resourcefilePath string, resourceCreationTimeStamp v1.Time) string {
@@ -215,6 +219,34 @@ func GetAge(resourcefilePath string, resourceCreationTimeStamp v1.Time) string {
 
 }
 
+// MustGatherTime returns the time the must-gather in root was collected: the
+// last time written in its timestamp file, in root or in its parent when root
+// is the image directory, or the modification time of its resources.
+func MustGatherTime(root string) (time.Time, error) {
+	root = strings.TrimSuffix(root, "/")
+	for _, path := range []string{root + "/timestamp", filepath.Dir(root) + "/timestamp"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		// the times are written like "2023-11-02 06:12:08.604741676 +0000 UTC m=+0.017"
+		value, _, _ := strings.Cut(strings.TrimSpace(lines[len(lines)-1]), " m=")
+		if t, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", value); err == nil {
+			return t, nil
+		}
+		if info, err := os.Stat(path); err == nil {
+			return info.ModTime(), nil
+		}
+	}
+	for _, path := range []string{root + "/namespaces", root + "/cluster-scoped-resources"} {
+		if info, err := os.Stat(path); err == nil {
+			return info.ModTime(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("the time the must-gather %s was collected is unknown", root)
+}
+
 func IsDirectory(path string) (bool, error) {
 	fileInfo, err := os.Stat(path)
 	if err != nil {
@@ -235,7 +267,13 @@ func Exists(path string) (bool, error) {
 	return false, err
 }
 
-func PrintOutput(resource interface{}, columns int16, outputFlag string, resourceName string, allNamespacesFlag bool, showLabels bool, _headers []string, data [][]string, jsonPathTemplate string) bool {
+func PrintOutput(resource interface{}, columns int16, outputFlag string, resourceName string, allNamespacesFlag bool, showLabels bool, _headers []string, data [][]string, jsonPathTemplate string) error {
+	return FprintOutput(os.Stdout, resource, columns, outputFlag, allNamespacesFlag, showLabels, _headers, data, jsonPathTemplate)
+}
+
+// FprintOutput writes to w the resource in the format of outputFlag, the
+// headers and data table when it is empty or "wide".
+func FprintOutput(w io.Writer, resource interface{}, columns int16, outputFlag string, allNamespacesFlag bool, showLabels bool, _headers []string, data [][]string, jsonPathTemplate string) error {
 	var headers []string
 	if outputFlag == "" {
 		if allNamespacesFlag == true {
@@ -246,8 +284,8 @@ func PrintOutput(resource interface{}, columns int16, outputFlag string, resourc
 		if showLabels {
 			headers = append(headers, "labels")
 		}
-		PrintTable(headers, data)
-		return false
+		FprintTable(w, headers, data)
+		return nil
 	}
 	if outputFlag == "wide" {
 		if allNamespacesFlag == true {
@@ -258,62 +296,54 @@ func PrintOutput(resource interface{}, columns int16, outputFlag string, resourc
 		if showLabels {
 			headers = append(headers, "labels")
 		}
-		PrintTable(headers, data)
-		return false
+		FprintTable(w, headers, data)
+		return nil
 	}
 
 	// TODO: de-slice single-item slice into element
 
 	if outputFlag == "yaml" {
 		y, _ := yaml.Marshal(resource)
-		fmt.Println(string(y))
+		fmt.Fprintln(w, string(y))
 	}
 	if outputFlag == "json" {
 		j, _ := json.MarshalIndent(resource, "", "  ")
-		fmt.Println(string(j))
+		fmt.Fprintln(w, string(j))
 	}
 	if strings.HasPrefix(outputFlag, "jsonpath=") {
-		ExecuteJsonPath(resource, jsonPathTemplate)
+		return ExecuteJsonPath(w, resource, jsonPathTemplate)
 	}
-	return false
+	return nil
 }
 
-func Cat(filePath string) {
+func Cat(filePath string) error {
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		fmt.Fprintln(os.Stderr, "error: could not find file "+filePath)
-		os.Exit(1)
+		return fmt.Errorf("error: could not find file %s", filePath)
 	}
 	file, err := os.Open(filePath)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "error: could not open file "+filePath)
-		os.Exit(1)
+		return fmt.Errorf("error: could not open file %s", filePath)
 	}
-	defer func() {
-		if err = file.Close(); err != nil {
-			fmt.Fprintln(os.Stderr, "error: could not close file "+filePath)
-			os.Exit(1)
-		}
-	}()
+	defer file.Close()
 
 	fileBytes, err := io.ReadAll(file)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "error: could not read file "+filePath)
-		os.Exit(1)
+		return fmt.Errorf("error: could not read file %s", filePath)
 	}
 	fmt.Print(string(fileBytes[:]))
+	return nil
 }
 
-func GetJsonTemplate(outputStringVar string) string {
+func GetJsonTemplate(outputStringVar string) (string, error) {
 	jsonPathTemplate := ""
 	if strings.HasPrefix(outputStringVar, "jsonpath=") {
 		s := outputStringVar[9:]
 		if len(s) < 1 {
-			fmt.Fprintln(os.Stderr, "error: template format specified but no template given")
-			os.Exit(1)
+			return "", fmt.Errorf("error: template format specified but no template given")
 		}
 		jsonPathTemplate = s
 	}
-	return jsonPathTemplate
+	return jsonPathTemplate, nil
 }
 
 func StringInSlice(a string, list []string) bool {
@@ -325,93 +355,60 @@ func StringInSlice(a string, list []string) bool {
 	return false
 }
 
-func MatchLabels(labels string, selector string) bool {
-	isMatching := true
-	if selector == "" {
-		return isMatching
-	}
-	selectorArray := strings.Split(selector, ",")
-	labelsArray := strings.Split(labels, ",")
-
-	for _, s := range selectorArray {
-		if !strings.Contains(s, "!=") && !strings.Contains(s, "=") && !strings.Contains(s, "==") {
-			s = "app=" + s
-		}
-		if strings.Contains(s, "!=") {
-			if StringInSlice(strings.ReplaceAll(s, "!=", "="), labelsArray) {
-				isMatching = false
-				break
-			}
-		} else if strings.Contains(s, "==") {
-			if !StringInSlice(strings.ReplaceAll(s, "==", "="), labelsArray) {
-				isMatching = false
-				break
-			}
-		} else if strings.Contains(s, "=") {
-			if !StringInSlice(s, labelsArray) {
-				isMatching = false
-				break
-			}
-		}
+// MatchLabels tells whether the labels, like key1=value1,key2=value2, match the
+// selector.
+func MatchLabels(labelsString string, selector string) bool {
+	labelsMap, err := labels.ConvertSelectorToLabelsMap(labelsString)
+	if err != nil {
+		return false
 	}
+	isMatching, _ := MatchLabelsFromMap(labelsMap, selector)
 	return isMatching
 }
 
-func MatchLabelsFromMap(labels map[string]string, selector string) (bool, error) {
-	if selector == "" {
-		return true, nil
+// MatchFieldsFromObject tells whether the object matches the field selector,
+// like status.phase!=Running,spec.nodeName=worker-0, the empty selector matches
+// every object.
+func MatchFieldsFromObject(object map[string]interface{}, selector string) (bool, error) {
+	fieldSelector, err := fields.ParseSelector(selector)
+	if err != nil {
+		return false, err
 	}
-	selectorArray := strings.Split(selector, ",")
+	return MatchFields(object, fieldSelector), nil
+}
 
-	for _, s := range selectorArray {
-		if strings.Contains(s, "!=") {
-			split := strings.Split(s, "!=")
-			if len(split) != 2 {
-				return false, fmt.Errorf("invalid labels input")
-			}
-			key := split[0]
-			val := split[1]
-			value, _ := labels[key]
-			if val == value {
-				return false, nil
-			}
-		} else if strings.Contains(s, "==") {
-			split := strings.Split(s, "==")
-			if len(split) != 2 {
-				return false, fmt.Errorf("invalid labels input")
-			}
-			key := split[0]
-			val := split[1]
-			value, isPresent := labels[key]
-			if val != value || !isPresent {
-				return false, nil
-			}
-		} else if strings.Contains(s, "=") {
-			split := strings.Split(s, "=")
-			if len(split) != 2 {
-				return false, fmt.Errorf("invalid labels input")
-			}
-			key := split[0]
-			val := split[1]
-			value, isPresent := labels[key]
-			if val != value || !isPresent {
-				return false, nil
-			}
-		} else if !strings.Contains(s, "!=") && !strings.Contains(s, "=") && !strings.Contains(s, "==") {
-			s = "app=" + s
-			split := strings.Split(s, "=")
-			if len(split) != 2 {
-				return false, fmt.Errorf("invalid labels input")
+// MatchFields tells whether the fields of the object, given by their JSON path
+// like involvedObject.name, match the selector. The missing fields are empty.
+func MatchFields(object map[string]interface{}, selector fields.Selector) bool {
+	for _, requirement := range selector.Requirements() {
+		value, _, _ := unstructured.NestedFieldNoCopy(object, strings.Split(requirement.Field, ".")...)
+		actual := ""
+		if value != nil {
+			actual = fmt.Sprint(value)
+		}
+		switch requirement.Operator {
+		case selection.Equals, selection.DoubleEquals:
+			if actual != requirement.Value {
+				return false
 			}
-			key := split[0]
-			val := split[1]
-			value, _ := labels[key]
-			if val != value {
-				return false, nil
+		case selection.NotEquals:
+			if actual == requirement.Value {
+				return false
 			}
 		}
 	}
-	return true, nil
+	return true
+}
+
+// MatchLabelsFromMap tells whether the labels match the selector, given in any
+// form kubectl accepts like app in (a,b),!canary,tier notin (x). The empty
+// selector matches every object.
+func MatchLabelsFromMap(labelsMap map[string]string, selector string) (bool, error) {
+	labelSelector, err := labels.Parse(selector)
+	if err != nil {
+		return false, err
+	}
+	return labelSelector.Matches(labels.Set(labelsMap)), nil
 }
 
 func TranslateTimestamp(timestamp metav1.Time) string {
@@ -441,16 +438,15 @@ func ShortHumanDuration(d time.Duration) string {
 	return fmt.Sprintf("%dy", int(d.Hours()/24/365))
 }
 
-func GetFromJsonPath(data interface{}, jsonPathTemplate string) string {
+func GetFromJsonPath(data interface{}, jsonPathTemplate string) (string, error) {
 	buf := new(bytes.Buffer)
 	jPath := jsonpath.New("out")
 	jPath.AllowMissingKeys(false)
 	jPath.EnableJSONOutput(false)
 	err := jPath.Parse(jsonPathTemplate)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "error: error parsing jsonpath "+jsonPathTemplate+", "+err.Error())
-		os.Exit(1)
+		return "", fmt.Errorf("error: error parsing jsonpath %s, %s", jsonPathTemplate, err.Error())
 	}
 	jPath.Execute(buf, data)
-	return buf.String()
+	return buf.String(), nil
 }
@@ -1,7 +1,19 @@
 package insights
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
 	in2un "github.com/bverschueren/in2un/cmd"
+	"github.com/bverschueren/in2un/pkg/reader"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes/scheme"
 )
 
 var InsightsCmd = in2un.InsightsCmd
@@ -9,4 +21,102 @@ var InsightsCmd = in2un.InsightsCmd
 func init() {
 	InsightsCmd.Use = "insights"
 	in2un.ConfigDir = "$HOME/.omc/"
+	// in2un prints to the process stdout and exits on errors, its get and logs
+	// commands are replaced so that they write to the command output and
+	// return their errors.
+	for _, c := range InsightsCmd.Commands() {
+		switch c.Name() {
+		case "get":
+			c.PreRun, c.Run, c.RunE = nil, nil, recoverFatal(getInsights)
+		case "logs":
+			c.Run, c.RunE = nil, recoverFatal(logsInsights)
+		}
+	}
+}
+
+func getInsights(cmd *cobra.Command, args []string) error {
+	ir, err := openInsights()
+	if err != nil {
+		return err
+	}
+	namespace := in2un.Namespace
+	if in2un.AllNamespaces {
+		namespace = reader.AllNamespaceValue
+	}
+	resourceGroup, resourceName := resourceArgs(args)
+	found := ir.ReadResource(resourceGroup, resourceName, namespace, in2un.OverrideApiVersion, in2un.OverrideKind)
+	return printInsights(cmd.OutOrStdout(), in2un.Output, found)
+}
+
+func logsInsights(cmd *cobra.Command, args []string) error {
+	ir, err := openInsights()
+	if err != nil {
+		return err
+	}
+	containerName, _ := cmd.Flags().GetString("container")
+	previous, _ := cmd.Flags().GetBool("previous")
+	_, err = io.Copy(cmd.OutOrStdout(), ir.ReadLog("pod", args[0], in2un.Namespace, containerName, previous))
+	return err
+}
+
+// openInsights opens the archive given with --insights-file, or the one
+// selected with "omc insights use".
+func openInsights() (*reader.InsightsReader, error) {
+	path := in2un.Active
+	if path == "" {
+		path = viper.GetString("active")
+	}
+	if path == "" {
+		return nil, errors.New("no insights archive selected, run \"omc insights use <archive>\" or set --insights-file")
+	}
+	return reader.NewInsightsReader(path)
+}
+
+// resourceArgs splits the arguments like in2un, either "<type>/<name>" or
+// "<type> [<name>]".
+func resourceArgs(args []string) (string, string) {
+	resourceGroup, resourceName, found := strings.Cut(args[0], "/")
+	if !found && len(args) > 1 {
+		resourceName = args[1]
+	}
+	return in2un.Unalias(resourceGroup), resourceName
+}
+
+func printInsights(w io.Writer, format string, obj *unstructured.UnstructuredList) error {
+	var printr printers.ResourcePrinter
+	switch format {
+	case "yaml":
+		printr = &printers.YAMLPrinter{}
+	case "json":
+		printr = &printers.JSONPrinter{}
+	case "name":
+		printr = &printers.NamePrinter{}
+	default:
+		printr = printers.NewTablePrinter(printers.PrintOptions{})
+	}
+	return printers.NewTypeSetter(scheme.Scheme).ToPrinter(printr).PrintObj(obj, w)
+}
+
+// fatal is raised in place of the exit of the logger.
+type fatal struct{ code int }
+
+// recoverFatal wraps run so that the fatal errors logged by the insights
+// reader, which would exit the process, are returned as errors.
+func recoverFatal(run func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) (err error) {
+		logger := log.StandardLogger()
+		exit := logger.ExitFunc
+		logger.ExitFunc = func(code int) { panic(fatal{code}) }
+		defer func() {
+			logger.ExitFunc = exit
+			if r := recover(); r != nil {
+				f, ok := r.(fatal)
+				if !ok {
+					panic(r)
+				}
+				err = fmt.Errorf("unable to read the insights archive (exit code %d)", f.code)
+			}
+		}()
+		return run(cmd, args)
+	}
 }
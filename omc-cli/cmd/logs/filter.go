@@ -19,3 +19,35 @@ package logs
 type logLineFilter interface {
 	filterLogLine([]byte) ([]byte, error)
 }
+
+// logLineFilters applies filters in turn, a line filtered out by one of them
+// isn't passed to the next ones.
+type logLineFilters []logLineFilter
+
+// newLogLineFilters chains the filters which aren't nil, it returns nil when
+// there is none.
+func newLogLineFilters(filters ...logLineFilter) logLineFilter {
+	var chain logLineFilters
+	for _, filter := range filters {
+		if filter != nil {
+			chain = append(chain, filter)
+		}
+	}
+	switch len(chain) {
+	case 0:
+		return nil
+	case 1:
+		return chain[0]
+	}
+	return chain
+}
+
+func (filters logLineFilters) filterLogLine(log []byte) ([]byte, error) {
+	for _, filter := range filters {
+		var err error
+		if log, err = filter.filterLogLine(log); err != nil || len(log) == 0 {
+			return log, err
+		}
+	}
+	return log, nil
+}
@@ -0,0 +1,358 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/gmeghnag/omc/vars"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	grepIgnoreCase bool
+	grepContext    int
+	grepMaxMatches int
+)
+
+// GrepResult holds the log lines matching a regular expression, in the order
+// of their namespace, pod, container, file and line number.
+type GrepResult struct {
+	Matches []GrepMatch `json:"matches"`
+	// Truncated is set when more lines than the maximum number of matches
+	// were found.
+	Truncated bool `json:"truncated"`
+}
+
+// GrepMatch is a matching log line and the lines around it.
+type GrepMatch struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	// File is the log file relative to the log directory of the container,
+	// like current.log or rotated/0.log.20231102-061208.gz.
+	File   string   `json:"file"`
+	Line   int      `json:"line"`
+	Text   string   `json:"text"`
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+// logDirectory is the log directory of a container found in the must-gather.
+type logDirectory struct {
+	namespace, pod, container, path string
+}
+
+// containerLogDirectories returns the log directories of the containers of the
+// pods in namespaces/<namespace>/pods/<pod>/<container>/<container>/logs, of
+// the pod and the container when they are set.
+func containerLogDirectories(root string, namespaces []string, pod string, container string) []logDirectory {
+	var directories []logDirectory
+	for _, namespace := range namespaces {
+		podsPath := filepath.Join(root, "namespaces", namespace, "pods")
+		pods, _ := os.ReadDir(podsPath)
+		for _, p := range pods {
+			if !p.IsDir() || (pod != "" && p.Name() != pod) {
+				continue
+			}
+			containers, _ := os.ReadDir(filepath.Join(podsPath, p.Name()))
+			for _, c := range containers {
+				if !c.IsDir() || (container != "" && c.Name() != container) {
+					continue
+				}
+				path := filepath.Join(podsPath, p.Name(), c.Name(), c.Name(), "logs")
+				if info, err := os.Stat(path); err == nil && info.IsDir() {
+					directories = append(directories, logDirectory{namespace, p.Name(), c.Name(), path})
+				}
+			}
+		}
+	}
+	return directories
+}
+
+// containerLogReaders returns the readers of the current, previous and rotated
+// logs of the directories, or only of the previous or rotated ones when their
+// flag is set, along with the directory each of them reads.
+func containerLogReaders(directories []logDirectory, previousFlag bool, rotatedFlag bool, insecureFlag bool) ([]*LogReader, []logDirectory) {
+	var readers []*LogReader
+	var owners []logDirectory
+	for _, directory := range directories {
+		var selected []*LogReader
+		if !previousFlag && !rotatedFlag {
+			current, previous, rotated := NewLogReader(directory.path), NewLogReader(directory.path), NewLogReader(directory.path)
+			previous.FromPrevious()
+			rotated.FromRotated()
+			selected = append(selected, current, previous, rotated)
+		} else {
+			if previousFlag {
+				previous := NewLogReader(directory.path)
+				previous.FromPrevious()
+				selected = append(selected, previous)
+			}
+			if rotatedFlag {
+				rotated := NewLogReader(directory.path)
+				rotated.FromRotated()
+				selected = append(selected, rotated)
+			}
+		}
+		for _, reader := range selected {
+			if insecureFlag {
+				reader.FromInsecure()
+			}
+			readers = append(readers, reader)
+			owners = append(owners, directory)
+		}
+	}
+	return readers, owners
+}
+
+// selectedNamespaces returns the namespace selected with -n, or all the
+// namespaces of the must-gather with -A.
+func selectedNamespaces() ([]string, error) {
+	if !vars.AllNamespaceBoolVar {
+		if vars.Namespace == "" {
+			return nil, fmt.Errorf("error: a namespace must be selected, use -n or -A")
+		}
+		return []string{vars.Namespace}, nil
+	}
+	entries, err := os.ReadDir(vars.MustGatherRootPath + "/namespaces")
+	if err != nil {
+		return nil, err
+	}
+	var namespaces []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			namespaces = append(namespaces, entry.Name())
+		}
+	}
+	return namespaces, nil
+}
+
+// grepLogs searches the current, previous and rotated logs of the containers
+// in the namespaces for the lines matching pattern, with contextLines lines
+// around them. The log files are read by a bounded pool of workers, and at
+// most maxMatches lines are returned when it is positive.
+func grepLogs(ctx context.Context, root string, namespaces []string, container string, pattern *regexp.Regexp, contextLines int, maxMatches int, previousFlag bool, rotatedFlag bool, insecureFlag bool) (*GrepResult, error) {
+	readers, owners := containerLogReaders(containerLogDirectories(root, namespaces, "", container), previousFlag, rotatedFlag, insecureFlag)
+	for _, reader := range readers {
+		reader.WithContext(ctx)
+	}
+
+	type job struct {
+		index  int
+		reader *LogReader
+	}
+	jobs := make(chan job)
+	matches := make([][]GrepMatch, len(readers))
+	errs := make([]error, len(readers))
+	var wg sync.WaitGroup
+	for i := 0; i < min(runtime.NumCPU(), len(readers)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				directory := owners[j.index]
+				errs[j.index] = j.reader.Walk(func(filename string, reader io.Reader) error {
+					found, err := grepReader(reader, pattern, contextLines, maxMatches)
+					for i := range found {
+						found[i].Namespace, found[i].Pod, found[i].Container, found[i].File = directory.namespace, directory.pod, directory.container, filename
+					}
+					matches[j.index] = append(matches[j.index], found...)
+					return err
+				})
+			}
+		}()
+	}
+	for i, reader := range readers {
+		jobs <- job{i, reader}
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := &GrepResult{Matches: []GrepMatch{}}
+	for i := range readers {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		result.Matches = append(result.Matches, matches[i]...)
+	}
+	sort.SliceStable(result.Matches, func(i, j int) bool {
+		a, b := result.Matches[i], result.Matches[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Pod != b.Pod {
+			return a.Pod < b.Pod
+		}
+		if a.Container != b.Container {
+			return a.Container < b.Container
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	if maxMatches > 0 && len(result.Matches) > maxMatches {
+		result.Matches = result.Matches[:maxMatches]
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+// grepReader returns the lines of reader matching pattern with contextLines
+// lines around them, it stops after the first line past maxMatches matches
+// when it is positive, to let the caller know there are more.
+func grepReader(reader io.Reader, pattern *regexp.Regexp, contextLines int, maxMatches int) ([]GrepMatch, error) {
+	var matches []GrepMatch
+	var before []string
+	// pending are the matches still waiting for lines after them
+	var pending []int
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		for _, i := range pending {
+			matches[i].After = append(matches[i].After, text)
+		}
+		for len(pending) > 0 && len(matches[pending[0]].After) == contextLines {
+			pending = pending[1:]
+		}
+		if pattern.MatchString(text) {
+			if maxMatches > 0 && len(matches) > maxMatches {
+				break
+			}
+			matches = append(matches, GrepMatch{Line: line, Text: text, Before: append([]string(nil), before...)})
+			if contextLines > 0 {
+				pending = append(pending, len(matches)-1)
+			}
+		}
+		if contextLines > 0 {
+			before = append(before, text)
+			if len(before) > contextLines {
+				before = before[1:]
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return matches, fmt.Errorf("failed to read log file: %v", err)
+	}
+	return matches, nil
+}
+
+// printGrepResult prints the matches like grep does with a file name prefix:
+// "<namespace>/<pod>/<container>/<file>:<line>:<text>" for the matching lines
+// and with dashes for the context lines, the groups of lines apart separated
+// by "--".
+func printGrepResult(w io.Writer, result *GrepResult, output string) error {
+	switch output {
+	case "json":
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", data)
+		return nil
+	case "yaml":
+		data, err := yaml.Marshal(result)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s", data)
+		return nil
+	case "":
+	default:
+		return fmt.Errorf("unsupported output format %q, expected one of: json|yaml", output)
+	}
+	lastFile, lastLine := "", 0
+	for start := 0; start < len(result.Matches); {
+		// the matches of a file are printed together, their context lines
+		// can overlap
+		match := result.Matches[start]
+		file := match.Namespace + "/" + match.Pod + "/" + match.Container + "/" + match.File
+		end := start
+		lines := map[int]string{}
+		matching := map[int]bool{}
+		for ; end < len(result.Matches) && result.Matches[end].Namespace == match.Namespace && result.Matches[end].Pod == match.Pod && result.Matches[end].Container == match.Container && result.Matches[end].File == match.File; end++ {
+			m := result.Matches[end]
+			for i, text := range m.Before {
+				lines[m.Line-len(m.Before)+i] = text
+			}
+			for i, text := range m.After {
+				lines[m.Line+i+1] = text
+			}
+			lines[m.Line] = m.Text
+			matching[m.Line] = true
+		}
+		numbers := make([]int, 0, len(lines))
+		for line := range lines {
+			numbers = append(numbers, line)
+		}
+		sort.Ints(numbers)
+		for _, line := range numbers {
+			if lastFile != "" && (file != lastFile || line > lastLine+1) && len(lines) > len(matching) {
+				fmt.Fprintln(w, "--")
+			}
+			separator := "-"
+			if matching[line] {
+				separator = ":"
+			}
+			fmt.Fprintf(w, "%s%s%d%s%s\n", file, separator, line, separator, lines[line])
+			lastFile, lastLine = file, line
+		}
+		start = end
+	}
+	if result.Truncated {
+		fmt.Fprintf(w, "\nOnly the first %d matches are shown.\n", len(result.Matches))
+	}
+	return nil
+}
+
+var Grep = &cobra.Command{
+	Use:   "grep <regex>",
+	Short: "Search the current, previous and rotated logs of every container for the lines matching a regular expression",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("error: expected 'logs grep <regex>', found %d arguments", len(args))
+		}
+		expression := args[0]
+		if grepIgnoreCase {
+			expression = "(?i)" + expression
+		}
+		pattern, err := regexp.Compile(expression)
+		if err != nil {
+			return fmt.Errorf("invalid regular expression %q: %w", args[0], err)
+		}
+		if grepContext < 0 {
+			return fmt.Errorf("invalid --context %d, expected a positive number of lines", grepContext)
+		}
+		if err := resolveNamespacesRoot(); err != nil {
+			return err
+		}
+		namespaces, err := selectedNamespaces()
+		if err != nil {
+			return err
+		}
+		result, err := grepLogs(cmd.Context(), vars.MustGatherRootPath, namespaces, vars.Container, pattern, grepContext, grepMaxMatches, vars.Previous, vars.Rotated, vars.InsecureLogs)
+		if err != nil {
+			return err
+		}
+		return printGrepResult(cmd.OutOrStdout(), result, vars.OutputStringVar)
+	},
+}
+
+func init() {
+	Grep.Flags().BoolVarP(&vars.AllNamespaceBoolVar, "all-namespaces", "A", false, "If present, search the logs of the pods across all namespaces.")
+	Grep.Flags().BoolVarP(&grepIgnoreCase, "ignore-case", "i", false, "Ignore the case of the regular expression.")
+	Grep.Flags().IntVarP(&grepContext, "context", "C", 0, "Print this number of lines before and after the matching lines.")
+	Grep.Flags().IntVar(&grepMaxMatches, "max-matches", 0, "Stop after this number of matching lines, all of them when 0.")
+	Grep.Flags().StringVarP(&vars.OutputStringVar, "output", "o", "", "Output format. One of: json|yaml")
+}
@@ -0,0 +1,105 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGrepLogs(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    string
+		context    int
+		maxMatches int
+		rotated    bool
+		expected   *GrepResult
+	}{
+		{
+			name:    "Search the current, previous and rotated logs",
+			pattern: "My (Error|Rotated)",
+			expected: &GrepResult{Matches: []GrepMatch{
+				{Namespace: "test-namespace", Pod: "test-pod", Container: "test-container", File: "current.log", Line: 3, Text: "2023-11-02T06:12:08.604741676Z E1106 06:12:08.604741       1 test_app_go:542] My Error LogMessage"},
+				{Namespace: "test-namespace", Pod: "test-pod", Container: "test-container", File: "rotated/1.log.20231102-061208", Line: 1, Text: "2023-11-02T06:12:07.604163885+00:00 stderr F I1102 06:12:07.604158       1 test_app.go:365] My Rotated LogMsg"},
+				{Namespace: "test-namespace", Pod: "test-pod", Container: "test-container", File: "rotated/1.log.20231102-061208.gz", Line: 1, Text: "2023-11-02T06:12:07.604163885+00:00 stderr F I1102 06:12:07.604158       1 test_app.go:365] My Rotated LogMsg"},
+			}},
+		},
+		{
+			name:    "Search the rotated logs only",
+			pattern: "My",
+			rotated: true,
+			expected: &GrepResult{Matches: []GrepMatch{
+				{Namespace: "test-namespace", Pod: "test-pod", Container: "test-container", File: "rotated/1.log.20231102-061208", Line: 1, Text: "2023-11-02T06:12:07.604163885+00:00 stderr F I1102 06:12:07.604158       1 test_app.go:365] My Rotated LogMsg"},
+				{Namespace: "test-namespace", Pod: "test-pod", Container: "test-container", File: "rotated/1.log.20231102-061208.gz", Line: 1, Text: "2023-11-02T06:12:07.604163885+00:00 stderr F I1102 06:12:07.604158       1 test_app.go:365] My Rotated LogMsg"},
+			}},
+		},
+		{
+			name:    "Context lines",
+			pattern: "My Warning",
+			context: 1,
+			expected: &GrepResult{Matches: []GrepMatch{
+				{
+					Namespace: "test-namespace", Pod: "test-pod", Container: "test-container", File: "current.log", Line: 2,
+					Text:   "2023-11-02T06:12:08.604740676Z W1103 06:12:08.604740       1 test_app_go:542] My Warning LogMessage",
+					Before: []string{"2023-11-02T06:12:08.604390676Z I1102 06:12:08.604739       1 test_app.go:242] My Info LogMessage"},
+					After:  []string{"2023-11-02T06:12:08.604741676Z E1106 06:12:08.604741       1 test_app_go:542] My Error LogMessage"},
+				},
+			}},
+		},
+		{
+			name:       "Maximum number of matches",
+			pattern:    "LogMessage",
+			maxMatches: 2,
+			expected: &GrepResult{Matches: []GrepMatch{
+				{Namespace: "test-namespace", Pod: "test-pod", Container: "test-container", File: "current.log", Line: 1, Text: "2023-11-02T06:12:08.604390676Z I1102 06:12:08.604739       1 test_app.go:242] My Info LogMessage"},
+				{Namespace: "test-namespace", Pod: "test-pod", Container: "test-container", File: "current.log", Line: 2, Text: "2023-11-02T06:12:08.604740676Z W1103 06:12:08.604740       1 test_app_go:542] My Warning LogMessage"},
+			}, Truncated: true},
+		},
+		{
+			name:     "No match",
+			pattern:  "panic",
+			expected: &GrepResult{Matches: []GrepMatch{}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := grepLogs(context.Background(), strings.TrimSuffix(testdata, "/"), []string{"test-namespace", "missing-namespace"}, "", regexp.MustCompile(tc.pattern), tc.context, tc.maxMatches, false, tc.rotated, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Fatalf("Expected : %+v, got: %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPrintGrepResult(t *testing.T) {
+	result := &GrepResult{Matches: []GrepMatch{
+		{Namespace: "ns", Pod: "pod", Container: "c", File: "current.log", Line: 2, Text: "error 1", Before: []string{"line 1"}, After: []string{"error 3"}},
+		{Namespace: "ns", Pod: "pod", Container: "c", File: "current.log", Line: 3, Text: "error 3", Before: []string{"error 1"}, After: []string{"line 4"}},
+		{Namespace: "ns", Pod: "pod", Container: "c", File: "current.log", Line: 9, Text: "error 9", Before: []string{"line 8"}},
+	}, Truncated: true}
+	output := new(bytes.Buffer)
+	if err := printGrepResult(output, result, ""); err != nil {
+		t.Fatal(err)
+	}
+	expected := `ns/pod/c/current.log-1-line 1
+ns/pod/c/current.log:2:error 1
+ns/pod/c/current.log:3:error 3
+ns/pod/c/current.log-4-line 4
+--
+ns/pod/c/current.log-8-line 8
+ns/pod/c/current.log:9:error 9
+
+Only the first 3 matches are shown.
+`
+	if output.String() != expected {
+		t.Errorf("Got: %v \n", output.String())
+		t.Errorf("Want: %v \n", expected)
+	}
+}
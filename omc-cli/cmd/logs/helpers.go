@@ -65,7 +65,7 @@ func parseCRILog(log []byte, infoLevel bool, warningLevel bool, errorLevel bool)
 	return "", nil
 }
 
-func FilterCatLogs(filePath string, logLevels []string) {
+func FilterCatLogs(filePath string, logLevels []string) error {
 	var infoLevel, warningLevel, errorLevel bool
 	for _, i := range logLevels {
 		if i == "info" {
@@ -79,13 +79,11 @@ func FilterCatLogs(filePath string, logLevels []string) {
 		}
 	}
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		fmt.Fprintln(os.Stderr, "error: file "+filePath+" does not exist")
-		os.Exit(1)
+		return fmt.Errorf("error: file %s does not exist", filePath)
 	}
 	file, err := os.Open(filePath)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "error: can't open file "+filePath)
-		os.Exit(1)
+		return fmt.Errorf("error: can't open file %s", filePath)
 	}
 	defer file.Close()
 
@@ -94,14 +92,14 @@ func FilterCatLogs(filePath string, logLevels []string) {
 	for scanner.Scan() {
 		log, err := parseCRILog(scanner.Bytes(), infoLevel, warningLevel, errorLevel)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+			return err
 		}
 		if log != "" {
 			fmt.Println(log)
 		}
 
 	}
+	return scanner.Err()
 }
 
 func isNumber(char byte) bool {
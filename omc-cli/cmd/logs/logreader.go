@@ -18,6 +18,7 @@ package logs
 import (
 	"bufio"
 	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
 	"io/fs"
@@ -48,6 +49,7 @@ type LogReader struct {
 	dirname string
 	files   *[]string
 	filter  logLineFilter
+	ctx     context.Context
 }
 
 // Create a LogReader which holds a reader to either a plain (bufio.Reader) or gzipped (gzip.Reader) logfile.
@@ -63,6 +65,12 @@ func (l *LogReader) WithFilter(llf logLineFilter) {
 	l.filter = llf
 }
 
+// WithContext stops the reading of the logs with the error of ctx once it is
+// done.
+func (l *LogReader) WithContext(ctx context.Context) {
+	l.ctx = ctx
+}
+
 func (l *LogReader) FromPrevious() {
 	l.files = &[]string{previousLogFile}
 }
@@ -86,24 +94,12 @@ func (l *LogReader) FromRotated() {
 // Print the current reader (filtered) to a provided writer.
 // If unfilter, write to provided writer (w).
 // If filtered read from reader line-by-line and apply the filter.
-func (l *LogReader) Read(w io.Writer) {
-	for _, filename := range *l.files {
-		reader, err := open(l.dirname + "/" + filename)
-		if err != nil {
-			if !os.IsNotExist(err) {
-				// since we're scanning through logs dynamically don't error out if log files do not exist
-				fmt.Errorf("failed to open log file: %v", err)
-			}
-			continue
-		}
-		defer reader.Close()
-		if err != nil {
-			fmt.Println(err)
-		}
+func (l *LogReader) Read(w io.Writer) error {
+	return l.Walk(func(filename string, reader io.Reader) error {
 		if l.filter == nil {
 			// without filter, copy entire content to the provided writer
 			if _, err := io.Copy(w, reader); err != nil {
-				log.Fatalf("fatal: %v", err)
+				return fmt.Errorf("fatal: %v", err)
 			}
 		} else {
 			// with filter, read line by line and apply the filter
@@ -115,7 +111,51 @@ func (l *LogReader) Read(w io.Writer) {
 				}
 			}
 		}
+		return nil
+	})
+}
+
+// Walk calls fn with the name, relative to the log directory, and the reader
+// of every selected log file which exists, gzipped ones being decompressed.
+func (l *LogReader) Walk(fn func(filename string, reader io.Reader) error) error {
+	for _, filename := range *l.files {
+		if l.ctx != nil && l.ctx.Err() != nil {
+			return l.ctx.Err()
+		}
+		reader, file, err := openFile(l.dirname + "/" + filename)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				// since we're scanning through logs dynamically don't error out if log files do not exist
+				return fmt.Errorf("failed to open log file: %v", err)
+			}
+			continue
+		}
+		if l.ctx != nil {
+			err = fn(filename, &contextReader{ctx: l.ctx, reader: reader})
+		} else {
+			err = fn(filename, reader)
+		}
+		reader.Close()
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contextReader fails with the error of ctx once it is done, so that a log
+// is no longer read after its command was cancelled.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
 	}
+	return r.reader.Read(p)
 }
 
 func (l *LogReader) applyFilter(raw []byte) []byte {
@@ -131,9 +171,16 @@ func (l *LogReader) applyFilter(raw []byte) []byte {
 
 // open the logfile and return either a *os.File or *gzip.Reader
 func open(filename string) (io.ReadCloser, error) {
+	reader, _, err := openFile(filename)
+	return reader, err
+}
+
+// openFile opens the logfile like open, and returns the file too since closing
+// a *gzip.Reader doesn't close it.
+func openFile(filename string) (io.ReadCloser, *os.File, error) {
 	file, err := os.Open(filename)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	var reader io.ReadCloser
 	reader, err = gzip.NewReader(file)
@@ -142,8 +189,7 @@ func open(filename string) (io.ReadCloser, error) {
 		file.Seek(0, io.SeekStart)
 		reader = file
 	}
-	//	defer file.Close()
-	return reader, nil
+	return reader, file, nil
 }
 
 // read rotated dir and return relative filenames for plain and gzipped logfiles
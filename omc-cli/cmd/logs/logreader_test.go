@@ -18,6 +18,8 @@ package logs
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
+	"errors"
 	"io"
 	"os"
 	"reflect"
@@ -69,7 +71,7 @@ func TestFromInsecure(t *testing.T) {
 		},
 		{
 			name:      "Handle insecure logs but only touch files suffixed .log",
-			logReader: &LogReader{"", &[]string{"current.log", "current.fakelog"}, nil},
+			logReader: &LogReader{"", &[]string{"current.log", "current.fakelog"}, nil, nil},
 			expected:  &[]string{"current.insecure.log"},
 		},
 	}
@@ -136,13 +138,13 @@ func TestRead(t *testing.T) {
 	}{
 		{
 			name:                    "Read file unfiltered",
-			logReader:               &LogReader{testdata + "namespaces/test-namespace/pods/test-pod/test-container/test-container/logs/", &[]string{"current.log"}, nil},
+			logReader:               &LogReader{testdata + "namespaces/test-namespace/pods/test-pod/test-container/test-container/logs/", &[]string{"current.log"}, nil, nil},
 			expectedText:            "My Info LogMessage",
 			expectedFilteredOutText: "",
 		},
 		{
 			name:                    "Read file and apply filter to every line.",
-			logReader:               &LogReader{testdata + "namespaces/test-namespace/pods/test-pod/test-container/test-container/logs/", &[]string{"current.log"}, &SimpleToUpperLogFilter{}},
+			logReader:               &LogReader{testdata + "namespaces/test-namespace/pods/test-pod/test-container/test-container/logs/", &[]string{"current.log"}, &SimpleToUpperLogFilter{}, nil},
 			expectedText:            "LOGMESSAGE",
 			expectedFilteredOutText: "LogMessage",
 		},
@@ -165,6 +167,20 @@ func TestRead(t *testing.T) {
 	}
 }
 
+func TestReadCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	logReader := NewLogReader(testdata + "namespaces/test-namespace/pods/test-pod/test-container/test-container/logs/")
+	logReader.WithContext(ctx)
+	output := new(bytes.Buffer)
+	if err := logReader.Read(output); !errors.Is(err, context.Canceled) {
+		t.Errorf("Got error %v, want %v", err, context.Canceled)
+	}
+	if output.Len() != 0 {
+		t.Errorf("Got output %q after the context was cancelled", output.String())
+	}
+}
+
 // dummy implementation of the logLineFilter interface for testing purposes
 type SimpleToUpperLogFilter struct{}
 
@@ -18,8 +18,6 @@ package logs
 import (
 	"fmt"
 	"io/ioutil"
-	"log"
-	"os"
 	"strings"
 
 	"github.com/gmeghnag/omc/cmd/helpers"
@@ -28,35 +26,42 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var LogLevel string
+var LogLevel, SinceTime, UntilTime, Since string
+
+// resolveNamespacesRoot points vars.MustGatherRootPath to the directory of the
+// namespaces, the image directory of the must-gather when it isn't set to it.
+func resolveNamespacesRoot() error {
+	if vars.MustGatherRootPath == "" {
+		return fmt.Errorf("There are no must-gather resources defined.")
+	}
+	exist, _ := helpers.Exists(vars.MustGatherRootPath + "/namespaces")
+	if !exist {
+		files, err := ioutil.ReadDir(vars.MustGatherRootPath)
+		if err != nil {
+			return err
+		}
+		var QuayString string
+		for _, f := range files {
+			if strings.HasPrefix(f.Name(), "quay") {
+				QuayString = f.Name()
+				vars.MustGatherRootPath = vars.MustGatherRootPath + "/" + QuayString
+				break
+			}
+		}
+		if QuayString == "" {
+			return fmt.Errorf("Some error occurred, wrong must-gather file composition")
+		}
+	}
+	return nil
+}
 
 // logsCmd represents the logs command
 var Logs = &cobra.Command{
 	Use:   "logs",
 	Short: "Print the logs for a container in a pod",
-	Run: func(cmd *cobra.Command, args []string) {
-		if vars.MustGatherRootPath == "" {
-			fmt.Fprintln(os.Stderr, "There are no must-gather resources defined.")
-			os.Exit(1)
-		}
-		exist, _ := helpers.Exists(vars.MustGatherRootPath + "/namespaces")
-		if !exist {
-			files, err := ioutil.ReadDir(vars.MustGatherRootPath)
-			if err != nil {
-				log.Fatal(err)
-			}
-			var QuayString string
-			for _, f := range files {
-				if strings.HasPrefix(f.Name(), "quay") {
-					QuayString = f.Name()
-					vars.MustGatherRootPath = vars.MustGatherRootPath + "/" + QuayString
-					break
-				}
-			}
-			if QuayString == "" {
-				fmt.Fprintln(os.Stderr, "Some error occurred, wrong must-gather file composition")
-				os.Exit(1)
-			}
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := resolveNamespacesRoot(); err != nil {
+			return err
 		}
 		namespaceFlag, _ := cmd.Flags().GetString("namespace")
 		if namespaceFlag != "" {
@@ -74,57 +79,51 @@ var Logs = &cobra.Command{
 		}
 
 		if len(args) == 0 || len(args) > 2 {
-			fmt.Fprintln(os.Stderr, "error: expected 'logs [-p] (POD | TYPE/NAME) [-c CONTAINER]'.")
-			fmt.Fprintln(os.Stderr, "POD or TYPE/NAME is a required argument for the logs command")
-			fmt.Fprintln(os.Stderr, "See 'omc logs -h' for help and examples")
-			os.Exit(1)
+			return fmt.Errorf("error: expected 'logs [-p] (POD | TYPE/NAME) [-c CONTAINER]'.\n" +
+				"POD or TYPE/NAME is a required argument for the logs command\n" +
+				"See 'omc logs -h' for help and examples")
 		}
 		if len(args) == 1 {
 			if s := strings.Split(args[0], "/"); len(s) == 2 && (s[0] == "po" || s[0] == "pod" || s[0] == "pods") {
 				podName = s[1]
 				if podName == "" {
-					fmt.Fprintln(os.Stderr, "arguments in resource/name form must have a single resource and name")
-					os.Exit(1)
+					return fmt.Errorf("arguments in resource/name form must have a single resource and name")
 				}
-				logsPods(vars.MustGatherRootPath, vars.Namespace, podName, containerName, previousFlag, rotatedFlag, allContainersFlag, logLevels, insecureFlag)
 			} else {
 				podName = s[0]
-				logsPods(vars.MustGatherRootPath, vars.Namespace, podName, containerName, previousFlag, rotatedFlag, allContainersFlag, logLevels, insecureFlag)
 			}
 		}
 		if len(args) == 2 {
+			if containerName != "" {
+				return fmt.Errorf("error: only one of -c or an inline [CONTAINER] arg is allowed")
+			}
 			if s := strings.Split(args[0], "/"); len(s) == 2 && (s[0] == "po" || s[0] == "pod" || s[0] == "pods") {
-				if containerName != "" {
-					fmt.Fprintln(os.Stderr, "error: only one of -c or an inline [CONTAINER] arg is allowed")
-					os.Exit(1)
-				} else {
-					podName = s[1]
-					if podName == "" {
-						fmt.Fprintln(os.Stderr, "error: arguments in resource/name form must have a single resource and name")
-						os.Exit(1)
-					}
-					containerName = args[1]
-					logsPods(vars.MustGatherRootPath, vars.Namespace, podName, containerName, previousFlag, rotatedFlag, allContainersFlag, logLevels, insecureFlag)
+				podName = s[1]
+				if podName == "" {
+					return fmt.Errorf("error: arguments in resource/name form must have a single resource and name")
 				}
 			} else {
-				if containerName != "" {
-					fmt.Fprintln(os.Stderr, "error: only one of -c or an inline [CONTAINER] arg is allowed")
-					os.Exit(1)
-				} else {
-					podName = args[0]
-					containerName = args[1]
-					logsPods(vars.MustGatherRootPath, vars.Namespace, podName, containerName, previousFlag, rotatedFlag, allContainersFlag, logLevels, insecureFlag)
-				}
+				podName = args[0]
 			}
+			containerName = args[1]
+		}
+		window, err := NewTimeWindow(vars.MustGatherRootPath, SinceTime, UntilTime, Since)
+		if err != nil {
+			return err
 		}
+		return logsPods(cmd.Context(), cmd.OutOrStdout(), vars.MustGatherRootPath, vars.Namespace, podName, containerName, previousFlag, rotatedFlag, allContainersFlag, logLevels, insecureFlag, window)
 	},
 }
 
 func init() {
+	Logs.AddCommand(Grep, Summarize)
 	Logs.PersistentFlags().StringVarP(&vars.Container, "container", "c", "", "Print the logs of this container")
 	Logs.PersistentFlags().BoolVar(&vars.InsecureLogs, "insecure", false, "")
 	Logs.PersistentFlags().BoolVarP(&vars.Previous, "previous", "p", false, "Print the logs for the previous instance of the container in a pod if it exists.")
 	Logs.PersistentFlags().BoolVarP(&vars.Rotated, "rotated", "r", false, "Print the logs for the rotated instance of the container in a pod if it exists.")
 	Logs.PersistentFlags().BoolVarP(&vars.AllContainers, "all-containers", "", false, "Get all containers' logs in the pod(s).")
 	Logs.Flags().StringVarP(&LogLevel, "log-level", "l", "", "Filter logs by level (info|error|worning), you can filter for more concatenating them comma separated.")
+	Logs.Flags().StringVar(&SinceTime, "since-time", "", "Only return logs after a specific date (RFC3339).")
+	Logs.Flags().StringVar(&UntilTime, "until-time", "", "Only return logs before a specific date (RFC3339).")
+	Logs.Flags().StringVar(&Since, "since", "", "Only return logs newer than a relative duration like 5s, 2m, or 3h, counted back from the time the must-gather was collected.")
 }
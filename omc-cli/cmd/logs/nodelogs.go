@@ -0,0 +1,223 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// The node service logs are found in the must-gather in one of these layouts:
+//
+//	host_service_logs/<role>s/<service>_service.log    the lines of all the nodes of the role
+//	nodes/<node>/<node>_logs_<service>[.gz]             the lines of a node
+//	nodes/<node>/<service>_service.log[.gz]             the lines of a node
+const (
+	hostServiceLogsDir = "host_service_logs"
+	nodesDir           = "nodes"
+	serviceLogSuffix   = "_service.log"
+)
+
+// NodeLogFile is a node service log of the must-gather.
+type NodeLogFile struct {
+	Service string
+	// Role is master or worker, it is empty when unknown.
+	Role string
+	// Node is the node the log was collected on, it is empty when the log
+	// holds the lines of all the nodes of its role.
+	Node string
+	Path string
+}
+
+// NodeRoles returns the roles of the nodes of the must-gather in root, master
+// for the control plane ones.
+func NodeRoles(root string) map[string]string {
+	roles := map[string]string{}
+	dir := filepath.Join(root, "cluster-scoped-resources", "core", "nodes")
+	files, _ := os.ReadDir(dir)
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			continue
+		}
+		node := corev1.Node{}
+		if err := yaml.Unmarshal(data, &node); err != nil || node.Name == "" {
+			continue
+		}
+		roles[node.Name] = ""
+		for label := range node.Labels {
+			switch label {
+			case "node-role.kubernetes.io/master", "node-role.kubernetes.io/control-plane":
+				roles[node.Name] = "master"
+			case "node-role.kubernetes.io/worker":
+				if roles[node.Name] == "" {
+					roles[node.Name] = "worker"
+				}
+			}
+		}
+	}
+	return roles
+}
+
+// NodeExists tells whether node is a node of the must-gather in root, known
+// from its manifest or from the directory of its logs.
+func NodeExists(root string, node string) bool {
+	if _, ok := NodeRoles(root)[node]; ok {
+		return true
+	}
+	if !filepath.IsLocal(node) || filepath.Base(node) != node {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(root, nodesDir, node))
+	return err == nil && info.IsDir()
+}
+
+// NodeLogFiles returns the node service logs found in the must-gather in
+// root, sorted by service, role and node.
+func NodeLogFiles(root string) []NodeLogFile {
+	var files []NodeLogFile
+	roleDirs, _ := os.ReadDir(filepath.Join(root, hostServiceLogsDir))
+	for _, roleDir := range roleDirs {
+		if !roleDir.IsDir() {
+			continue
+		}
+		entries, _ := os.ReadDir(filepath.Join(root, hostServiceLogsDir, roleDir.Name()))
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), serviceLogSuffix) {
+				files = append(files, NodeLogFile{
+					Service: strings.TrimSuffix(entry.Name(), serviceLogSuffix),
+					Role:    strings.TrimSuffix(roleDir.Name(), "s"),
+					Path:    filepath.Join(root, hostServiceLogsDir, roleDir.Name(), entry.Name()),
+				})
+			}
+		}
+	}
+	roles := NodeRoles(root)
+	nodeDirs, _ := os.ReadDir(filepath.Join(root, nodesDir))
+	for _, nodeDir := range nodeDirs {
+		if !nodeDir.IsDir() {
+			continue
+		}
+		node := nodeDir.Name()
+		entries, _ := os.ReadDir(filepath.Join(root, nodesDir, node))
+		for _, entry := range entries {
+			name := strings.TrimSuffix(entry.Name(), ".gz")
+			service := ""
+			if strings.HasPrefix(name, node+"_logs_") {
+				service = strings.TrimPrefix(name, node+"_logs_")
+			} else if strings.HasSuffix(name, serviceLogSuffix) {
+				service = strings.TrimSuffix(name, serviceLogSuffix)
+			}
+			if entry.IsDir() || service == "" {
+				continue
+			}
+			files = append(files, NodeLogFile{
+				Service: service,
+				Role:    roles[node],
+				Node:    node,
+				Path:    filepath.Join(root, nodesDir, node, entry.Name()),
+			})
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].Service != files[j].Service {
+			return files[i].Service < files[j].Service
+		}
+		if files[i].Role != files[j].Role {
+			return files[i].Role < files[j].Role
+		}
+		return files[i].Node < files[j].Node
+	})
+	return files
+}
+
+// SelectNodeLogFiles returns the logs of service among files, of node and of
+// role when they are set. The log of a node is preferred to the log of its
+// role, which holds its lines too.
+func SelectNodeLogFiles(files []NodeLogFile, service string, node string, role string) []NodeLogFile {
+	var nodeFiles, roleFiles []NodeLogFile
+	for _, file := range files {
+		if file.Service != service || (role != "" && file.Role != role) {
+			continue
+		}
+		if file.Node == "" {
+			roleFiles = append(roleFiles, file)
+		} else if node == "" || file.Node == node {
+			nodeFiles = append(nodeFiles, file)
+		}
+	}
+	if node != "" {
+		if len(nodeFiles) > 0 {
+			return nodeFiles
+		}
+		return roleFiles
+	}
+	// the nodes of a role whose log was collected are read from it
+	selected := roleFiles
+	for _, file := range nodeFiles {
+		covered := false
+		for _, roleFile := range roleFiles {
+			covered = covered || (file.Role != "" && roleFile.Role == file.Role)
+		}
+		if !covered {
+			selected = append(selected, file)
+		}
+	}
+	return selected
+}
+
+// NewNodeLogReader returns a reader of a node service log, of the lines of
+// node only when it is set and the log holds the lines of several nodes, and
+// of the lines in window when it isn't nil.
+func NewNodeLogReader(file NodeLogFile, node string, window *TimeWindow) *LogReader {
+	reader := &LogReader{dirname: filepath.Dir(file.Path), files: &[]string{filepath.Base(file.Path)}}
+	var windowFilter, hostFilter logLineFilter
+	if window != nil {
+		windowFilter = window.filter()
+	}
+	if node != "" && file.Node == "" {
+		hostFilter = &journalHostFilter{node: node}
+	}
+	reader.WithFilter(newLogLineFilters(windowFilter, hostFilter))
+	return reader
+}
+
+// journalHostFilter keeps the journald lines written on a node, the lines
+// without journald prefix go with the previous line.
+type journalHostFilter struct {
+	node string
+	keep bool
+}
+
+func (f *journalHostFilter) filterLogLine(log []byte) ([]byte, error) {
+	if host, ok := journalHost(log); ok {
+		// the host name may be the short name of the node or the reverse
+		f.keep = host == f.node || strings.HasPrefix(f.node, host+".") || strings.HasPrefix(host, f.node+".")
+	}
+	if f.keep {
+		return log, nil
+	}
+	return []byte{}, nil
+}
+
+// journalHost returns the host of a journald line like
+// "Nov 02 06:12:08.604741 master-0 kubelet[2314]: ..." or
+// "2023-11-02T06:12:08+0000 master-0 kubelet[2314]: ...".
+func journalHost(log []byte) (string, bool) {
+	fields := strings.Fields(string(log[:min(len(log), 128)]))
+	if len(fields) > 1 {
+		if _, err := time.Parse(timestampLayouts[1], fields[0]); err == nil {
+			return fields[1], true
+		}
+	}
+	if len(fields) > 3 {
+		if _, err := time.Parse("Jan 2 15:04:05", strings.Join(fields[:3], " ")); err == nil {
+			return fields[3], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,150 @@
+package logs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeNodeLogs(t *testing.T, root string) {
+	files := map[string]string{
+		"host_service_logs/masters/kubelet_service.log": `Jan 02 09:00:00.000000 master-0 kubelet[1]: E0102 09:00:00.000000 1 a.go:1] boom on master-0
+Jan 02 09:00:01.000000 master-1.example.com kubelet[1]: I0102 09:00:01.000000 1 a.go:2] ok on master-1
+goroutine 1 [running]:
+Jan 02 09:00:02.000000 master-0 kubelet[1]: I0102 09:00:02.000000 1 a.go:3] ok on master-0
+`,
+		"host_service_logs/masters/crio_service.log": "Jan 02 09:00:00.000000 master-0 crio[1]: crio up\n",
+		"cluster-scoped-resources/core/nodes/master-1.example.com.yaml": `apiVersion: v1
+kind: Node
+metadata:
+  name: master-1.example.com
+  labels:
+    node-role.kubernetes.io/control-plane: ""
+    node-role.kubernetes.io/master: ""
+`,
+		"cluster-scoped-resources/core/nodes/worker-0.yaml": `apiVersion: v1
+kind: Node
+metadata:
+  name: worker-0
+  labels:
+    node-role.kubernetes.io/worker: ""
+`,
+		"nodes/worker-0/sysinfo.log": "not a service log\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write([]byte("Jan 02 09:30:00.000000 worker-0 kubelet[1]: E0102 09:30:00.000000 1 a.go:1] boom on worker-0\n"))
+	writer.Close()
+	if err := os.WriteFile(filepath.Join(root, "nodes/worker-0/worker-0_logs_kubelet.gz"), compressed.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNodeLogFiles(t *testing.T) {
+	root := t.TempDir()
+	writeNodeLogs(t, root)
+	crio := NodeLogFile{Service: "crio", Role: "master", Path: filepath.Join(root, "host_service_logs/masters/crio_service.log")}
+	masters := NodeLogFile{Service: "kubelet", Role: "master", Path: filepath.Join(root, "host_service_logs/masters/kubelet_service.log")}
+	worker := NodeLogFile{Service: "kubelet", Role: "worker", Node: "worker-0", Path: filepath.Join(root, "nodes/worker-0/worker-0_logs_kubelet.gz")}
+	files := NodeLogFiles(root)
+	if expected := []NodeLogFile{crio, masters, worker}; !reflect.DeepEqual(files, expected) {
+		t.Fatalf("Expected : %+v, got: %+v", expected, files)
+	}
+
+	tests := []struct {
+		name     string
+		service  string
+		node     string
+		role     string
+		expected []NodeLogFile
+	}{
+		{name: "All the nodes", service: "kubelet", expected: []NodeLogFile{masters, worker}},
+		{name: "Nodes of a role", service: "kubelet", role: "worker", expected: []NodeLogFile{worker}},
+		{name: "Node with its own log", service: "kubelet", node: "worker-0", role: "worker", expected: []NodeLogFile{worker}},
+		{name: "Node read from the log of its role", service: "kubelet", node: "master-1.example.com", role: "master", expected: []NodeLogFile{masters}},
+		{name: "Service not collected for the node", service: "crio", node: "worker-0", role: "worker"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := SelectNodeLogFiles(files, tc.service, tc.node, tc.role)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Fatalf("Expected : %+v, got: %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNodeExists(t *testing.T) {
+	root := t.TempDir()
+	writeNodeLogs(t, root)
+	tests := []struct {
+		node     string
+		expected bool
+	}{
+		{node: "master-1.example.com", expected: true},
+		{node: "worker-0", expected: true},
+		{node: "master-0"},
+		{node: "worker-9"},
+		{node: "../nodes"},
+		{node: "worker-0/sysinfo.log"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.node, func(t *testing.T) {
+			if actual := NodeExists(root, tc.node); actual != tc.expected {
+				t.Fatalf("Expected : %v, got: %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNodeLogReader(t *testing.T) {
+	root := t.TempDir()
+	writeNodeLogs(t, root)
+	files := NodeLogFiles(root)
+
+	tests := []struct {
+		name     string
+		file     NodeLogFile
+		node     string
+		expected string
+	}{
+		{
+			name: "Lines of a node from the log of its role",
+			file: files[1],
+			node: "master-1.example.com",
+			expected: `Jan 02 09:00:01.000000 master-1.example.com kubelet[1]: I0102 09:00:01.000000 1 a.go:2] ok on master-1
+goroutine 1 [running]:
+`,
+		},
+		{
+			name:     "Gzipped log of a node",
+			file:     files[2],
+			node:     "worker-0",
+			expected: "Jan 02 09:30:00.000000 worker-0 kubelet[1]: E0102 09:30:00.000000 1 a.go:1] boom on worker-0\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			output := new(bytes.Buffer)
+			if err := NewNodeLogReader(tc.file, tc.node, nil).Read(output); err != nil {
+				t.Fatal(err)
+			}
+			if output.String() != tc.expected {
+				t.Errorf("Got: %v \n", output.String())
+				t.Errorf("Want: %v \n", tc.expected)
+			}
+		})
+	}
+}
@@ -16,16 +16,25 @@ limitations under the License.
 package logs
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"os"
 
 	v1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/yaml"
 )
 
-func logsPods(currentContextPath string, defaultConfigNamespace string, podName string, containerName string, previousFlag bool, rotatedFlag bool, allContainersFlag bool, logLevels []string, insecureFlag bool) {
-	var logFilter logLineFilter = NewCRILogFilter(logLevels, nil)
+func logsPods(ctx context.Context, w io.Writer, currentContextPath string, defaultConfigNamespace string, podName string, containerName string, previousFlag bool, rotatedFlag bool, allContainersFlag bool, logLevels []string, insecureFlag bool, window *TimeWindow) error {
+	// the time window filter keeps the state of the log it reads, every
+	// reader gets its own
+	logFilter := func() logLineFilter {
+		var windowFilter logLineFilter
+		if window != nil {
+			windowFilter = window.filter()
+		}
+		return newLogLineFilters(windowFilter, NewCRILogFilter(logLevels, nil))
+	}
 	var _Items v1.PodList
 	CurrentNamespacePath := currentContextPath + "/namespaces/" + defaultConfigNamespace
 	_file, err := ioutil.ReadFile(CurrentNamespacePath + "/core/pods.yaml")
@@ -34,20 +43,17 @@ func logsPods(currentContextPath string, defaultConfigNamespace string, podName
 		// In such cases, we need to look for the pod in the pods directory
 		_file, err = ioutil.ReadFile(CurrentNamespacePath + "/pods/" + podName + "/" + podName + ".yaml")
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "error: pod "+podName+" not found.")
-			os.Exit(1)
+			return fmt.Errorf("error: pod %s not found.", podName)
 		}
 		// We create a Pod object and append it to the _Items PodList
 		var pod v1.Pod
 		if err := yaml.Unmarshal([]byte(_file), &pod); err != nil {
-			fmt.Fprintln(os.Stderr, "Error when trying to unmarshal file "+CurrentNamespacePath+"/pods/"+podName+"/"+podName+".yaml")
-			os.Exit(1)
+			return fmt.Errorf("Error when trying to unmarshal file %s/pods/%s/%s.yaml", CurrentNamespacePath, podName, podName)
 		}
 		_Items.Items = append(_Items.Items, pod)
 	}
 	if err := yaml.Unmarshal([]byte(_file), &_Items); err != nil {
-		fmt.Fprintln(os.Stderr, "Error when trying to unmarshal file "+CurrentNamespacePath+"/core/pods.yaml")
-		os.Exit(1)
+		return fmt.Errorf("Error when trying to unmarshal file %s/core/pods.yaml", CurrentNamespacePath)
 	}
 	podMatch := ""
 	for _, Pod := range _Items.Items {
@@ -63,7 +69,8 @@ func logsPods(currentContextPath string, defaultConfigNamespace string, podName
 			if allContainersFlag {
 				for _, c := range Pod.Spec.Containers {
 					log := NewLogReader(CurrentNamespacePath + "/pods/" + Pod.Name + "/" + c.Name + "/" + c.Name + "/logs")
-					log.WithFilter(logFilter)
+					log.WithFilter(logFilter())
+					log.WithContext(ctx)
 					if previousFlag {
 						log.FromPrevious()
 					}
@@ -73,9 +80,11 @@ func logsPods(currentContextPath string, defaultConfigNamespace string, podName
 					if insecureFlag {
 						log.FromInsecure()
 					}
-					log.Read(os.Stdout)
+					if err := log.Read(w); err != nil {
+						return err
+					}
 				}
-				return
+				return nil
 			} else {
 				var containerSlice []v1.Container
 				containerSlice = append(containerSlice, Pod.Spec.Containers...)
@@ -99,15 +108,14 @@ func logsPods(currentContextPath string, defaultConfigNamespace string, podName
 		}
 		if containerMatch == "" {
 			if containerName != "" {
-				fmt.Fprintln(os.Stderr, "error: container "+containerName+" is not valid for pod "+Pod.Name)
-				os.Exit(1)
+				return fmt.Errorf("error: container %s is not valid for pod %s", containerName, Pod.Name)
 			} else {
-				fmt.Fprintln(os.Stderr, "error: a container name must be specified for pod "+Pod.Name+", choose one of:", containers)
-				os.Exit(1)
+				return fmt.Errorf("error: a container name must be specified for pod %s, choose one of: %v", Pod.Name, containers)
 			}
 		} else {
 			log := NewLogReader(CurrentNamespacePath + "/pods/" + Pod.Name + "/" + containerMatch + "/" + containerMatch + "/logs/")
-			log.WithFilter(logFilter)
+			log.WithFilter(logFilter())
+			log.WithContext(ctx)
 			if previousFlag {
 				log.FromPrevious()
 			}
@@ -117,11 +125,13 @@ func logsPods(currentContextPath string, defaultConfigNamespace string, podName
 			if insecureFlag {
 				log.FromInsecure()
 			}
-			log.Read(os.Stdout)
+			if err := log.Read(w); err != nil {
+				return err
+			}
 		}
 	}
 	if podMatch == "" {
-		fmt.Fprintln(os.Stderr, "error: pods "+podName+" not found")
-		os.Exit(1)
+		return fmt.Errorf("error: pods %s not found", podName)
 	}
+	return nil
 }
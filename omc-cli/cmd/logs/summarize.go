@@ -0,0 +1,409 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gmeghnag/omc/cmd/helpers"
+	"github.com/gmeghnag/omc/vars"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	summarizeService string
+	summarizeTop     int
+)
+
+const (
+	levelError   = "error"
+	levelWarning = "warning"
+	levelInfo    = "info"
+)
+
+// LogSummary groups the log lines by template, the lines with their variable
+// parts masked, and holds the most frequent error and warning signatures.
+type LogSummary struct {
+	Lines    int `json:"lines"`
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+	// Templates is the number of templates of all levels.
+	Templates  int            `json:"templates"`
+	Signatures []LogSignature `json:"signatures"`
+	// Truncated is set when there are more error and warning signatures
+	// than the ones returned.
+	Truncated bool `json:"truncated"`
+}
+
+// LogSignature is a template of log lines and where and when it was found.
+type LogSignature struct {
+	Level     string     `json:"level"`
+	Template  string     `json:"template"`
+	Count     int        `json:"count"`
+	FirstSeen *time.Time `json:"firstSeen,omitempty"`
+	LastSeen  *time.Time `json:"lastSeen,omitempty"`
+	// Example is the first line of the template.
+	Example string `json:"example"`
+	// Sources are the containers, as <namespace>/<pod>/<container>, or the
+	// node service logs, as <role>s/<service> or <node>/<service>, with lines
+	// of the template.
+	Sources []string `json:"sources"`
+}
+
+// variableParts mask, in turn, the parts of a log token which change from a
+// line to another.
+var variableParts = []struct {
+	pattern *regexp.Regexp
+	mask    string
+}{
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<UUID>"},
+	{regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}(?:T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?)?\b`), "<TIME>"},
+	{regexp.MustCompile(`\b\d{1,2}:\d{2}:\d{2}(?:\.\d+)?\b`), "<TIME>"},
+	{regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?\b`), "<IP>"},
+	{regexp.MustCompile(`(?i)\b(?:[0-9a-f]{1,4}:){7}[0-9a-f]{1,4}\b|\b[0-9a-f]{1,4}(?::[0-9a-f]{1,4})*::(?:[0-9a-f]{1,4}(?::[0-9a-f]{1,4})*)?|::[0-9a-f]{1,4}(?::[0-9a-f]{1,4})*`), "<IP>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{12,}\b`), "<ID>"},
+	// the replica set and pod suffixes of generated pod names, without vowels
+	{regexp.MustCompile(`-[bcdfghjklmnpqrstvwxz2456789]{9,10}-[bcdfghjklmnpqrstvwxz2456789]{5}\b`), "-<ID>"},
+	{regexp.MustCompile(`\b\d(?:[\w.]*\w)?`), "<NUM>"},
+}
+
+var (
+	klogHeader   = regexp.MustCompile(`^[IWEF]\d{4}$`)
+	levelField   = regexp.MustCompile(`(?i)\b(?:level|lvl|severity)"?\s*[=:]\s*"?([a-z]+)`)
+	errorWords   = regexp.MustCompile(`(?i)\b(?:error|errors|failed|failure|fatal|panic|exception)\b`)
+	warningWords = regexp.MustCompile(`(?i)\bwarn(?:ing)?\b`)
+)
+
+// parseLogLine returns the level and the template of a log line: its message,
+// without the CRI-O, journald and klog prefixes, split in tokens whose
+// variable parts are masked.
+func parseLogLine(line string) (string, string) {
+	fields := strings.Fields(line)
+	i, journald := 0, false
+	if len(fields) > 0 {
+		if _, err := time.Parse(time.RFC3339Nano, fields[0]); err == nil {
+			i = 1
+			if len(fields) > 2 && (fields[1] == "stdout" || fields[1] == "stderr") && (fields[2] == "F" || fields[2] == "P") {
+				i = 3
+			}
+		} else if _, err := time.Parse(timestampLayouts[1], fields[0]); err == nil {
+			// journald short-iso timestamp and host
+			i, journald = 2, true
+		} else if len(fields) > 3 {
+			if _, err := time.Parse("Jan 2 15:04:05", strings.Join(fields[:3], " ")); err == nil {
+				// journald timestamp and host
+				i, journald = 4, true
+			}
+		}
+	}
+	fields = fields[min(i, len(fields)):]
+	// journald lines go on with the process, like kubelet[2314]:
+	j := 0
+	if journald && len(fields) > 0 && strings.HasSuffix(fields[0], ":") {
+		j = 1
+	}
+	level := ""
+	if j+2 < len(fields) && klogHeader.MatchString(fields[j]) {
+		if _, err := time.Parse("15:04:05", fields[j+1]); err == nil {
+			switch fields[j][0] {
+			case 'E', 'F':
+				level = levelError
+			case 'W':
+				level = levelWarning
+			default:
+				level = levelInfo
+			}
+			// the thread id follows the time
+			fields = append(fields[:j:j], fields[j+3:]...)
+		}
+	}
+	if level == "" {
+		level = messageLevel(strings.Join(fields, " "))
+	}
+	tokens := make([]string, 0, len(fields))
+	for _, token := range fields {
+		for _, part := range variableParts {
+			token = part.pattern.ReplaceAllString(token, part.mask)
+		}
+		tokens = append(tokens, token)
+	}
+	return level, strings.Join(tokens, " ")
+}
+
+// messageLevel returns the level of a message without klog header, from its
+// level field like level=error, or else from the words it contains.
+func messageLevel(message string) string {
+	if match := levelField.FindStringSubmatch(message); match != nil {
+		value := strings.ToLower(match[1])
+		switch {
+		case strings.HasPrefix(value, "err"), strings.HasPrefix(value, "fatal"), strings.HasPrefix(value, "crit"), strings.HasPrefix(value, "panic"):
+			return levelError
+		case strings.HasPrefix(value, "warn"):
+			return levelWarning
+		default:
+			return levelInfo
+		}
+	}
+	if errorWords.MatchString(message) {
+		return levelError
+	}
+	if warningWords.MatchString(message) {
+		return levelWarning
+	}
+	return levelInfo
+}
+
+// logSummarizer is a logLineFilter grouping the lines it reads by template, it
+// filters all of them out.
+type logSummarizer struct {
+	window     *TimeWindow
+	signatures map[string]*LogSignature
+	lines      int
+	// source is the source being read and last the time of its last line
+	// with a timestamp, given to the following lines without one.
+	source string
+	last   time.Time
+}
+
+func newLogSummarizer(reference time.Time) *logSummarizer {
+	return &logSummarizer{window: &TimeWindow{Reference: reference}, signatures: map[string]*LogSignature{}}
+}
+
+// from starts reading the lines of another source.
+func (s *logSummarizer) from(source string) {
+	s.source, s.last = source, time.Time{}
+}
+
+func (s *logSummarizer) filterLogLine(log []byte) ([]byte, error) {
+	line := strings.TrimSpace(string(log))
+	if line == "" {
+		return []byte{}, nil
+	}
+	s.lines++
+	if t, ok := s.window.timestamp(log); ok {
+		s.last = t
+	}
+	level, template := parseLogLine(line)
+	key := level + "\x00" + template
+	signature, ok := s.signatures[key]
+	if !ok {
+		signature = &LogSignature{Level: level, Template: template, Example: line}
+		s.signatures[key] = signature
+	}
+	signature.Count++
+	if !s.last.IsZero() {
+		t := s.last
+		if signature.FirstSeen == nil || t.Before(*signature.FirstSeen) {
+			signature.FirstSeen = &t
+		}
+		if signature.LastSeen == nil || t.After(*signature.LastSeen) {
+			signature.LastSeen = &t
+		}
+	}
+	if len(signature.Sources) == 0 || signature.Sources[len(signature.Sources)-1] != s.source {
+		signature.Sources = append(signature.Sources, s.source)
+	}
+	return []byte{}, nil
+}
+
+// summary returns the top error and warning signatures, all of them when top
+// isn't positive.
+func (s *logSummarizer) summary(top int) *LogSummary {
+	summary := &LogSummary{Lines: s.lines, Templates: len(s.signatures), Signatures: []LogSignature{}}
+	for _, signature := range s.signatures {
+		switch signature.Level {
+		case levelError:
+			summary.Errors += signature.Count
+		case levelWarning:
+			summary.Warnings += signature.Count
+		default:
+			continue
+		}
+		sources := map[string]bool{}
+		for _, source := range signature.Sources {
+			sources[source] = true
+		}
+		signature.Sources = signature.Sources[:0]
+		for source := range sources {
+			signature.Sources = append(signature.Sources, source)
+		}
+		sort.Strings(signature.Sources)
+		summary.Signatures = append(summary.Signatures, *signature)
+	}
+	sort.Slice(summary.Signatures, func(i, j int) bool {
+		a, b := summary.Signatures[i], summary.Signatures[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Level != b.Level {
+			return a.Level == levelError
+		}
+		return a.Template < b.Template
+	})
+	if top > 0 && len(summary.Signatures) > top {
+		summary.Signatures = summary.Signatures[:top]
+		summary.Truncated = true
+	}
+	return summary
+}
+
+// summarizeLogs reads the logs of the readers, sources being the names of
+// what they read, and returns their summary. reference is the time the
+// must-gather was collected, only the lines in window are read when it isn't
+// nil.
+func summarizeLogs(ctx context.Context, readers []*LogReader, sources []string, reference time.Time, window *TimeWindow, top int) (*LogSummary, error) {
+	summarizer := newLogSummarizer(reference)
+	for i, reader := range readers {
+		summarizer.from(sources[i])
+		// the time window filter keeps the state of the log it reads
+		var windowFilter logLineFilter
+		if window != nil {
+			windowFilter = window.filter()
+		}
+		reader.WithFilter(newLogLineFilters(windowFilter, summarizer))
+		reader.WithContext(ctx)
+		if err := reader.Read(io.Discard); err != nil {
+			return nil, err
+		}
+	}
+	return summarizer.summary(top), nil
+}
+
+// ContainerErrorSignatures returns the error signatures of the current,
+// previous and rotated logs of the containers of the namespaces, by container
+// as <namespace>/<pod>/<container>. Only the lines in window are read when it
+// isn't nil. The reading stops with the error of ctx once it is done.
+func ContainerErrorSignatures(ctx context.Context, root string, namespaces []string, window *TimeWindow) (map[string][]LogSignature, error) {
+	reference, _ := helpers.MustGatherTime(root)
+	if window != nil {
+		reference = window.Reference
+	}
+	signatures := map[string][]LogSignature{}
+	for _, directory := range containerLogDirectories(root, namespaces, "", "") {
+		source := directory.namespace + "/" + directory.pod + "/" + directory.container
+		readers, _ := containerLogReaders([]logDirectory{directory}, false, false, false)
+		summary, err := summarizeLogs(ctx, readers, make([]string, len(readers)), reference, window, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, signature := range summary.Signatures {
+			if signature.Level == levelError {
+				signature.Sources = []string{source}
+				signatures[source] = append(signatures[source], signature)
+			}
+		}
+	}
+	return signatures, nil
+}
+
+func printLogSummary(w io.Writer, summary *LogSummary, output string) error {
+	switch output {
+	case "json":
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", data)
+		return nil
+	case "yaml":
+		data, err := yaml.Marshal(summary)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s", data)
+		return nil
+	case "":
+	default:
+		return fmt.Errorf("unsupported output format %q, expected one of: json|yaml", output)
+	}
+	fmt.Fprintf(w, "Lines: %d, errors: %d, warnings: %d, templates: %d\n", summary.Lines, summary.Errors, summary.Warnings, summary.Templates)
+	for i, signature := range summary.Signatures {
+		fmt.Fprintf(w, "\n%d. %s (count %d", i+1, signature.Level, signature.Count)
+		if signature.FirstSeen != nil {
+			fmt.Fprintf(w, ", %s to %s", signature.FirstSeen.Format(time.RFC3339), signature.LastSeen.Format(time.RFC3339))
+		}
+		fmt.Fprintln(w, ")")
+		fmt.Fprintf(w, "   template: %s\n", signature.Template)
+		fmt.Fprintf(w, "   example:  %s\n", signature.Example)
+		fmt.Fprintf(w, "   sources:  %s\n", strings.Join(signature.Sources, ", "))
+	}
+	if summary.Truncated {
+		fmt.Fprintf(w, "\nOnly the top %d error and warning signatures are shown.\n", len(summary.Signatures))
+	}
+	return nil
+}
+
+var Summarize = &cobra.Command{
+	Use:   "summarize [POD]",
+	Short: "Group the log lines of a pod, a namespace or a node service by template and show the top error and warning signatures",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 1 {
+			return fmt.Errorf("error: expected 'logs summarize [POD]', found %d arguments", len(args))
+		}
+		if err := resolveNamespacesRoot(); err != nil {
+			return err
+		}
+		// without timestamp file the years of the journald and klog
+		// timestamps are guessed from the current time
+		reference, _ := helpers.MustGatherTime(vars.MustGatherRootPath)
+		var readers []*LogReader
+		var sources []string
+		if summarizeService != "" {
+			if len(args) == 1 {
+				return fmt.Errorf("error: only one of a pod or --service is allowed")
+			}
+			for _, file := range SelectNodeLogFiles(NodeLogFiles(vars.MustGatherRootPath), summarizeService, "", "") {
+				source := file.Role + "s/" + summarizeService
+				if file.Node != "" {
+					source = file.Node + "/" + summarizeService
+				}
+				readers = append(readers, NewNodeLogReader(file, "", nil))
+				sources = append(sources, source)
+			}
+			if len(readers) == 0 {
+				return fmt.Errorf("logs for service \"%s\" not found or readable.", summarizeService)
+			}
+		} else {
+			pod := ""
+			namespaces := []string{vars.Namespace}
+			if len(args) == 1 {
+				pod = strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(args[0], "pods/"), "pod/"), "po/")
+			} else {
+				var err error
+				if namespaces, err = selectedNamespaces(); err != nil {
+					return err
+				}
+			}
+			directories := containerLogDirectories(vars.MustGatherRootPath, namespaces, pod, vars.Container)
+			if len(directories) == 0 {
+				if pod != "" {
+					return fmt.Errorf("error: pod %s has no container logs in namespace %s", pod, vars.Namespace)
+				}
+				return fmt.Errorf("error: no container logs found")
+			}
+			var owners []logDirectory
+			readers, owners = containerLogReaders(directories, vars.Previous, vars.Rotated, vars.InsecureLogs)
+			for _, owner := range owners {
+				sources = append(sources, owner.namespace+"/"+owner.pod+"/"+owner.container)
+			}
+		}
+		summary, err := summarizeLogs(cmd.Context(), readers, sources, reference, nil, summarizeTop)
+		if err != nil {
+			return err
+		}
+		return printLogSummary(cmd.OutOrStdout(), summary, vars.OutputStringVar)
+	},
+}
+
+func init() {
+	Summarize.Flags().BoolVarP(&vars.AllNamespaceBoolVar, "all-namespaces", "A", false, "If present, summarize the logs of the pods across all namespaces.")
+	Summarize.Flags().StringVar(&summarizeService, "service", "", "Summarize the log of this node service, like kubelet or crio, instead of the pods.")
+	Summarize.Flags().IntVar(&summarizeTop, "top", 10, "Number of error and warning signatures to show, all of them when 0.")
+	Summarize.Flags().StringVarP(&vars.OutputStringVar, "output", "o", "", "Output format. One of: json|yaml")
+}
@@ -0,0 +1,118 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLogLine(t *testing.T) {
+	tests := []struct {
+		name             string
+		log              string
+		expectedLevel    string
+		expectedTemplate string
+	}{
+		{
+			name:             "CRI-O line with klog header",
+			log:              "2023-11-02T06:12:07.604163885+00:00 stderr F E1102 06:12:07.604158       1 reflector.go:138] failed to list *v1.Pod: Get \"https://172.30.0.1:443/api/v1/pods?limit=500\": dial tcp 172.30.0.1:443: i/o timeout",
+			expectedLevel:    "error",
+			expectedTemplate: "reflector.go:<NUM>] failed to list *v1.Pod: Get \"https://<IP>/api/v1/pods?limit=<NUM>\": dial tcp <IP>: i/o timeout",
+		},
+		{
+			name:             "journald line with level field",
+			log:              "Nov 02 06:12:08.604741 master-0 crio[2314]: time=\"2023-11-02 06:12:08.604741\" level=warning msg=\"Stopping container 3f9a0d6c2b1e4f5a for pod 7b3e5c1a-2d4f-4e6a-8b9c-0d1e2f3a4b5c\"",
+			expectedLevel:    "warning",
+			expectedTemplate: "crio[<NUM>]: time=\"<TIME> <TIME>\" level=warning msg=\"Stopping container <ID> for pod <UUID>\"",
+		},
+		{
+			name:             "Plain line with error word",
+			log:              "panic: runtime error: invalid memory address or nil pointer dereference",
+			expectedLevel:    "error",
+			expectedTemplate: "panic: runtime error: invalid memory address or nil pointer dereference",
+		},
+		{
+			name:             "Generated pod name and IPv6 address",
+			log:              "I1102 06:12:08.604741       1 controller.go:42] Syncing pod openshift-dns/dns-default-7d9f8b6c5-x2k4p on fd00::1 after 1m30s",
+			expectedLevel:    "info",
+			expectedTemplate: "controller.go:<NUM>] Syncing pod openshift-dns/dns-default-<ID> on <IP> after <NUM>",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			level, template := parseLogLine(tc.log)
+			if level != tc.expectedLevel || template != tc.expectedTemplate {
+				t.Fatalf("Expected : %q %q, got: %q %q", tc.expectedLevel, tc.expectedTemplate, level, template)
+			}
+		})
+	}
+}
+
+func TestSummarizeLogs(t *testing.T) {
+	dirname := t.TempDir()
+	log := `Nov 02 06:10:00.000000 master-0 kubelet[2314]: E1102 06:10:00.000000    2314 pod_workers.go:951] "Error syncing pod, skipping" pod="openshift-etcd/etcd-master-0" podUID=7b3e5c1a-2d4f-4e6a-8b9c-0d1e2f3a4b5c
+Nov 02 06:11:00.000000 master-0 kubelet[2314]: I1102 06:11:00.000000    2314 kubelet.go:2100] "SyncLoop UPDATE" source="api"
+Nov 02 06:12:00.000000 master-0 kubelet[2314]: E1102 06:12:00.000000    2314 pod_workers.go:951] "Error syncing pod, skipping" pod="openshift-etcd/etcd-master-1" podUID=0d1e2f3a-2d4f-4e6a-8b9c-7b3e5c1a4b5c
+Nov 02 06:13:00.000000 master-0 kubelet[2314]: W1102 06:13:00.000000    2314 watcher.go:93] Error while processing event
+goroutine 1 [running]:
+`
+	if err := os.WriteFile(filepath.Join(dirname, "kubelet_service.log"), []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reader := &LogReader{dirname: dirname, files: &[]string{"kubelet_service.log"}}
+	current := NewLogReader(testdata + "namespaces/test-namespace/pods/test-pod/test-container/test-container/logs")
+	reference := time.Date(2023, time.November, 2, 6, 15, 0, 0, time.UTC)
+	first, last := time.Date(2023, time.November, 2, 6, 10, 0, 0, time.UTC), time.Date(2023, time.November, 2, 6, 12, 0, 0, time.UTC)
+	containerError := time.Date(2023, time.November, 2, 6, 12, 8, 604741676, time.UTC)
+
+	actual, err := summarizeLogs(context.Background(), []*LogReader{reader, current}, []string{"kubelet", "test-namespace/test-pod/test-container"}, reference, nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &LogSummary{Lines: 8, Errors: 3, Warnings: 2, Templates: 7, Truncated: true, Signatures: []LogSignature{
+		{
+			Level: "error", Template: `kubelet[<NUM>]: pod_workers.go:<NUM>] "Error syncing pod, skipping" pod="openshift-etcd/etcd-master-<NUM>" podUID=<UUID>`, Count: 2,
+			FirstSeen: &first, LastSeen: &last,
+			Example: `Nov 02 06:10:00.000000 master-0 kubelet[2314]: E1102 06:10:00.000000    2314 pod_workers.go:951] "Error syncing pod, skipping" pod="openshift-etcd/etcd-master-0" podUID=7b3e5c1a-2d4f-4e6a-8b9c-0d1e2f3a4b5c`,
+			Sources: []string{"kubelet"},
+		},
+		{
+			Level: "error", Template: "test_app_go:<NUM>] My Error LogMessage", Count: 1,
+			FirstSeen: &containerError, LastSeen: &containerError,
+			Example: "2023-11-02T06:12:08.604741676Z E1106 06:12:08.604741       1 test_app_go:542] My Error LogMessage",
+			Sources: []string{"test-namespace/test-pod/test-container"},
+		},
+	}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected : %+v, got: %+v", expected, actual)
+	}
+}
+
+func TestPrintLogSummary(t *testing.T) {
+	first, last := time.Date(2023, time.November, 2, 6, 10, 0, 0, time.UTC), time.Date(2023, time.November, 2, 6, 12, 0, 0, time.UTC)
+	summary := &LogSummary{Lines: 10, Errors: 2, Warnings: 1, Templates: 4, Truncated: true, Signatures: []LogSignature{
+		{Level: "error", Template: "dial tcp <IP>: i/o timeout", Count: 2, FirstSeen: &first, LastSeen: &last, Example: "dial tcp 172.30.0.1:443: i/o timeout", Sources: []string{"ns/pod/c", "ns/pod/d"}},
+	}}
+	output := new(bytes.Buffer)
+	if err := printLogSummary(output, summary, ""); err != nil {
+		t.Fatal(err)
+	}
+	expected := `Lines: 10, errors: 2, warnings: 1, templates: 4
+
+1. error (count 2, 2023-11-02T06:10:00Z to 2023-11-02T06:12:00Z)
+   template: dial tcp <IP>: i/o timeout
+   example:  dial tcp 172.30.0.1:443: i/o timeout
+   sources:  ns/pod/c, ns/pod/d
+
+Only the top 1 error and warning signatures are shown.
+`
+	if output.String() != expected {
+		t.Errorf("Got: %v \n", output.String())
+		t.Errorf("Want: %v \n", expected)
+	}
+}
@@ -0,0 +1,155 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gmeghnag/omc/cmd/helpers"
+)
+
+// timestampLayouts are the layouts of the timestamps prefixing the log lines
+// with a date: the CRI-O ones and the journald short-iso ones.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999-0700",
+}
+
+// TimeWindow selects the log lines written from Since to Until, a zero time
+// leaves the window open on its side. The lines without timestamp, like the
+// ones of a stack trace, go with the previous line.
+type TimeWindow struct {
+	Since time.Time
+	Until time.Time
+	// Reference is the time the must-gather was collected, it gives its year
+	// to the journald and klog timestamps, which have none.
+	Reference time.Time
+}
+
+// NewTimeWindow returns the time window of the --since-time, --until-time and
+// --since flags, the duration of --since being relative to the time the
+// must-gather in root was collected. It returns nil when none is set.
+func NewTimeWindow(root string, sinceTime string, untilTime string, since string) (*TimeWindow, error) {
+	if sinceTime == "" && untilTime == "" && since == "" {
+		return nil, nil
+	}
+	if sinceTime != "" && since != "" {
+		return nil, fmt.Errorf("at most one of --since-time or --since may be specified")
+	}
+	window := &TimeWindow{}
+	reference, err := helpers.MustGatherTime(root)
+	if err != nil {
+		return nil, err
+	}
+	window.Reference = reference
+	if sinceTime != "" {
+		if window.Since, err = time.Parse(time.RFC3339, sinceTime); err != nil {
+			return nil, fmt.Errorf("invalid --since-time %q, expected a RFC3339 time like 2006-01-02T15:04:05Z", sinceTime)
+		}
+	}
+	if untilTime != "" {
+		if window.Until, err = time.Parse(time.RFC3339, untilTime); err != nil {
+			return nil, fmt.Errorf("invalid --until-time %q, expected a RFC3339 time like 2006-01-02T15:04:05Z", untilTime)
+		}
+	}
+	if since != "" {
+		duration, err := time.ParseDuration(since)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("invalid --since %q, expected a positive duration like 5s, 2m or 3h", since)
+		}
+		window.Since = reference.Add(-duration)
+	}
+	if !window.Since.IsZero() && !window.Until.IsZero() && window.Until.Before(window.Since) {
+		return nil, fmt.Errorf("the end of the time window %s is before its start %s", window.Until.Format(time.RFC3339), window.Since.Format(time.RFC3339))
+	}
+	return window, nil
+}
+
+// Contains returns whether t is in the time window.
+func (window *TimeWindow) Contains(t time.Time) bool {
+	return (window.Since.IsZero() || !t.Before(window.Since)) && (window.Until.IsZero() || !t.After(window.Until))
+}
+
+// Copy copies the lines of r written in the time window to w.
+func (window *TimeWindow) Copy(w io.Writer, r io.Reader) error {
+	filter := window.filter()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if log, _ := filter.filterLogLine(scanner.Bytes()); len(log) > 0 {
+			fmt.Fprintln(w, string(log))
+		}
+	}
+	return scanner.Err()
+}
+
+// filter returns a line filter for the time window, it keeps the state of a
+// single log file.
+func (window *TimeWindow) filter() *timeWindowFilter {
+	return &timeWindowFilter{window: window}
+}
+
+type timeWindowFilter struct {
+	window *TimeWindow
+	// keep is the decision taken for the last line with a timestamp.
+	keep bool
+}
+
+func (f *timeWindowFilter) filterLogLine(log []byte) ([]byte, error) {
+	if t, ok := f.window.timestamp(log); ok {
+		f.keep = f.window.Contains(t)
+	}
+	if f.keep {
+		return log, nil
+	}
+	return []byte{}, nil
+}
+
+// timestamp parses the time a log line was written from its CRI-O RFC3339Nano
+// prefix, its journald prefix like "Nov 02 06:12:08.604741" or its klog header
+// like "I1102 06:12:08.604741".
+func (window *TimeWindow) timestamp(log []byte) (time.Time, bool) {
+	fields := bytes.SplitN(log, []byte{' '}, 4)
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, string(fields[0])); err == nil {
+			return t, true
+		}
+	}
+	if len(fields) < 2 {
+		return time.Time{}, false
+	}
+	if len(fields[0]) == 5 && bytes.IndexByte([]byte("IWEF"), fields[0][0]) >= 0 {
+		if t, err := time.Parse("0102 15:04:05", string(fields[0][1:])+" "+string(fields[1])); err == nil {
+			return window.withYear(t), true
+		}
+	}
+	if len(fields) >= 3 {
+		// journald pads the day with a space, "Nov  2" splits in an empty
+		// field
+		day, clock := fields[1], fields[2]
+		if len(day) == 0 && len(fields) == 4 {
+			day = fields[2]
+			clock, _, _ = bytes.Cut(fields[3], []byte{' '})
+		}
+		if t, err := time.Parse("Jan 2 15:04:05", string(fields[0])+" "+string(day)+" "+string(clock)); err == nil {
+			return window.withYear(t), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// withYear sets the year of the must-gather to a timestamp without year, the
+// previous one when it would be more than a day after the must-gather.
+func (window *TimeWindow) withYear(t time.Time) time.Time {
+	reference := window.Reference
+	if reference.IsZero() {
+		reference = time.Now()
+	}
+	t = t.AddDate(reference.Year(), 0, 0)
+	if t.After(reference.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
@@ -0,0 +1,171 @@
+package logs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimestamp(t *testing.T) {
+	window := &TimeWindow{Reference: time.Date(2024, time.January, 2, 10, 0, 0, 0, time.UTC)}
+	tests := []struct {
+		name     string
+		log      string
+		expected time.Time
+		ok       bool
+	}{
+		{
+			name:     "CRI-O RFC3339Nano prefix",
+			log:      "2023-11-02T06:12:08.604741676Z stderr F E1102 06:12:08.604741       1 test_app_go:542] My Error LogMessage",
+			expected: time.Date(2023, time.November, 2, 6, 12, 8, 604741676, time.UTC),
+			ok:       true,
+		},
+		{
+			name:     "klog header gets the year of the must-gather",
+			log:      "I0102 06:12:08.604739       1 test_app.go:242] My Info LogMessage",
+			expected: time.Date(2024, time.January, 2, 6, 12, 8, 604739000, time.UTC),
+			ok:       true,
+		},
+		{
+			name:     "journald timestamp of the previous year",
+			log:      "Dec 31 23:59:59.000001 master-0 kubelet[2314]: I1231 23:59:59.000001    2314 kubelet.go:1] My Info LogMessage",
+			expected: time.Date(2023, time.December, 31, 23, 59, 59, 1000, time.UTC),
+			ok:       true,
+		},
+		{
+			name:     "journald timestamp with a space padded day",
+			log:      "Jan  2 09:00:00 master-0 crio[1234]: message",
+			expected: time.Date(2024, time.January, 2, 9, 0, 0, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			name:     "journald short-iso timestamp",
+			log:      "2024-01-02T09:00:00+0000 master-0 crio[1234]: message",
+			expected: time.Date(2024, time.January, 2, 9, 0, 0, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			name: "line without timestamp",
+			log:  "goroutine 1 [running]:",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, ok := window.timestamp([]byte(tc.log))
+			if ok != tc.ok || !actual.Equal(tc.expected) {
+				t.Fatalf("Expected : %v %v, got: %v %v", tc.expected, tc.ok, actual, ok)
+			}
+		})
+	}
+}
+
+func TestTimeWindowCopy(t *testing.T) {
+	input := `Jan 02 08:59:59.000000 master-0 kubelet[1]: before
+Jan 02 09:00:00.000000 master-0 kubelet[1]: panic: first
+goroutine 1 [running]:
+Jan 02 09:30:00.000000 master-0 kubelet[1]: second
+Jan 02 10:00:01.000000 master-0 kubelet[1]: after
+goroutine 2 [running]:
+`
+	reference := time.Date(2024, time.January, 2, 10, 0, 0, 0, time.UTC)
+	window := &TimeWindow{Since: reference.Add(-time.Hour), Until: reference, Reference: reference}
+	output := new(bytes.Buffer)
+	if err := window.Copy(output, strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	expected := `Jan 02 09:00:00.000000 master-0 kubelet[1]: panic: first
+goroutine 1 [running]:
+Jan 02 09:30:00.000000 master-0 kubelet[1]: second
+`
+	if output.String() != expected {
+		t.Errorf("Got: %v \n", output.String())
+		t.Errorf("Want: %v \n", expected)
+	}
+}
+
+func TestNewTimeWindow(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "timestamp"), []byte("2023-11-02 06:00:00.123456789 +0000 UTC m=+0.017\n2023-11-02 06:15:00.123456789 +0000 UTC m=+900.017\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustGather := filepath.Join(root, "quay-io-openshift-release-dev-ocp-v4-0-art-dev-sha256-0123")
+	if err := os.Mkdir(mustGather, 0755); err != nil {
+		t.Fatal(err)
+	}
+	reference := time.Date(2023, time.November, 2, 6, 15, 0, 123456789, time.UTC)
+
+	tests := []struct {
+		name              string
+		sinceTime         string
+		untilTime         string
+		since             string
+		expected          *TimeWindow
+		expectedErrorText string
+	}{
+		{
+			name: "No flags, no window",
+		},
+		{
+			name:     "Since is relative to the must-gather timestamp",
+			since:    "10m",
+			expected: &TimeWindow{Since: reference.Add(-10 * time.Minute), Reference: reference},
+		},
+		{
+			name:      "Since and until times",
+			sinceTime: "2023-11-02T06:10:00Z",
+			untilTime: "2023-11-02T06:12:00Z",
+			expected:  &TimeWindow{Since: time.Date(2023, time.November, 2, 6, 10, 0, 0, time.UTC), Until: time.Date(2023, time.November, 2, 6, 12, 0, 0, time.UTC), Reference: reference},
+		},
+		{
+			name:              "Since and since time are exclusive",
+			sinceTime:         "2023-11-02T06:10:00Z",
+			since:             "10m",
+			expectedErrorText: "at most one of --since-time or --since may be specified",
+		},
+		{
+			name:              "Invalid duration",
+			since:             "yesterday",
+			expectedErrorText: "invalid --since",
+		},
+		{
+			name:              "Until before since",
+			sinceTime:         "2023-11-02T06:12:00Z",
+			untilTime:         "2023-11-02T06:10:00Z",
+			expectedErrorText: "is before its start",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := NewTimeWindow(mustGather, tc.sinceTime, tc.untilTime, tc.since)
+			if tc.expectedErrorText != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErrorText) {
+					t.Fatalf("Expected error %q, got: %v", tc.expectedErrorText, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (actual == nil) != (tc.expected == nil) || (actual != nil && (!actual.Since.Equal(tc.expected.Since) || !actual.Until.Equal(tc.expected.Until) || !actual.Reference.Equal(tc.expected.Reference))) {
+				t.Fatalf("Expected : %v, got: %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestReadTimeWindow(t *testing.T) {
+	window := &TimeWindow{Since: time.Date(2023, time.November, 2, 6, 12, 8, 604740000, time.UTC)}
+	logReader := &LogReader{testdata + "namespaces/test-namespace/pods/test-pod/test-container/test-container/logs/", &[]string{"current.log"}, newLogLineFilters(window.filter(), NewCRILogFilter([]string{"error"}, nil)), nil}
+	output := new(bytes.Buffer)
+	if err := logReader.Read(output); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(output.String(), "My Info LogMessage") || strings.Contains(output.String(), "My Warning LogMessage") || !strings.Contains(output.String(), "My Error LogMessage") {
+		t.Errorf("Got: %v \n", output.String())
+	}
+}
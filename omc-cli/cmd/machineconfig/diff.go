@@ -16,46 +16,179 @@ limitations under the License.
 package machineconfig
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"os/exec"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/gmeghnag/omc/vars"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/spf13/cobra"
 )
 
-func checkMachineConfigDiff(first string, second string) {
+// machineConfigFiles returns what the machine config writes on the nodes by
+// path, laid out like "omc machine-config extract" does: the decoded storage
+// files, the systemd units and their dropins, the users' SSH keys, and the
+// kernel arguments, kernel type, extensions, FIPS mode and OS image of the
+// spec.
+func machineConfigFiles(name string) (map[string]string, error) {
+	machineConfig, ignConfig, err := loadMachineConfig(name)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]string{}
+	for _, f := range ignConfig.Storage.Files {
+		contents, err := decodeFileContents(f)
+		if err != nil {
+			return nil, err
+		}
+		files["storage/files"+f.Path] = string(contents)
+	}
+	for _, u := range ignConfig.Passwd.Users {
+		keys := ""
+		for _, key := range u.SSHAuthorizedKeys {
+			keys = keys + string(key) + "\n"
+		}
+		files["passwd/users/"+u.Name] = keys
+	}
+	for _, u := range ignConfig.Systemd.Units {
+		if u.Contents != nil {
+			files["systemd/units/"+u.Name] = *u.Contents
+		}
+		if u.Enabled != nil {
+			files["systemd/units/"+u.Name+"/enabled"] = strconv.FormatBool(*u.Enabled) + "\n"
+		}
+		if u.Mask != nil {
+			files["systemd/units/"+u.Name+"/mask"] = strconv.FormatBool(*u.Mask) + "\n"
+		}
+		for _, dropin := range u.Dropins {
+			contents := ""
+			if dropin.Contents != nil {
+				contents = *dropin.Contents
+			}
+			files["systemd/units/"+u.Name+"/dropins/"+dropin.Name] = contents
+		}
+	}
+	spec := machineConfig.Spec
+	if len(spec.KernelArguments) > 0 {
+		files["kernelArguments"] = strings.Join(spec.KernelArguments, "\n") + "\n"
+	}
+	if spec.KernelType != "" {
+		files["kernelType"] = spec.KernelType + "\n"
+	}
+	if len(spec.Extensions) > 0 {
+		files["extensions"] = strings.Join(spec.Extensions, "\n") + "\n"
+	}
+	if spec.FIPS {
+		files["fips"] = "true\n"
+	}
+	if spec.OSImageURL != "" {
+		files["osImageURL"] = spec.OSImageURL + "\n"
+	}
+	return files, nil
+}
+
+// splitLines splits the content in lines keeping their end of line, the last
+// line gets one if it is missing.
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+// writeMachineConfigDiff writes the unified diff of the files of the two
+// machine configs, file by file and sorted by path.
+func writeMachineConfigDiff(w io.Writer, first string, second string) error {
+	firstFiles, err := machineConfigFiles(first)
+	if err != nil {
+		return err
+	}
+	secondFiles, err := machineConfigFiles(second)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for path := range firstFiles {
+		paths = append(paths, path)
+	}
+	for path := range secondFiles {
+		if _, ok := firstFiles[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		firstContent, inFirst := firstFiles[path]
+		secondContent, inSecond := secondFiles[path]
+		fromFile, toFile := "a/"+first+"/"+path, "b/"+second+"/"+path
+		if !inFirst {
+			fromFile = "/dev/null"
+		}
+		if !inSecond {
+			toFile = "/dev/null"
+		}
+		if err := difflib.WriteUnifiedDiff(w, difflib.UnifiedDiff{
+			A:        splitLines(firstContent),
+			B:        splitLines(secondContent),
+			FromFile: fromFile,
+			ToFile:   toFile,
+			Context:  3,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkMachineConfigDiff(w io.Writer, first string, second string) error {
+	if vars.DiffCmd == "" || w != os.Stdout {
+		return writeMachineConfigDiff(w, first, second)
+	}
+	// the configured diff command is only used on a terminal, it can be
+	// interactive
 	firstMachineConfigPath := vars.MustGatherRootPath + "/cluster-scoped-resources/machineconfiguration.openshift.io/machineconfigs/" + first + ".yaml"
 	secondMachineConfigPath := vars.MustGatherRootPath + "/cluster-scoped-resources/machineconfiguration.openshift.io/machineconfigs/" + second + ".yaml"
-	if vars.DiffCmd == "" {
-		vars.DiffCmd = "vimdiff"
+	for _, path := range []string{firstMachineConfigPath, secondMachineConfigPath} {
+		if _, err := os.Stat(path); err != nil {
+			return err
+		}
 	}
 	_, err := exec.LookPath(vars.DiffCmd)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	cmd := exec.Command(vars.DiffCmd, firstMachineConfigPath, secondMachineConfigPath)
 	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = w
 	cmd.Stderr = os.Stderr
 
 	err = cmd.Run()
-	if err != nil {
-		log.Fatal(err)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		// diff exits with 1 when the files differ
+		return nil
 	}
-
+	return err
 }
 
 var Diff = &cobra.Command{
-	Use:     "diff",
+	Use:     "diff <machine-config> <machine-config>",
 	Aliases: []string{"compare"},
-	Run: func(cmd *cobra.Command, args []string) {
+	Short:   "Show the unified diff of the files, units, kernel arguments and OS image of two machine configs",
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) != 2 {
-			fmt.Fprintln(os.Stderr, "error: two arguments expected, found ", strconv.Itoa(len(args)))
-			os.Exit(1)
+			return fmt.Errorf("error: two arguments expected, found %d", len(args))
 		}
-		checkMachineConfigDiff(args[0], args[1])
+		return checkMachineConfigDiff(cmd.OutOrStdout(), args[0], args[1])
 	},
 }
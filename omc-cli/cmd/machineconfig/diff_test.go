@@ -0,0 +1,166 @@
+package machineconfig
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gmeghnag/omc/vars"
+)
+
+const firstMachineConfig = `apiVersion: machineconfiguration.openshift.io/v1
+kind: MachineConfig
+metadata:
+  name: 00-worker
+spec:
+  osImageURL: quay.io/openshift/os@sha256:1111
+  kernelArguments:
+  - systemd.unified_cgroup_hierarchy=1
+  config:
+    ignition:
+      version: 3.2.0
+    storage:
+      files:
+      - path: /etc/chrony.conf
+        mode: 420
+        contents:
+          source: data:,pool%202.rhel.pool.ntp.org%20iburst%0Adriftfile%20%2Fvar%2Flib%2Fchrony%2Fdrift%0A
+      - path: /etc/removed.conf
+        mode: 420
+        contents:
+          source: data:,removed%0A
+    systemd:
+      units:
+      - name: kubelet.service
+        enabled: true
+        contents: |
+          [Service]
+          ExecStart=/usr/bin/kubelet
+        dropins:
+        - name: 10-env.conf
+          contents: |
+            [Service]
+            Environment=A=1
+`
+
+func secondMachineConfig(t *testing.T) string {
+	t.Helper()
+	var compressed bytes.Buffer
+	gzipWriter := gzip.NewWriter(&compressed)
+	if _, err := gzipWriter.Write([]byte("pool 2.rhel.pool.ntp.org iburst\nmakestep 1.0 3\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return `apiVersion: machineconfiguration.openshift.io/v1
+kind: MachineConfig
+metadata:
+  name: 01-worker
+spec:
+  osImageURL: quay.io/openshift/os@sha256:2222
+  kernelArguments:
+  - systemd.unified_cgroup_hierarchy=1
+  - nosmt
+  config:
+    ignition:
+      version: 3.2.0
+    storage:
+      files:
+      - path: /etc/chrony.conf
+        mode: 420
+        contents:
+          compression: gzip
+          source: data:;base64,` + base64.StdEncoding.EncodeToString(compressed.Bytes()) + `
+      - path: /etc/added.conf
+        mode: 420
+        contents:
+          source: data:,` + url.PathEscape("added\n") + `
+    systemd:
+      units:
+      - name: kubelet.service
+        enabled: true
+        contents: |
+          [Service]
+          ExecStart=/usr/bin/kubelet
+        dropins:
+        - name: 10-env.conf
+          contents: |
+            [Service]
+            Environment=A=2
+`
+}
+
+func TestWriteMachineConfigDiff(t *testing.T) {
+	root := t.TempDir()
+	machineConfigsPath := filepath.Join(root, "cluster-scoped-resources", "machineconfiguration.openshift.io", "machineconfigs")
+	if err := os.MkdirAll(machineConfigsPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"00-worker.yaml": firstMachineConfig,
+		"01-worker.yaml": secondMachineConfig(t),
+	} {
+		if err := os.WriteFile(filepath.Join(machineConfigsPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	vars.MustGatherRootPath = root
+	defer func() { vars.MustGatherRootPath = "" }()
+
+	var output bytes.Buffer
+	if err := writeMachineConfigDiff(&output, "00-worker", "01-worker"); err != nil {
+		t.Fatal(err)
+	}
+	want := `--- a/00-worker/kernelArguments
++++ b/01-worker/kernelArguments
+@@ -1 +1,2 @@
+ systemd.unified_cgroup_hierarchy=1
++nosmt
+--- a/00-worker/osImageURL
++++ b/01-worker/osImageURL
+@@ -1 +1 @@
+-quay.io/openshift/os@sha256:1111
++quay.io/openshift/os@sha256:2222
+--- /dev/null
++++ b/01-worker/storage/files/etc/added.conf
+@@ -0,0 +1 @@
++added
+--- a/00-worker/storage/files/etc/chrony.conf
++++ b/01-worker/storage/files/etc/chrony.conf
+@@ -1,2 +1,2 @@
+ pool 2.rhel.pool.ntp.org iburst
+-driftfile /var/lib/chrony/drift
++makestep 1.0 3
+--- a/00-worker/storage/files/etc/removed.conf
++++ /dev/null
+@@ -1 +0,0 @@
+-removed
+--- a/00-worker/systemd/units/kubelet.service/dropins/10-env.conf
++++ b/01-worker/systemd/units/kubelet.service/dropins/10-env.conf
+@@ -1,2 +1,2 @@
+ [Service]
+-Environment=A=1
++Environment=A=2
+`
+	if output.String() != want {
+		t.Errorf("got diff:\n%s\nwant:\n%s", output.String(), want)
+	}
+
+	output.Reset()
+	if err := writeMachineConfigDiff(&output, "00-worker", "00-worker"); err != nil {
+		t.Fatal(err)
+	}
+	if output.Len() != 0 {
+		t.Errorf("got a diff of a machine config with itself:\n%s", output.String())
+	}
+
+	if err := writeMachineConfigDiff(&output, "00-worker", "missing"); err == nil || !strings.Contains(err.Error(), "missing.yaml") {
+		t.Errorf("got error %v for a missing machine config", err)
+	}
+}
@@ -0,0 +1,263 @@
+package machineconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gmeghnag/omc/cmd/helpers"
+	"github.com/gmeghnag/omc/vars"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+// explanation maps the files and units of a rendered machine config to the
+// source machine configs of its pool.
+type explanation struct {
+	Name    string   `json:"name"`
+	Pool    string   `json:"pool"`
+	Sources []string `json:"sources"`
+	// SelectedSources is set when the pool doesn't target the rendered
+	// config anymore, and the sources are the machine configs its selector
+	// matches now.
+	SelectedSources bool     `json:"selectedSources,omitempty"`
+	Entries         []origin `json:"entries"`
+}
+
+// origin is the provenance of a file, unit, dropin or user of the rendered
+// config.
+type origin struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+	// Source is the machine config whose content was rendered, it is empty
+	// when no source writes the same content.
+	Source string `json:"source"`
+	// WrittenBy are all the sources writing the path, in merge order.
+	WrittenBy []string `json:"writtenBy"`
+	// Conflict is set when the sources write different contents to the path.
+	Conflict bool `json:"conflict"`
+}
+
+// explainedPath returns the type and the path on the node of a path returned
+// by machineConfigFiles, it returns false for the ones that aren't written by
+// a single source, like the kernel arguments.
+func explainedPath(path string) (string, string, bool) {
+	switch {
+	case strings.HasPrefix(path, "storage/files/"):
+		return "file", strings.TrimPrefix(path, "storage/files"), true
+	case strings.HasPrefix(path, "passwd/users/"):
+		return "user", strings.TrimPrefix(path, "passwd/users/"), true
+	case strings.HasPrefix(path, "systemd/units/"):
+		unit := strings.TrimPrefix(path, "systemd/units/")
+		if strings.Contains(unit, "/dropins/") {
+			return "dropin", unit, true
+		}
+		if !strings.Contains(unit, "/") {
+			return "unit", unit, true
+		}
+	}
+	return "", "", false
+}
+
+// renderedSources returns the pool owning the rendered machine config and its
+// source machine configs sorted by name, the order they are merged in. The
+// sources come from the pool configuration targeting the rendered config, or
+// from its machine config selector when the pool moved to another one.
+func renderedSources(machineConfig *mcfgv1.MachineConfig) (string, []string, bool, error) {
+	pool := ""
+	for _, owner := range machineConfig.OwnerReferences {
+		if owner.Kind == "MachineConfigPool" {
+			pool = owner.Name
+		}
+	}
+	if pool == "" {
+		return "", nil, false, fmt.Errorf("%s is not a rendered machine config, it is not owned by a MachineConfigPool", machineConfig.Name)
+	}
+	machineConfigPoolPath := vars.MustGatherRootPath + "/cluster-scoped-resources/machineconfiguration.openshift.io/machineconfigpools/" + pool + ".yaml"
+	_file, err := os.ReadFile(machineConfigPoolPath)
+	if err != nil {
+		return "", nil, false, err
+	}
+	machineConfigPool := mcfgv1.MachineConfigPool{}
+	if err := yaml.Unmarshal(_file, &machineConfigPool); err != nil {
+		return "", nil, false, fmt.Errorf("Error when trying to unmarshal file: %s", machineConfigPoolPath)
+	}
+
+	var sources []string
+	for _, configuration := range []mcfgv1.MachineConfigPoolStatusConfiguration{machineConfigPool.Status.Configuration, machineConfigPool.Spec.Configuration} {
+		if configuration.Name == machineConfig.Name && len(configuration.Source) > 0 {
+			for _, source := range configuration.Source {
+				sources = append(sources, source.Name)
+			}
+			sort.Strings(sources)
+			return pool, sources, false, nil
+		}
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(machineConfigPool.Spec.MachineConfigSelector)
+	if err != nil {
+		return "", nil, false, fmt.Errorf("invalid machine config selector of pool %s: %w", pool, err)
+	}
+	machineConfigsPath := vars.MustGatherRootPath + "/cluster-scoped-resources/machineconfiguration.openshift.io/machineconfigs/"
+	entries, err := os.ReadDir(machineConfigsPath)
+	if err != nil {
+		return "", nil, false, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".yaml") {
+			continue
+		}
+		_file, err := os.ReadFile(machineConfigsPath + entry.Name())
+		if err != nil {
+			return "", nil, false, err
+		}
+		source := mcfgv1.MachineConfig{}
+		if err := yaml.Unmarshal(_file, &source); err != nil {
+			return "", nil, false, fmt.Errorf("Error when trying to unmarshal file: %s", machineConfigsPath+entry.Name())
+		}
+		if _, rendered := source.Annotations[ctrlcommon.GeneratedByControllerVersionAnnotationKey]; rendered && len(source.OwnerReferences) > 0 {
+			continue
+		}
+		if selector.Matches(labels.Set(source.Labels)) {
+			sources = append(sources, source.Name)
+		}
+	}
+	sort.Strings(sources)
+	return pool, sources, true, nil
+}
+
+// explainRenderedConfig maps every file, unit, dropin and user of the rendered
+// machine config to the source writing it.
+func explainRenderedConfig(name string) (*explanation, error) {
+	machineConfig, _, err := loadMachineConfig(name)
+	if err != nil {
+		return nil, err
+	}
+	pool, sources, selected, err := renderedSources(machineConfig)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := machineConfigFiles(name)
+	if err != nil {
+		return nil, err
+	}
+	sourceFiles := make([]map[string]string, len(sources))
+	for i, source := range sources {
+		if sourceFiles[i], err = machineConfigFiles(source); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &explanation{Name: name, Pool: pool, Sources: sources, SelectedSources: selected, Entries: []origin{}}
+	for path, content := range rendered {
+		entryType, entryPath, ok := explainedPath(path)
+		if !ok {
+			continue
+		}
+		entry := origin{Type: entryType, Path: entryPath, WrittenBy: []string{}}
+		var contents []string
+		for i, source := range sources {
+			sourceContent, ok := sourceFiles[i][path]
+			if !ok {
+				continue
+			}
+			entry.WrittenBy = append(entry.WrittenBy, source)
+			contents = append(contents, sourceContent)
+			if sourceContent == content {
+				// the last source wins the merge
+				entry.Source = source
+			}
+		}
+		for _, sourceContent := range contents {
+			if sourceContent != contents[0] {
+				entry.Conflict = true
+			}
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+	sort.Slice(result.Entries, func(i, j int) bool {
+		if result.Entries[i].Type != result.Entries[j].Type {
+			return result.Entries[i].Type < result.Entries[j].Type
+		}
+		return result.Entries[i].Path < result.Entries[j].Path
+	})
+	return result, nil
+}
+
+func printExplanation(w io.Writer, result *explanation, output string) error {
+	switch output {
+	case "json":
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", data)
+		return nil
+	case "yaml":
+		data, err := yaml.Marshal(result)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s", data)
+		return nil
+	case "":
+	default:
+		return fmt.Errorf("unsupported output format %q, expected one of: json|yaml", output)
+	}
+	fmt.Fprintf(w, "Rendered: %s\n", result.Name)
+	fmt.Fprintf(w, "Pool:     %s\n", result.Pool)
+	if result.SelectedSources {
+		fmt.Fprintf(w, "Sources:  %s (selected by the pool, it doesn't target %s anymore)\n\n", strings.Join(result.Sources, ", "), result.Name)
+	} else {
+		fmt.Fprintf(w, "Sources:  %s\n\n", strings.Join(result.Sources, ", "))
+	}
+	var data [][]string
+	conflicts := 0
+	for _, entry := range result.Entries {
+		source := entry.Source
+		if source == "" {
+			source = "<unknown>"
+		}
+		var others []string
+		for _, writer := range entry.WrittenBy {
+			if writer != entry.Source {
+				others = append(others, writer)
+			}
+		}
+		overrides := strings.Join(others, ",")
+		if entry.Conflict {
+			conflicts++
+			overrides += " (conflict)"
+		}
+		data = append(data, []string{entry.Type, entry.Path, source, strings.TrimSpace(overrides)})
+	}
+	helpers.FprintTable(w, []string{"TYPE", "PATH", "MACHINECONFIG", "ALSO WRITTEN BY"}, data)
+	fmt.Fprintf(w, "\n%d entries, %d conflicting\n", len(result.Entries), conflicts)
+	return nil
+}
+
+var Explain = &cobra.Command{
+	Use:   "explain <rendered-machine-config>",
+	Short: "Map the files, units and dropins of a rendered machine config to the machine configs they come from",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("error: one argument expected, found %d", len(args))
+		}
+		result, err := explainRenderedConfig(args[0])
+		if err != nil {
+			return err
+		}
+		return printExplanation(cmd.OutOrStdout(), result, vars.OutputStringVar)
+	},
+}
+
+func init() {
+	Explain.Flags().StringVarP(&vars.OutputStringVar, "output", "o", "", "Output format. One of: json|yaml")
+}
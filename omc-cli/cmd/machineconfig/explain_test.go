@@ -0,0 +1,172 @@
+package machineconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gmeghnag/omc/vars"
+)
+
+func testMachineConfig(name, role, owner, files string) string {
+	config := `apiVersion: machineconfiguration.openshift.io/v1
+kind: MachineConfig
+metadata:
+  name: ` + name + `
+  labels:
+    machineconfiguration.openshift.io/role: ` + role + `
+`
+	if owner != "" {
+		config += `  annotations:
+    machineconfiguration.openshift.io/generated-by-controller-version: 4.14.0
+  ownerReferences:
+  - apiVersion: machineconfiguration.openshift.io/v1
+    kind: MachineConfigPool
+    name: ` + owner + `
+    controller: true
+`
+	}
+	return config + `spec:
+  config:
+    ignition:
+      version: 3.2.0
+` + files
+}
+
+const testWorkerPool = `apiVersion: machineconfiguration.openshift.io/v1
+kind: MachineConfigPool
+metadata:
+  name: worker
+spec:
+  machineConfigSelector:
+    matchLabels:
+      machineconfiguration.openshift.io/role: worker
+  configuration:
+    name: rendered-worker-1
+    source:
+    - name: 99-worker-custom
+    - name: 00-worker
+status:
+  configuration:
+    name: rendered-worker-1
+    source:
+    - name: 99-worker-custom
+    - name: 00-worker
+`
+
+func TestExplainRenderedConfig(t *testing.T) {
+	root := t.TempDir()
+	machineConfigsPath := filepath.Join(root, "cluster-scoped-resources", "machineconfiguration.openshift.io")
+	for path, content := range map[string]string{
+		"machineconfigpools/worker.yaml": testWorkerPool,
+		"machineconfigs/00-worker.yaml": testMachineConfig("00-worker", "worker", "", `    storage:
+      files:
+      - path: /etc/a.conf
+        contents:
+          source: data:,a%0A
+      - path: /etc/shared.conf
+        contents:
+          source: data:,shared%0A
+    systemd:
+      units:
+      - name: kubelet.service
+        contents: "[Service]\n"
+`),
+		"machineconfigs/99-worker-custom.yaml": testMachineConfig("99-worker-custom", "worker", "", `    storage:
+      files:
+      - path: /etc/a.conf
+        contents:
+          source: data:,custom%0A
+      - path: /etc/shared.conf
+        contents:
+          source: data:,shared%0A
+      - path: /etc/b.conf
+        contents:
+          source: data:,b%0A
+`),
+		"machineconfigs/00-master.yaml": testMachineConfig("00-master", "master", "", `    storage:
+      files:
+      - path: /etc/b.conf
+        contents:
+          source: data:,master%0A
+`),
+		"machineconfigs/rendered-worker-1.yaml": testMachineConfig("rendered-worker-1", "", "worker", `    storage:
+      files:
+      - path: /etc/a.conf
+        contents:
+          source: data:,custom%0A
+      - path: /etc/shared.conf
+        contents:
+          source: data:,shared%0A
+      - path: /etc/b.conf
+        contents:
+          source: data:,b%0A
+      - path: /etc/unknown.conf
+        contents:
+          source: data:,unknown%0A
+    systemd:
+      units:
+      - name: kubelet.service
+        contents: "[Service]\n"
+`),
+		"machineconfigs/rendered-worker-0.yaml": testMachineConfig("rendered-worker-0", "", "worker", `    storage:
+      files:
+      - path: /etc/b.conf
+        contents:
+          source: data:,b%0A
+`),
+	} {
+		path = filepath.Join(machineConfigsPath, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	vars.MustGatherRootPath = root
+	defer func() { vars.MustGatherRootPath = "" }()
+
+	got, err := explainRenderedConfig("rendered-worker-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &explanation{
+		Name:    "rendered-worker-1",
+		Pool:    "worker",
+		Sources: []string{"00-worker", "99-worker-custom"},
+		Entries: []origin{
+			{Type: "file", Path: "/etc/a.conf", Source: "99-worker-custom", WrittenBy: []string{"00-worker", "99-worker-custom"}, Conflict: true},
+			{Type: "file", Path: "/etc/b.conf", Source: "99-worker-custom", WrittenBy: []string{"99-worker-custom"}},
+			{Type: "file", Path: "/etc/shared.conf", Source: "99-worker-custom", WrittenBy: []string{"00-worker", "99-worker-custom"}},
+			{Type: "file", Path: "/etc/unknown.conf", WrittenBy: []string{}},
+			{Type: "unit", Path: "kubelet.service", Source: "00-worker", WrittenBy: []string{"00-worker"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// the pool moved to rendered-worker-1, the sources are selected
+	got, err = explainRenderedConfig("rendered-worker-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = &explanation{
+		Name:            "rendered-worker-0",
+		Pool:            "worker",
+		Sources:         []string{"00-worker", "99-worker-custom"},
+		SelectedSources: true,
+		Entries: []origin{
+			{Type: "file", Path: "/etc/b.conf", Source: "99-worker-custom", WrittenBy: []string{"99-worker-custom"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if _, err := explainRenderedConfig("00-worker"); err == nil || err.Error() != "00-worker is not a rendered machine config, it is not owned by a MachineConfigPool" {
+		t.Errorf("got error %v for a source machine config", err)
+	}
+}
@@ -21,7 +21,7 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strconv"
+	"path/filepath"
 	"strings"
 
 	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
@@ -33,98 +33,169 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
-func extractIgnitionConfigStorage(ignConfig ign3types.Config, extractedMachineConfigPath string) {
-	ignitionStorageFilesPath := extractedMachineConfigPath + "/storage/files"
-	for _, f := range ignConfig.Storage.Files {
-		lastInd := strings.LastIndex(f.Path, "/")
-		ignitionReferencedFilePath := f.Path[:lastInd]
-		_ = os.MkdirAll(ignitionStorageFilesPath+ignitionReferencedFilePath, os.ModePerm)
-		if f.Contents.Source != nil {
-			contents, err := dataurl.DecodeString(*f.Contents.Source)
-			if err != nil {
-				fmt.Fprintln(os.Stderr, err)
-			}
-			if f.Contents.Compression != nil && *f.Contents.Compression == "gzip" {
-				reader := bytes.NewReader([]byte(contents.Data))
-				gzreader, e1 := gzip.NewReader(reader)
-				if e1 != nil {
-					fmt.Fprintln(os.Stderr, e1)
-				}
+// loadMachineConfig reads the machine config from the must-gather and parses
+// its ignition config.
+func loadMachineConfig(name string) (*mcfgv1.MachineConfig, ign3types.Config, error) {
+	if !isName(name) {
+		return nil, ign3types.Config{}, fmt.Errorf("invalid machine config name %q", name)
+	}
+	machineconfigYamlPath := filepath.Join(vars.MustGatherRootPath, "cluster-scoped-resources", "machineconfiguration.openshift.io", "machineconfigs", name+".yaml")
+	_file, err := os.ReadFile(machineconfigYamlPath)
+	if err != nil {
+		return nil, ign3types.Config{}, err
+	}
+	machineConfig := &mcfgv1.MachineConfig{}
+	if err := yaml.Unmarshal([]byte(_file), machineConfig); err != nil {
+		return nil, ign3types.Config{}, fmt.Errorf("Error when trying to unmarshal file: %s", machineconfigYamlPath)
+	}
+	ignConfig, err := ctrlcommon.ParseAndConvertConfig(machineConfig.Spec.Config.Raw)
+	if err != nil {
+		return nil, ign3types.Config{}, err
+	}
+	return machineConfig, ignConfig, nil
+}
 
-				output, e2 := io.ReadAll(gzreader)
-				if e2 != nil {
-					fmt.Fprintln(os.Stderr, e2)
-				}
+// decodeFileContents decodes the data URL of an ignition file, and
+// decompresses it when it is gzipped.
+func decodeFileContents(f ign3types.File) ([]byte, error) {
+	if f.Contents.Source == nil {
+		return nil, nil
+	}
+	contents, err := dataurl.DecodeString(*f.Contents.Source)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the contents of %s: %w", f.Path, err)
+	}
+	if f.Contents.Compression != nil && *f.Contents.Compression == "gzip" {
+		gzreader, err := gzip.NewReader(bytes.NewReader(contents.Data))
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing the contents of %s: %w", f.Path, err)
+		}
+		defer gzreader.Close()
+		output, err := io.ReadAll(gzreader)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing the contents of %s: %w", f.Path, err)
+		}
+		return output, nil
+	}
+	return contents.Data, nil
+}
 
-				result := string(output)
-				fmt.Println(ignitionStorageFilesPath + f.Path)
-				os.WriteFile(ignitionStorageFilesPath+f.Path, []byte(result), 0644)
-			} else {
-				fmt.Println(ignitionStorageFilesPath + f.Path)
-				os.WriteFile(ignitionStorageFilesPath+f.Path, contents.Data, 0644)
-			}
+// isName tells whether name can be used as a single path element.
+func isName(name string) bool {
+	return filepath.IsLocal(name) && filepath.Base(name) == name
+}
+
+// localPath returns the path of name in dir, name being relative to dir or
+// absolute like the ignition paths, or an error when the path would be outside
+// of dir.
+func localPath(dir string, name string) (string, error) {
+	relative := filepath.FromSlash(strings.TrimLeft(name, "/"))
+	if !filepath.IsLocal(relative) {
+		return "", fmt.Errorf("path %q is outside of %s", name, dir)
+	}
+	return filepath.Join(dir, relative), nil
+}
+
+// writeExtractedFile writes contents at name in dir, creating its parent
+// directories, and prints its path.
+func writeExtractedFile(w io.Writer, dir string, name string, contents []byte) error {
+	path, err := localPath(dir, name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, contents, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, path)
+	return nil
+}
+
+// extractIgnitionConfigStorage writes the files, users and systemd units of
+// ignConfig in extractedMachineConfigPath. The entries whose path would be
+// outside of it are skipped with an error on stderr.
+func extractIgnitionConfigStorage(w io.Writer, ignConfig ign3types.Config, extractedMachineConfigPath string) {
+	ignitionStorageFilesPath := filepath.Join(extractedMachineConfigPath, "storage", "files")
+	for _, f := range ignConfig.Storage.Files {
+		if f.Contents.Source == nil {
+			continue
+		}
+		contents, err := decodeFileContents(f)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		if err := writeExtractedFile(w, ignitionStorageFilesPath, f.Path, contents); err != nil {
+			fmt.Fprintln(os.Stderr, err)
 		}
 	}
-	ignitionPasswdFilesPath := extractedMachineConfigPath + "/passwd/"
+	ignitionUsersPath := filepath.Join(extractedMachineConfigPath, "passwd", "users")
 	for _, f := range ignConfig.Passwd.Users {
-		_ = os.MkdirAll(ignitionPasswdFilesPath+"users/", os.ModePerm)
+		if !isName(f.Name) {
+			fmt.Fprintf(os.Stderr, "invalid user name %q\n", f.Name)
+			continue
+		}
 		keys := ""
 		for _, key := range f.SSHAuthorizedKeys {
 			keys = keys + string(key) + "\n"
 		}
-		os.WriteFile(ignitionPasswdFilesPath+"users/"+f.Name, []byte(keys), 0644)
-		fmt.Println(ignitionPasswdFilesPath + "users/" + f.Name)
+		if err := writeExtractedFile(w, ignitionUsersPath, f.Name, []byte(keys)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
 	}
-	ignitionSystemdPath := extractedMachineConfigPath + "/systemd/units/"
+	ignitionSystemdPath := filepath.Join(extractedMachineConfigPath, "systemd", "units")
 	for _, f := range ignConfig.Systemd.Units {
+		if !isName(f.Name) {
+			fmt.Fprintf(os.Stderr, "invalid systemd unit name %q\n", f.Name)
+			continue
+		}
 		if len(f.Dropins) > 0 {
-			_ = os.MkdirAll(ignitionSystemdPath+f.Name+"/dropins", os.ModePerm)
+			dropinsPath := filepath.Join(ignitionSystemdPath, f.Name, "dropins")
 			for _, dropin := range f.Dropins {
+				if !isName(dropin.Name) {
+					fmt.Fprintf(os.Stderr, "invalid systemd dropin name %q\n", dropin.Name)
+					continue
+				}
 				var content []byte
 				if dropin.Contents != nil {
 					content = []byte(*dropin.Contents)
 				}
-				os.WriteFile(ignitionSystemdPath+f.Name+"/dropins/"+dropin.Name, content, 0644)
-				fmt.Println(ignitionSystemdPath + f.Name + "/dropins/" + dropin.Name)
+				if err := writeExtractedFile(w, dropinsPath, dropin.Name, content); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
 			}
 		} else {
-			fmt.Println(ignitionSystemdPath + f.Name)
 			var content []byte
 			if f.Contents != nil {
 				content = []byte(*f.Contents)
 			}
-			os.WriteFile(ignitionSystemdPath+f.Name, content, 0644)
+			if err := writeExtractedFile(w, ignitionSystemdPath, f.Name, content); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
 		}
-
-		//keys := ""
-		//for _, key := range f.SSHAuthorizedKeys {
-		//	keys = keys + string(key) + "\n"
-		//}
-		//os.WriteFile(ignitionPasswdFilesPath+"users/"+f.Name, []byte(keys), 0644)
-		//fmt.Println(ignitionPasswdFilesPath + "users/" + f.Name)
 	}
 }
 
 var Extract = &cobra.Command{
 	Use: "extract",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) != 1 {
-			fmt.Fprintln(os.Stderr, "error: one argument expected, found ", strconv.Itoa(len(args)))
-			os.Exit(1)
+			return fmt.Errorf("error: one argument expected, found %d", len(args))
 		}
-		machineconfigYamlPath := vars.MustGatherRootPath + "/cluster-scoped-resources/machineconfiguration.openshift.io/machineconfigs/" + args[0] + ".yaml"
-		_file, err := os.ReadFile(machineconfigYamlPath)
+		machineConfig, ignConfig, err := loadMachineConfig(args[0])
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
+			return err
+		}
+		if !isName(machineConfig.Name) {
+			return fmt.Errorf("invalid machine config name %q", machineConfig.Name)
 		}
-		machineConfig := mcfgv1.MachineConfig{}
-		if err := yaml.Unmarshal([]byte(_file), &machineConfig); err != nil {
-			fmt.Fprintln(os.Stderr, "Error when trying to unmarshal file: "+machineconfigYamlPath)
-			os.Exit(1)
+		extractedMachineConfigPath := filepath.Join(vars.MustGatherRootPath, "extracted-machine-configs", machineConfig.Name)
+		if err := os.MkdirAll(extractedMachineConfigPath, os.ModePerm); err != nil {
+			return err
 		}
-		ignConfig, err := ctrlcommon.ParseAndConvertConfig(machineConfig.Spec.Config.Raw)
-		extractedMachineConfigPath := vars.MustGatherRootPath + "/extracted-machine-configs/" + machineConfig.Name
-		_ = os.Mkdir(extractedMachineConfigPath, os.ModePerm)
-		extractIgnitionConfigStorage(ignConfig, extractedMachineConfigPath)
+		extractIgnitionConfigStorage(cmd.OutOrStdout(), ignConfig, extractedMachineConfigPath)
+		return nil
 	},
 }
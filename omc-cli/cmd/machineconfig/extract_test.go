@@ -0,0 +1,105 @@
+package machineconfig
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gmeghnag/omc/vars"
+)
+
+const escapingMachineConfig = `apiVersion: machineconfiguration.openshift.io/v1
+kind: MachineConfig
+metadata:
+  name: 99-escaping
+spec:
+  config:
+    ignition:
+      version: 3.2.0
+    passwd:
+      users:
+      - name: ../../../../escaped-user
+        sshAuthorizedKeys:
+        - ssh-rsa AAAA
+      - name: core
+        sshAuthorizedKeys:
+        - ssh-rsa BBBB
+    storage:
+      files:
+      - path: /etc/chrony.conf
+        contents:
+          source: data:,pool%0A
+    systemd:
+      units:
+      - name: ../../escaped.service
+        contents: escaped
+      - name: kubelet.service
+        dropins:
+        - name: ../../../../../escaped.conf
+          contents: escaped
+        - name: 10-env.conf
+          contents: kept
+`
+
+func TestExtractKeepsFilesInTheExtractionDirectory(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "must-gather")
+	machineConfigsPath := filepath.Join(root, "cluster-scoped-resources", "machineconfiguration.openshift.io", "machineconfigs")
+	if err := os.MkdirAll(machineConfigsPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(machineConfigsPath, "99-escaping.yaml"), []byte(escapingMachineConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	vars.MustGatherRootPath = root
+	defer func() { vars.MustGatherRootPath = "" }()
+
+	var output bytes.Buffer
+	Extract.SetOut(&output)
+	if err := Extract.RunE(Extract, []string{"99-escaping"}); err != nil {
+		t.Fatal(err)
+	}
+	extracted := filepath.Join(root, "extracted-machine-configs", "99-escaping")
+	want := []string{
+		filepath.Join(extracted, "storage", "files", "etc", "chrony.conf"),
+		filepath.Join(extracted, "passwd", "users", "core"),
+		filepath.Join(extracted, "systemd", "units", "kubelet.service", "dropins", "10-env.conf"),
+	}
+	if got := strings.Fields(output.String()); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got extracted files %v, want %v", got, want)
+	}
+	err := filepath.Walk(parent, func(path string, info os.FileInfo, err error) error {
+		if err == nil && strings.Contains(info.Name(), "escaped") {
+			t.Errorf("file %s written outside of the extraction directory", path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"../99-escaping", "../../etc/passwd", "/etc/passwd"} {
+		if err := Extract.RunE(Extract, []string{name}); err == nil || !strings.Contains(err.Error(), "invalid machine config name") {
+			t.Errorf("got error %v extracting %q", err, name)
+		}
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	for name, want := range map[string]string{
+		"/etc/chrony.conf": filepath.Join("dir", "etc", "chrony.conf"),
+		"core":             filepath.Join("dir", "core"),
+		"/../escaped":      "",
+		"a/../../escaped":  "",
+		"..":               "",
+	} {
+		got, err := localPath("dir", name)
+		if want == "" && err == nil {
+			t.Errorf("got path %s for %q, want an error", got, name)
+		} else if want != "" && (err != nil || got != want) {
+			t.Errorf("got path %s and error %v for %q, want %s", got, err, name, want)
+		}
+	}
+}
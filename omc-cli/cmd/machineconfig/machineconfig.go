@@ -16,23 +16,21 @@ limitations under the License.
 package machineconfig
 
 import (
-	"os"
-
 	"github.com/spf13/cobra"
 )
 
 var MachineConfig = &cobra.Command{
 	Use:     "machine-config",
 	Aliases: []string{"mc"},
-	Run: func(cmd *cobra.Command, args []string) {
-		cmd.Help()
-		os.Exit(0)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
 	},
 }
 
 func init() {
 	MachineConfig.AddCommand(
 		Diff,
+		Explain,
 		Extract,
 	)
 }
@@ -2,10 +2,8 @@ package cmd
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io/ioutil"
-	"os"
-	"reflect"
 
 	"github.com/gmeghnag/omc/cmd/helpers"
 	"github.com/gmeghnag/omc/types"
@@ -19,9 +17,8 @@ var MustGather = &cobra.Command{
 	Use:     "mg",
 	Aliases: []string{"must-gather", "must-gathers"},
 	Short:   "List or delete previously used must-gathers.",
-	Run: func(cmd *cobra.Command, args []string) {
-		cmd.Help()
-		os.Exit(0)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
 	},
 }
 
@@ -29,29 +26,33 @@ var GetMustGather = &cobra.Command{
 	Use:     "get",
 	Aliases: []string{"ls", "list"},
 	Short:   "List must-gathers saved in omc config file.",
-	Run: func(cmd *cobra.Command, args []string) {
-		file, _ := ioutil.ReadFile(viper.ConfigFileUsed())
-		omcConfigJson := types.Config{}
-		_ = json.Unmarshal([]byte(file), &omcConfigJson)
-
-		var data [][]string
-		var emptyData [][]string
-		headers := []string{"current", "id", "path", "namespace"}
-		var mg []types.Context
-		mg = omcConfigJson.Contexts
-		for _, context := range mg {
-			_list := []string{context.Current, context.Id, context.Path, context.Project}
-			data = append(data, _list)
-		}
-		if reflect.DeepEqual(data, emptyData) {
-			fmt.Fprintln(os.Stderr, "There are no must-gather resources defined.")
-			os.Exit(1)
-		} else {
-			helpers.PrintTable(headers, data)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data := MustGatherRows(viper.ConfigFileUsed())
+		if len(data) == 0 {
+			return errors.New("there are no must-gather resources defined")
 		}
+		helpers.FprintTable(cmd.OutOrStdout(), MustGatherHeaders, data)
+		return nil
 	},
 }
 
+// MustGatherHeaders are the columns of the must-gathers table.
+var MustGatherHeaders = []string{"current", "id", "path", "namespace"}
+
+// MustGatherRows returns the rows of the must-gathers table of the omc config
+// file at configFile.
+func MustGatherRows(configFile string) [][]string {
+	file, _ := ioutil.ReadFile(configFile)
+	omcConfigJson := types.Config{}
+	_ = json.Unmarshal([]byte(file), &omcConfigJson)
+
+	var data [][]string
+	for _, context := range omcConfigJson.Contexts {
+		data = append(data, []string{context.Current, context.Id, context.Path, context.Project})
+	}
+	return data
+}
+
 func init() {
 
 	MustGather.AddCommand(
@@ -2,37 +2,146 @@ package nodelogs
 
 import (
 	"fmt"
-	"os"
+	"io"
+	"sort"
 	"strings"
+	"text/tabwriter"
 
+	"github.com/gmeghnag/omc/cmd/logs"
 	"github.com/gmeghnag/omc/vars"
 	"github.com/spf13/cobra"
 )
 
+var sinceTime, untilTime, since, node, role string
+
 var NodeLogs = &cobra.Command{
 	Use:   "node-logs",
 	Short: "Display and filter node logs.",
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Display and filter node logs.
+The logs are read from host_service_logs/<role>s/<service>_service.log, which holds the lines of all
+the nodes of a role, and from the nodes/<node> directories of the must-gather, which hold the logs
+of a single node. The lines of a node are read from the log of its role when its own log wasn't
+collected, the node being known from its manifest or from its directory.`,
+	Example: `  omc node-logs
+  omc node-logs kubelet --role worker
+  omc node-logs crio --node master-0 --since 1h`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if len(args) > 1 {
+			return fmt.Errorf("Expect zero arguemnt, found: %d", len(args))
+		}
+		if role != "" && role != "master" && role != "worker" {
+			return fmt.Errorf("invalid --role %q, expected one of: master|worker", role)
+		}
+		if node != "" && !logs.NodeExists(vars.MustGatherRootPath, node) {
+			return fmt.Errorf("node \"%s\" not found", node)
+		}
+		files := logs.NodeLogFiles(vars.MustGatherRootPath)
+		roles := logs.NodeRoles(vars.MustGatherRootPath)
 		if len(args) == 0 {
-			fmt.Println("The following node service logs are available to be displayed:")
-			fmt.Println("")
-			files, _ := os.ReadDir(vars.MustGatherRootPath + "/host_service_logs/masters/")
-			for _, f := range files {
-				fmt.Println("-", strings.TrimSuffix(f.Name(), "_service.log"))
+			fmt.Fprintln(out, "The following node service logs are available to be displayed:")
+			fmt.Fprintln(out, "")
+			printAvailability(out, files, roles)
+			fmt.Fprintln(out, "\nExecuting 'omc node-logs <SERVICE> [--node <NODE> | --role <ROLE>]' will display the logs.")
+			return nil
+		}
+		window, err := logs.NewTimeWindow(vars.MustGatherRootPath, sinceTime, untilTime, since)
+		if err != nil {
+			return err
+		}
+		selectedRole := role
+		if node != "" && selectedRole == "" {
+			selectedRole = roles[node]
+		}
+		selected := logs.SelectNodeLogFiles(files, args[0], node, selectedRole)
+		if len(selected) == 0 {
+			if node != "" {
+				return fmt.Errorf("logs for service \"%s\" of node \"%s\" not found or readable.", args[0], node)
 			}
-			fmt.Println("\nExecuting 'omc node-logs <SERVICE>' will display the logs.")
+			return fmt.Errorf("logs for service \"%s\" not found or readable.", args[0])
 		}
-		if len(args) > 1 {
-			fmt.Fprintln(os.Stderr, "Expect zero arguemnt, found: ", len(args))
-			os.Exit(1)
-		}
-		if len(args) == 1 {
-			text, err := os.ReadFile(vars.MustGatherRootPath + "/host_service_logs/masters/" + args[0] + "_service.log")
-			if err != nil {
-				fmt.Fprintln(os.Stderr, "logs for service \""+args[0]+"\" not found or readable.")
-				os.Exit(1)
+		for _, file := range selected {
+			reader := logs.NewNodeLogReader(file, node, window)
+			reader.WithContext(cmd.Context())
+			if err := reader.Read(out); err != nil {
+				return fmt.Errorf("logs for service \"%s\" not readable: %w", args[0], err)
 			}
-			fmt.Print(string(text))
 		}
+		return nil
 	},
 }
+
+// printAvailability prints which service logs are available for which node,
+// the nodes being the ones of --node and --role when they are set. The logs of
+// the roles without known node are listed in a column of their role.
+func printAvailability(w io.Writer, files []logs.NodeLogFile, roles map[string]string) {
+	for _, file := range files {
+		if _, ok := roles[file.Node]; file.Node != "" && !ok {
+			roles[file.Node] = file.Role
+		}
+	}
+	var columns []string
+	for name, nodeRole := range roles {
+		if (node == "" || name == node) && (role == "" || nodeRole == role) {
+			columns = append(columns, name)
+		}
+	}
+	sort.Strings(columns)
+	// the logs of the roles without known node get their own column
+	roleColumns := map[string]bool{}
+	if node == "" {
+		for _, file := range files {
+			covered := false
+			for _, column := range columns {
+				covered = covered || roles[column] == file.Role
+			}
+			if file.Node == "" && (role == "" || file.Role == role) && !covered && !roleColumns[file.Role+"s"] {
+				roleColumns[file.Role+"s"] = true
+				columns = append(columns, file.Role+"s")
+			}
+		}
+	}
+	available := map[string]map[string]string{}
+	var services []string
+	for _, file := range files {
+		if _, ok := available[file.Service]; !ok {
+			available[file.Service] = map[string]string{}
+			services = append(services, file.Service)
+		}
+		for _, column := range columns {
+			switch {
+			case roleColumns[column]:
+				if file.Node == "" && column == file.Role+"s" {
+					available[file.Service][column] = "yes"
+				}
+			case file.Node == column:
+				available[file.Service][column] = "yes"
+			case file.Node == "" && roles[column] == file.Role && available[file.Service][column] == "":
+				available[file.Service][column] = "in " + file.Role + "s log"
+			}
+		}
+	}
+	// the node names are the headers, they aren't upper cased
+	writer := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(append([]string{"SERVICE"}, columns...), "\t"))
+	for _, service := range services {
+		row := []string{service}
+		for _, column := range columns {
+			cell := available[service][column]
+			if cell == "" {
+				cell = "-"
+			}
+			row = append(row, cell)
+		}
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	writer.Flush()
+}
+
+func init() {
+	NodeLogs.Flags().StringVar(&sinceTime, "since-time", "", "Only return logs after a specific date (RFC3339).")
+	NodeLogs.Flags().StringVar(&untilTime, "until-time", "", "Only return logs before a specific date (RFC3339).")
+	NodeLogs.Flags().StringVar(&since, "since", "", "Only return logs newer than a relative duration like 5s, 2m, or 3h, counted back from the time the must-gather was collected.")
+	NodeLogs.Flags().StringVar(&node, "node", "", "Only return the logs of this node.")
+	NodeLogs.Flags().StringVar(&role, "role", "", "Only return the logs of the nodes of this role. One of: master|worker")
+}
@@ -16,17 +16,14 @@ limitations under the License.
 package ovn
 
 import (
-	"os"
-
 	"github.com/spf13/cobra"
 )
 
 // alertCmd represents the alert command
 var OvnCmd = &cobra.Command{
 	Use: "ovn",
-	Run: func(cmd *cobra.Command, args []string) {
-		cmd.Help()
-		os.Exit(0)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
 	},
 }
 
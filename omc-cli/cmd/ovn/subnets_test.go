@@ -0,0 +1,74 @@
+package ovn
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeNode writes a node manifest with the annotations to the must-gather in
+// root.
+func writeNode(t *testing.T, root string, name string, annotations string) {
+	t.Helper()
+	dir := filepath.Join(root, "cluster-scoped-resources", "core", "nodes")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	node := "apiVersion: v1\nkind: Node\nmetadata:\n  name: " + name + "\n  labels:\n    node-role.kubernetes.io/worker: \"\"\n  annotations:\n" + annotations
+	if err := os.WriteFile(filepath.Join(dir, name+".yaml"), []byte(node), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSubnets(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations string
+		headers     []string
+		data        [][]string
+		wantErr     string
+	}{
+		{
+			name:        "valid annotations",
+			annotations: "    k8s.ovn.org/host-cidrs: '[\"10.0.0.5/24\"]'\n    k8s.ovn.org/node-subnets: '{\"default\":[\"10.128.2.0/23\"]}'\n",
+			headers:     []string{"HOST/NODE", "ROLE", "HOST IP-ADDRESSES", "NODE SUBNET"},
+			data:        [][]string{{"worker-0", "worker", "10.0.0.5/24", "10.128.2.0/23"}},
+		},
+		{
+			name:        "invalid host CIDRs",
+			annotations: "    k8s.ovn.org/host-cidrs: 'not a list'\n",
+			wantErr:     "invalid k8s.ovn.org/host-cidrs annotation of node worker-0",
+		},
+		{
+			name:        "invalid gateway config",
+			annotations: "    k8s.ovn.org/l3-gateway-config: '[]'\n",
+			wantErr:     "invalid k8s.ovn.org/l3-gateway-config annotation of node worker-0",
+		},
+		{
+			name:        "invalid node subnets",
+			annotations: "    k8s.ovn.org/node-subnets: '[\"10.128.2.0/23\"]'\n",
+			wantErr:     "invalid k8s.ovn.org/node-subnets annotation of node worker-0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeNode(t, root, "worker-0", tt.annotations)
+			headers, data, err := Subnets(root, false)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(headers, tt.headers) || !reflect.DeepEqual(data, tt.data) {
+				t.Errorf("got %v %v, want %v %v", headers, data, tt.headers, tt.data)
+			}
+		})
+	}
+}
@@ -74,24 +74,23 @@ func projectsDefault(omcConfigFile string, projDefault string) {
 var ProjectsCmd = &cobra.Command{
 	Use:   "projects",
 	Short: "List all available projects",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) != 0 {
-			fmt.Fprintln(os.Stderr, "Expect zero arguemnt, found: ", len(args))
-			os.Exit(1)
+			return fmt.Errorf("Expect zero arguemnt, found: %d", len(args))
 		}
-		if len(args) == 0 {
-			fmt.Println("You have access to the following projects and can switch between them with ' project <projectname>':")
-			fmt.Println("")
-			_namespaces, _ := ioutil.ReadDir(vars.MustGatherRootPath + "/namespaces/")
-			for _, f := range _namespaces {
-				if f.Name() == vars.Namespace {
-					fmt.Println("  * ", f.Name())
-				} else {
-					fmt.Println("    ", f.Name())
-				}
+		out := cmd.OutOrStdout()
+		fmt.Fprintln(out, "You have access to the following projects and can switch between them with ' project <projectname>':")
+		fmt.Fprintln(out, "")
+		_namespaces, _ := ioutil.ReadDir(vars.MustGatherRootPath + "/namespaces/")
+		for _, f := range _namespaces {
+			if f.Name() == vars.Namespace {
+				fmt.Fprintln(out, "  * ", f.Name())
+			} else {
+				fmt.Fprintln(out, "    ", f.Name())
 			}
-			fmt.Println("")
-			fmt.Println("Using project \"" + vars.Namespace + "\" on must-gather \"" + vars.MustGatherRootPath + "\".")
 		}
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "Using project \""+vars.Namespace+"\" on must-gather \""+vars.MustGatherRootPath+"\".")
+		return nil
 	},
 }
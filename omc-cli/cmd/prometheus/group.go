@@ -17,7 +17,9 @@ package prometheus
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -29,15 +31,14 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
-func GetAlertGroups(resourcesNames []string, outputFlag string, groupFile string, alertsFilePath string) {
+func GetAlertGroups(w io.Writer, resourcesNames []string, outputFlag string, groupFile string, alertsFilePath string) error {
 	_headers := []string{"group", "filename", "age"}
 	var data [][]string
 	var filteredGroups []RuleGroup
 	var _Alerts alerts
 	_file, _ := os.ReadFile(alertsFilePath)
 	if err := yaml.Unmarshal([]byte(_file), &_Alerts); err != nil {
-		fmt.Fprintln(os.Stderr, "Error when trying to unmarshal file "+alertsFilePath)
-		os.Exit(1)
+		return errors.New("Error when trying to unmarshal file " + alertsFilePath)
 	}
 
 	for _, group := range _Alerts.Data.Groups {
@@ -69,34 +70,33 @@ func GetAlertGroups(resourcesNames []string, outputFlag string, groupFile string
 	if outputFlag == "" || outputFlag == "wide" {
 		headers = _headers[0:3]
 		if len(data) == 0 {
-			fmt.Println("No alertgroups found.")
+			fmt.Fprintln(w, "No alertgroups found.")
 		} else {
-			helpers.PrintTable(headers, data)
+			helpers.FprintTable(w, headers, data)
 		}
 	}
 	if outputFlag == "yaml" {
 		_Alerts.Data.Groups = filteredGroups
 		y, _ := yaml.Marshal(_Alerts)
-		fmt.Println(string(y))
+		fmt.Fprintln(w, string(y))
 	}
 	if outputFlag == "json" {
 		_Alerts.Data.Groups = filteredGroups
 		j, _ := json.Marshal(_Alerts)
-		fmt.Println(string(j))
+		fmt.Fprintln(w, string(j))
 	}
-
+	return nil
 }
 
 var GroupSubCmd = &cobra.Command{
 	Use:     "alertgroup",
 	Aliases: []string{"alertgroups", "group", "groups"},
 	Short:   "Retrieve the alerting rules' groups configured in Prometheus.",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		resourcesNames := args
 		monitoringExist, _ := helpers.Exists(vars.MustGatherRootPath + "/monitoring")
 		if !monitoringExist {
-			fmt.Fprintln(os.Stderr, "Path '"+vars.MustGatherRootPath+"/monitoring' does not exist.")
-			os.Exit(1)
+			return errors.New("Path '" + vars.MustGatherRootPath + "/monitoring' does not exist.")
 		}
 		alertsFilePath := vars.MustGatherRootPath + "/monitoring/alerts.json"
 		alertsFilePathExist, _ := helpers.Exists(alertsFilePath)
@@ -104,11 +104,10 @@ var GroupSubCmd = &cobra.Command{
 			alertsFilePath = vars.MustGatherRootPath + "/monitoring/prometheus/rules.json"
 			alertsFilePathExist, _ := helpers.Exists(alertsFilePath)
 			if !alertsFilePathExist {
-				fmt.Fprintln(os.Stderr, "Prometheus rules not found in must-gather.")
-				os.Exit(1)
+				return errors.New("Prometheus rules not found in must-gather.")
 			}
 		}
-		GetAlertGroups(resourcesNames, vars.OutputStringVar, GroupFilename, alertsFilePath)
+		return GetAlertGroups(cmd.OutOrStdout(), resourcesNames, vars.OutputStringVar, GroupFilename, alertsFilePath)
 	},
 }
 
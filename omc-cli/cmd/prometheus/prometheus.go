@@ -26,9 +26,8 @@ import (
 var PrometheusCmd = &cobra.Command{
 	Use:     "prometheus",
 	Aliases: []string{"prom"},
-	Run: func(cmd *cobra.Command, args []string) {
-		cmd.Help()
-		os.Exit(0)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
 	},
 }
 
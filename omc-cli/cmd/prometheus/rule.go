@@ -17,7 +17,9 @@ package prometheus
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"strconv"
@@ -31,7 +33,7 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
-func GetAlertRules(resourcesNames []string, outputFlag string, groupsNames string, rulesStates string, alertsFilePath string) {
+func GetAlertRules(w io.Writer, resourcesNames []string, outputFlag string, groupsNames string, rulesStates string, alertsFilePath string) error {
 	_headers := []string{"group", "rule", "severity", "state", "age", "alerts", "active since"}
 	var data [][]string
 	var filteredRules []Rule
@@ -39,8 +41,7 @@ func GetAlertRules(resourcesNames []string, outputFlag string, groupsNames strin
 	var _Alerts alerts
 	_file, _ := ioutil.ReadFile(alertsFilePath)
 	if err := yaml.Unmarshal([]byte(_file), &_Alerts); err != nil {
-		fmt.Fprintln(os.Stderr, "Error when trying to unmarshal file "+alertsFilePath)
-		os.Exit(1)
+		return errors.New("Error when trying to unmarshal file " + alertsFilePath)
 	}
 	searchingGroups := []string{}
 	if groupsNames != "" {
@@ -83,8 +84,7 @@ func GetAlertRules(resourcesNames []string, outputFlag string, groupsNames strin
 			alertsList := []PromAlert{}
 			b, err := json.Marshal(alerts)
 			if err != nil {
-				fmt.Fprintln(os.Stderr, err)
-				os.Exit(1)
+				return err
 			}
 			json.Unmarshal(b, &alertsList)
 			numAlerts := strconv.Itoa(len(alertsList))
@@ -123,41 +123,41 @@ func GetAlertRules(resourcesNames []string, outputFlag string, groupsNames strin
 	if outputFlag == "" {
 		headers = _headers[1:]
 		if len(data) == 0 {
-			fmt.Println("No resources found.")
+			fmt.Fprintln(w, "No resources found.")
 		} else {
-			helpers.PrintTable(headers, data)
+			helpers.FprintTable(w, headers, data)
 		}
 	}
 	if outputFlag == "wide" {
 		headers = _headers[0:]
 		if len(data) == 0 {
-			fmt.Println("No resources found.")
+			fmt.Fprintln(w, "No resources found.")
 		} else {
-			helpers.PrintTable(headers, data)
+			helpers.FprintTable(w, headers, data)
 		}
 	}
 	if outputFlag == "yaml" {
 		filteredRulesList.Data = filteredRules
 		y, _ := yaml.Marshal(filteredRulesList)
-		fmt.Println(string(y))
+		fmt.Fprintln(w, string(y))
 	}
 	if outputFlag == "json" {
 		filteredRulesList.Data = filteredRules
 		j, _ := json.Marshal(filteredRulesList)
-		fmt.Println(string(j))
+		fmt.Fprintln(w, string(j))
 	}
+	return nil
 }
 
 var RuleSubCmd = &cobra.Command{
 	Use:     "alertrule",
 	Aliases: []string{"rule", "rules", "alertrules"},
 	Short:   "Retrieve the alerting rules (and their status) configured in Prometheus.",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		resourcesNames := args
 		monitoringExist, _ := helpers.Exists(vars.MustGatherRootPath + "/monitoring")
 		if !monitoringExist {
-			fmt.Fprintln(os.Stderr, "Path '"+vars.MustGatherRootPath+"/monitoring' does not exist.")
-			os.Exit(1)
+			return errors.New("Path '" + vars.MustGatherRootPath + "/monitoring' does not exist.")
 		}
 		alertsFilePath := vars.MustGatherRootPath + "/monitoring/alerts.json"
 		alertsFilePathExist, _ := helpers.Exists(alertsFilePath)
@@ -165,11 +165,10 @@ var RuleSubCmd = &cobra.Command{
 			alertsFilePath = vars.MustGatherRootPath + "/monitoring/prometheus/rules.json"
 			alertsFilePathExist, _ := helpers.Exists(alertsFilePath)
 			if !alertsFilePathExist {
-				fmt.Fprintln(os.Stderr, "Prometheus rules not found in must-gather.")
-				os.Exit(1)
+				return errors.New("Prometheus rules not found in must-gather.")
 			}
 		}
-		GetAlertRules(resourcesNames, vars.OutputStringVar, GroupName, RuleState, alertsFilePath)
+		return GetAlertRules(cmd.OutOrStdout(), resourcesNames, vars.OutputStringVar, GroupName, RuleState, alertsFilePath)
 	},
 }
 
@@ -17,7 +17,7 @@ package prometheus
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"os"
 	"time"
 
@@ -34,34 +34,45 @@ var TargetSubCmd = &cobra.Command{
 	Use:     "target",
 	Aliases: []string{"targets"},
 	Short:   "Retrieve the targets (and their status) scraped by Prometheus.",
-	Run: func(cmd *cobra.Command, args []string) {
-		monitoringExist, _ := helpers.Exists(vars.MustGatherRootPath + "/monitoring")
-		if !monitoringExist {
-			fmt.Fprintln(os.Stderr, "Path '"+vars.MustGatherRootPath+"/monitoring' does not exist.")
-			os.Exit(1)
-		}
-		alertsFilePath := vars.MustGatherRootPath + "/monitoring/prometheus/" + PrometheusInstance + "/active-targets.json"
-		alertsFilePathExist, _ := helpers.Exists(alertsFilePath)
-		if !alertsFilePathExist {
-			fmt.Fprintln(os.Stderr, "Prometheus targets not found in must-gather.")
-			os.Exit(1)
-		}
-		targets := TargetData{}
-		file, _ := os.ReadFile(alertsFilePath)
-		err := json.Unmarshal([]byte(file), &targets)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rows, err := TargetsRows(vars.MustGatherRootPath, PrometheusInstance)
 		if err != nil {
-			fmt.Println(err)
-		}
-		headers := []string{"TARGET", "SCRAPE URL", "HEALTH", "LAST ERROR"}
-		var data [][]string
-		for _, target := range targets.Data.ActiveTargets {
-			row := []string{target.DiscoveredLabels["__meta_kubernetes_endpoint_address_target_name"], target.ScrapeURL, string(target.Health), target.LastError}
-			data = append(data, row)
+			return err
 		}
-		helpers.PrintTable(headers, data)
+		helpers.FprintTable(cmd.OutOrStdout(), TargetsHeaders, rows)
+		return nil
 	},
 }
 
+// TargetsHeaders are the columns of the targets table.
+var TargetsHeaders = []string{"TARGET", "SCRAPE URL", "HEALTH", "LAST ERROR"}
+
+// TargetsRows returns the rows of the targets table of the prometheus instance
+// of the must-gather in root.
+func TargetsRows(root string, instance string) ([][]string, error) {
+	monitoringExist, _ := helpers.Exists(root + "/monitoring")
+	if !monitoringExist {
+		return nil, errors.New("Path '" + root + "/monitoring' does not exist.")
+	}
+	alertsFilePath := root + "/monitoring/prometheus/" + instance + "/active-targets.json"
+	alertsFilePathExist, _ := helpers.Exists(alertsFilePath)
+	if !alertsFilePathExist {
+		return nil, errors.New("Prometheus targets not found in must-gather.")
+	}
+	targets := TargetData{}
+	file, _ := os.ReadFile(alertsFilePath)
+	err := json.Unmarshal([]byte(file), &targets)
+	if err != nil {
+		return nil, err
+	}
+	var data [][]string
+	for _, target := range targets.Data.ActiveTargets {
+		row := []string{target.DiscoveredLabels["__meta_kubernetes_endpoint_address_target_name"], target.ScrapeURL, string(target.Health), target.LastError}
+		data = append(data, row)
+	}
+	return data, nil
+}
+
 func init() {
 	TargetSubCmd.Flags().StringVarP(&PrometheusInstance, "instance", "i", "prometheus-k8s-0", "Show targets for specific prometheus instance, availables: [prometheus-k8s-0|prometheus-k8s-1].")
 }
@@ -0,0 +1,430 @@
+package timeline
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gmeghnag/omc/cmd/events"
+	"github.com/gmeghnag/omc/cmd/helpers"
+	"github.com/gmeghnag/omc/cmd/logs"
+	"github.com/gmeghnag/omc/vars"
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// The sources of the timeline entries.
+const (
+	SourceEvent           = "event"
+	SourcePod             = "pod"
+	SourceNode            = "node"
+	SourceClusterOperator = "clusteroperator"
+	SourceLog             = "log"
+)
+
+// Sources are the sources of the timeline, in the order their entries at the
+// same time are listed.
+var Sources = []string{SourceClusterOperator, SourceNode, SourceEvent, SourcePod, SourceLog}
+
+var (
+	since, until    string
+	selectedSources []string
+)
+
+// Entry is something which happened in the cluster.
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Source    string    `json:"source"`
+	Namespace string    `json:"namespace,omitempty"`
+	// Object is the object the entry is about, as <kind>/<name>.
+	Object string `json:"object"`
+	// Type is the type of the event, the condition with its status like
+	// Degraded=True, the state of the container or the level of the log.
+	Type    string `json:"type"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message"`
+}
+
+// Timeline is the time-sorted list of the entries of the must-gather.
+type Timeline struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Options select the entries of the timeline.
+type Options struct {
+	// Namespaces are the namespaces of the events, pods and logs, the cluster
+	// scoped sources are read whatever they are.
+	Namespaces []string
+	// Sources are the sources to read, all of them when empty.
+	Sources []string
+	// Window selects the entries by time when it isn't nil.
+	Window *logs.TimeWindow
+}
+
+// Build returns the timeline of the must-gather in root. The reading of the logs
+// stops with the error of ctx once it is done.
+func Build(ctx context.Context, root string, options Options) (*Timeline, error) {
+	selected := map[string]bool{}
+	for _, source := range options.Sources {
+		selected[source] = true
+	}
+	readers := map[string]func(string, []string) ([]Entry, error){
+		SourceEvent:           eventEntries,
+		SourcePod:             podEntries,
+		SourceNode:            nodeEntries,
+		SourceClusterOperator: clusterOperatorEntries,
+		SourceLog: func(root string, namespaces []string) ([]Entry, error) {
+			return logEntries(ctx, root, namespaces, options.Window)
+		},
+	}
+	timeline := &Timeline{Entries: []Entry{}}
+	for _, source := range Sources {
+		if len(selected) > 0 && !selected[source] {
+			continue
+		}
+		entries, err := readers[source](root, options.Namespaces)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.Time.IsZero() || (options.Window != nil && !options.Window.Contains(entry.Time)) {
+				continue
+			}
+			entry.Time = entry.Time.UTC()
+			timeline.Entries = append(timeline.Entries, entry)
+		}
+	}
+	// the entries are sorted by source already, the ones at the same time
+	// keep the order of the sources
+	sort.SliceStable(timeline.Entries, func(i, j int) bool {
+		return timeline.Entries[i].Time.Before(timeline.Entries[j].Time)
+	})
+	return timeline, nil
+}
+
+func eventEntries(root string, namespaces []string) ([]Entry, error) {
+	var entries []Entry
+	for _, namespace := range namespaces {
+		eventList, err := events.GetEventList(root, namespace, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range eventList.Items {
+			entries = append(entries, Entry{
+				Time:      events.GetLastTime(event).Time,
+				Source:    SourceEvent,
+				Namespace: event.Namespace,
+				Object:    strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name,
+				Type:      event.Type,
+				Reason:    event.Reason,
+				Message:   strings.TrimSpace(event.Message),
+			})
+		}
+	}
+	return entries, nil
+}
+
+// podEntries returns the times the containers of the pods started and
+// terminated, the last termination of the restarted ones included.
+func podEntries(root string, namespaces []string) ([]Entry, error) {
+	var entries []Entry
+	for _, namespace := range namespaces {
+		data, err := os.ReadFile(filepath.Join(root, "namespaces", namespace, "core", "pods.yaml"))
+		if err != nil {
+			continue
+		}
+		var pods corev1.PodList
+		if err := yaml.Unmarshal(data, &pods); err != nil {
+			klog.V(3).ErrorS(err, "Unable to parse Kubernetes PodList object from "+namespace+"/core/pods.yaml")
+			continue
+		}
+		for _, pod := range pods.Items {
+			entry := Entry{Source: SourcePod, Namespace: pod.Namespace, Object: "pod/" + pod.Name}
+			for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+				if terminated := status.LastTerminationState.Terminated; terminated != nil {
+					entries = append(entries, terminatedEntry(entry, status.Name, terminated))
+				}
+				if running := status.State.Running; running != nil {
+					entry.Time, entry.Type, entry.Reason = running.StartedAt.Time, "Running", "Started"
+					entry.Message = fmt.Sprintf("container %s started", status.Name)
+					if status.RestartCount > 0 {
+						entry.Message += fmt.Sprintf(", %d restarts", status.RestartCount)
+					}
+					entries = append(entries, entry)
+				}
+				if terminated := status.State.Terminated; terminated != nil {
+					entries = append(entries, terminatedEntry(entry, status.Name, terminated))
+				}
+			}
+		}
+	}
+	return entries, nil
+}
+
+func terminatedEntry(entry Entry, container string, terminated *corev1.ContainerStateTerminated) Entry {
+	entry.Time, entry.Type, entry.Reason = terminated.FinishedAt.Time, "Terminated", terminated.Reason
+	entry.Message = fmt.Sprintf("container %s exited with code %d", container, terminated.ExitCode)
+	if message := strings.TrimSpace(terminated.Message); message != "" {
+		entry.Message += ": " + message
+	}
+	return entry
+}
+
+// nodeEntries returns the last transitions of the conditions of the nodes.
+func nodeEntries(root string, namespaces []string) ([]Entry, error) {
+	var entries []Entry
+	dir := filepath.Join(root, "cluster-scoped-resources", "core", "nodes")
+	files, _ := os.ReadDir(dir)
+	for _, file := range files {
+		data, err := helpers.ReadYaml(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		node := corev1.Node{}
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			klog.V(3).ErrorS(err, "Unable to parse Kubernetes Node object from "+file.Name())
+			continue
+		}
+		for _, condition := range node.Status.Conditions {
+			entries = append(entries, Entry{
+				Time:    condition.LastTransitionTime.Time,
+				Source:  SourceNode,
+				Object:  "node/" + node.Name,
+				Type:    string(condition.Type) + "=" + string(condition.Status),
+				Reason:  condition.Reason,
+				Message: strings.TrimSpace(condition.Message),
+			})
+		}
+	}
+	return entries, nil
+}
+
+// clusterOperatorEntries returns the last transitions of the conditions of the
+// cluster operators.
+func clusterOperatorEntries(root string, namespaces []string) ([]Entry, error) {
+	var entries []Entry
+	dir := filepath.Join(root, "cluster-scoped-resources", "config.openshift.io", "clusteroperators")
+	files, _ := os.ReadDir(dir)
+	for _, file := range files {
+		data, err := helpers.ReadYaml(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		operator := configv1.ClusterOperator{}
+		if err := yaml.Unmarshal(data, &operator); err != nil {
+			klog.V(3).ErrorS(err, "Unable to parse ClusterOperator object from "+file.Name())
+			continue
+		}
+		for _, condition := range operator.Status.Conditions {
+			entries = append(entries, Entry{
+				Time:    condition.LastTransitionTime.Time,
+				Source:  SourceClusterOperator,
+				Object:  "clusteroperator/" + operator.Name,
+				Type:    string(condition.Type) + "=" + string(condition.Status),
+				Reason:  condition.Reason,
+				Message: strings.TrimSpace(condition.Message),
+			})
+		}
+	}
+	return entries, nil
+}
+
+// logEntries returns the first occurrence of the error signatures of the logs
+// of the containers, in window when it isn't nil.
+func logEntries(ctx context.Context, root string, namespaces []string, window *logs.TimeWindow) ([]Entry, error) {
+	signatures, err := logs.ContainerErrorSignatures(ctx, root, namespaces, window)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for source, containerSignatures := range signatures {
+		// sources are <namespace>/<pod>/<container>
+		parts := strings.SplitN(source, "/", 3)
+		for _, signature := range containerSignatures {
+			if signature.FirstSeen == nil {
+				continue
+			}
+			message := fmt.Sprintf("container %s: %s", parts[2], signature.Example)
+			if signature.Count > 1 {
+				message += fmt.Sprintf(" (%d times until %s)", signature.Count, signature.LastSeen.Format(time.RFC3339))
+			}
+			entries = append(entries, Entry{
+				Time:      *signature.FirstSeen,
+				Source:    SourceLog,
+				Namespace: parts[0],
+				Object:    "pod/" + parts[1],
+				Type:      signature.Level,
+				Message:   message,
+			})
+		}
+	}
+	// the signatures come from a map
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Time.Equal(entries[j].Time) {
+			return entries[i].Time.Before(entries[j].Time)
+		}
+		if entries[i].Namespace+entries[i].Object != entries[j].Namespace+entries[j].Object {
+			return entries[i].Namespace+entries[i].Object < entries[j].Namespace+entries[j].Object
+		}
+		return entries[i].Message < entries[j].Message
+	})
+	return entries, nil
+}
+
+// timeBound parses the value of --since or --until, a RFC3339 time or a
+// duration counted back from the time the must-gather was collected.
+func timeBound(flag string, value string, reference time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return time.Time{}, fmt.Errorf("invalid --%s %q, expected a RFC3339 time like 2006-01-02T15:04:05Z or a duration like 5s, 2m or 3h", flag, value)
+	}
+	return reference.Add(-duration), nil
+}
+
+// NewWindow returns the time window of the --since and --until values, nil
+// when none is set.
+func NewWindow(root string, since string, until string) (*logs.TimeWindow, error) {
+	if since == "" && until == "" {
+		return nil, nil
+	}
+	reference, err := helpers.MustGatherTime(root)
+	if err != nil {
+		return nil, err
+	}
+	window := &logs.TimeWindow{Reference: reference}
+	if since != "" {
+		if window.Since, err = timeBound("since", since, reference); err != nil {
+			return nil, err
+		}
+	}
+	if until != "" {
+		if window.Until, err = timeBound("until", until, reference); err != nil {
+			return nil, err
+		}
+	}
+	if !window.Since.IsZero() && !window.Until.IsZero() && window.Until.Before(window.Since) {
+		return nil, fmt.Errorf("the end of the time window %s is before its start %s", window.Until.Format(time.RFC3339), window.Since.Format(time.RFC3339))
+	}
+	return window, nil
+}
+
+// Print prints the timeline as a table, with the namespace column when
+// allNamespaces is set, or as json, yaml or csv.
+func Print(w io.Writer, timeline *Timeline, output string, allNamespaces bool) error {
+	switch output {
+	case "json":
+		data, err := json.MarshalIndent(timeline, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", data)
+		return nil
+	case "yaml":
+		data, err := yaml.Marshal(timeline)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s", data)
+		return nil
+	case "csv":
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"time", "source", "namespace", "object", "type", "reason", "message"})
+		for _, entry := range timeline.Entries {
+			writer.Write([]string{entry.Time.Format(time.RFC3339), entry.Source, entry.Namespace, entry.Object, entry.Type, entry.Reason, entry.Message})
+		}
+		writer.Flush()
+		return writer.Error()
+	case "":
+	default:
+		return fmt.Errorf("unsupported output format %q, expected one of: json|yaml|csv", output)
+	}
+	if len(timeline.Entries) == 0 {
+		fmt.Fprintln(w, "No entries found.")
+		return nil
+	}
+	headers := []string{"TIME", "SOURCE", "OBJECT", "TYPE", "REASON", "MESSAGE"}
+	if allNamespaces {
+		headers = []string{"TIME", "SOURCE", "NAMESPACE", "OBJECT", "TYPE", "REASON", "MESSAGE"}
+	}
+	var data [][]string
+	for _, entry := range timeline.Entries {
+		message := strings.Join(strings.Fields(entry.Message), " ")
+		row := []string{entry.Time.Format(time.RFC3339), entry.Source, entry.Object, entry.Type, entry.Reason, message}
+		if allNamespaces {
+			row = append(row[:2], append([]string{entry.Namespace}, row[2:]...)...)
+		}
+		data = append(data, row)
+	}
+	helpers.FprintTable(w, headers, data)
+	return nil
+}
+
+var TimelineCmd = &cobra.Command{
+	Use:   "timeline",
+	Short: "Merge events, container state transitions, node and cluster operator conditions and log errors in one time-sorted view.",
+	Long: `Merge in one time-sorted view the events, the times the containers started and terminated,
+the last transitions of the conditions of the nodes and of the cluster operators, and the first
+occurrence of the error signatures of the container logs.
+The events, pods and logs are the ones of the namespace of the current project, or of the one given
+with --namespace, or of all namespaces with -A. --since and --until take a RFC3339 time or a
+duration counted back from the time the must-gather was collected.`,
+	Example: `  omc timeline -n openshift-etcd --since 2h
+  omc timeline -A --since 2024-01-02T15:00:00Z --until 2024-01-02T16:00:00Z -o csv
+  omc timeline -A --sources clusteroperator,node,event`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("error: expected no arguments, found %d", len(args))
+		}
+		for _, source := range selectedSources {
+			if !slices.Contains(Sources, source) {
+				return fmt.Errorf("invalid source %q, expected some of: %s", source, strings.Join(Sources, ","))
+			}
+		}
+		window, err := NewWindow(vars.MustGatherRootPath, since, until)
+		if err != nil {
+			return err
+		}
+		namespaces := []string{vars.Namespace}
+		if vars.AllNamespaceBoolVar {
+			namespaces = nil
+			entries, err := os.ReadDir(filepath.Join(vars.MustGatherRootPath, "namespaces"))
+			if err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				if entry.IsDir() {
+					namespaces = append(namespaces, entry.Name())
+				}
+			}
+		} else if vars.Namespace == "" {
+			namespaces = nil
+		}
+		timeline, err := Build(cmd.Context(), vars.MustGatherRootPath, Options{Namespaces: namespaces, Sources: selectedSources, Window: window})
+		if err != nil {
+			return err
+		}
+		return Print(cmd.OutOrStdout(), timeline, vars.OutputStringVar, vars.AllNamespaceBoolVar)
+	},
+}
+
+func init() {
+	TimelineCmd.Flags().BoolVarP(&vars.AllNamespaceBoolVar, "all-namespaces", "A", false, "If present, read the events, pods and logs of all namespaces.")
+	TimelineCmd.Flags().StringVar(&since, "since", "", "Only show the entries after this RFC3339 time, or in this duration, like 2h, before the must-gather was collected.")
+	TimelineCmd.Flags().StringVar(&until, "until", "", "Only show the entries before this RFC3339 time, or this duration, like 30m, before the must-gather was collected.")
+	TimelineCmd.Flags().StringSliceVar(&selectedSources, "sources", nil, "Only show the entries of these sources, some of: "+strings.Join(Sources, ","))
+	TimelineCmd.Flags().StringVarP(&vars.OutputStringVar, "output", "o", "", "Output format. One of: json|yaml|csv")
+}
@@ -0,0 +1,193 @@
+package timeline
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gmeghnag/omc/cmd/logs"
+)
+
+// writeFiles writes the files of a must-gather in root.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"timestamp": "2024-01-02 11:00:00.000000000 +0000 UTC m=+0.017\n",
+		"cluster-scoped-resources/config.openshift.io/clusteroperators/etcd.yaml": `apiVersion: config.openshift.io/v1
+kind: ClusterOperator
+metadata:
+  name: etcd
+status:
+  conditions:
+  - type: Degraded
+    status: "True"
+    reason: EtcdMembers_UnhealthyMembers
+    message: 1 of 3 members are available
+    lastTransitionTime: "2024-01-02T10:05:00Z"
+  - type: Available
+    status: "True"
+    reason: AsExpected
+    lastTransitionTime: "2023-12-01T00:00:00Z"
+`,
+		"cluster-scoped-resources/core/nodes/master-1.yaml": `apiVersion: v1
+kind: Node
+metadata:
+  name: master-1
+status:
+  conditions:
+  - type: Ready
+    status: "False"
+    reason: KubeletNotReady
+    message: PLEG is not healthy
+    lastTransitionTime: "2024-01-02T10:00:00Z"
+`,
+		"namespaces/openshift-etcd/core/events.yaml": `apiVersion: v1
+kind: EventList
+items:
+- metadata:
+    name: etcd-master-1.1
+    namespace: openshift-etcd
+  involvedObject:
+    kind: Pod
+    name: etcd-master-1
+  type: Warning
+  reason: Unhealthy
+  message: "Readiness probe failed"
+  lastTimestamp: "2024-01-02T10:02:00Z"
+`,
+		"namespaces/openshift-etcd/core/pods.yaml": `apiVersion: v1
+kind: PodList
+items:
+- metadata:
+    name: etcd-master-1
+    namespace: openshift-etcd
+  status:
+    containerStatuses:
+    - name: etcd
+      restartCount: 1
+      lastState:
+        terminated:
+          exitCode: 2
+          reason: Error
+          finishedAt: "2024-01-02T10:03:00Z"
+      state:
+        running:
+          startedAt: "2024-01-02T10:04:00Z"
+`,
+		"namespaces/openshift-etcd/pods/etcd-master-1/etcd/etcd/logs/current.log": `2024-01-02T10:01:00.000000000Z stderr F {"level":"warn","msg":"slow fdatasync","took":"1.2s"}
+2024-01-02T10:01:30.000000000Z stderr F {"level":"error","msg":"failed to reach the peer","peer-id":"8e9e05c52164694d"}
+2024-01-02T10:02:30.000000000Z stderr F {"level":"error","msg":"failed to reach the peer","peer-id":"91bc3c398fb3c146"}
+`,
+	})
+	entry := func(t string, source, namespace, object, typ, reason, message string) Entry {
+		parsed, _ := time.Parse(time.RFC3339, t)
+		return Entry{Time: parsed, Source: source, Namespace: namespace, Object: object, Type: typ, Reason: reason, Message: message}
+	}
+	all := []Entry{
+		entry("2023-12-01T00:00:00Z", "clusteroperator", "", "clusteroperator/etcd", "Available=True", "AsExpected", ""),
+		entry("2024-01-02T10:00:00Z", "node", "", "node/master-1", "Ready=False", "KubeletNotReady", "PLEG is not healthy"),
+		entry("2024-01-02T10:01:30Z", "log", "openshift-etcd", "pod/etcd-master-1", "error", "", `container etcd: 2024-01-02T10:01:30.000000000Z stderr F {"level":"error","msg":"failed to reach the peer","peer-id":"8e9e05c52164694d"} (2 times until 2024-01-02T10:02:30Z)`),
+		entry("2024-01-02T10:02:00Z", "event", "openshift-etcd", "pod/etcd-master-1", "Warning", "Unhealthy", "Readiness probe failed"),
+		entry("2024-01-02T10:03:00Z", "pod", "openshift-etcd", "pod/etcd-master-1", "Terminated", "Error", "container etcd exited with code 2"),
+		entry("2024-01-02T10:04:00Z", "pod", "openshift-etcd", "pod/etcd-master-1", "Running", "Started", "container etcd started, 1 restarts"),
+		entry("2024-01-02T10:05:00Z", "clusteroperator", "", "clusteroperator/etcd", "Degraded=True", "EtcdMembers_UnhealthyMembers", "1 of 3 members are available"),
+	}
+
+	tests := []struct {
+		name     string
+		options  Options
+		since    string
+		until    string
+		expected []Entry
+	}{
+		{
+			name:     "All the sources",
+			options:  Options{Namespaces: []string{"openshift-etcd"}},
+			expected: all,
+		},
+		{
+			name:     "Time window relative to the must-gather",
+			options:  Options{Namespaces: []string{"openshift-etcd"}},
+			since:    "1h",
+			until:    "2024-01-02T10:03:00Z",
+			expected: all[1:5],
+		},
+		{
+			name:     "Cluster scoped sources only",
+			options:  Options{Sources: []string{"clusteroperator", "node"}},
+			expected: []Entry{all[0], all[1], all[6]},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			window, err := NewWindow(root, tc.since, tc.until)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.options.Window = window
+			actual, err := Build(context.Background(), root, tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(actual.Entries, tc.expected) {
+				t.Fatalf("Expected : %+v, got: %+v", tc.expected, actual.Entries)
+			}
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	timeline := &Timeline{Entries: []Entry{
+		{Time: time.Date(2024, time.January, 2, 10, 0, 0, 0, time.UTC), Source: "node", Object: "node/master-1", Type: "Ready=False", Reason: "KubeletNotReady", Message: "PLEG is not healthy"},
+		{Time: time.Date(2024, time.January, 2, 10, 2, 0, 0, time.UTC), Source: "event", Namespace: "openshift-etcd", Object: "pod/etcd-master-1", Type: "Warning", Reason: "Unhealthy", Message: "Readiness probe failed:\n connection refused"},
+	}}
+	output := new(bytes.Buffer)
+	if err := Print(output, timeline, "csv", false); err != nil {
+		t.Fatal(err)
+	}
+	expected := `time,source,namespace,object,type,reason,message
+2024-01-02T10:00:00Z,node,,node/master-1,Ready=False,KubeletNotReady,PLEG is not healthy
+2024-01-02T10:02:00Z,event,openshift-etcd,pod/etcd-master-1,Warning,Unhealthy,"Readiness probe failed:
+ connection refused"
+`
+	if output.String() != expected {
+		t.Errorf("Got: %v \n", output.String())
+		t.Errorf("Want: %v \n", expected)
+	}
+}
+
+func TestNewWindow(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"timestamp": "2024-01-02 11:00:00.000000000 +0000 UTC m=+0.017\n"})
+	if _, err := NewWindow(root, "yesterday", ""); err == nil {
+		t.Fatal("Expected an error for an invalid --since")
+	}
+	if _, err := NewWindow(root, "10m", "1h"); err == nil {
+		t.Fatal("Expected an error for an --until before --since")
+	}
+	window, err := NewWindow(root, "", "30m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &logs.TimeWindow{Until: time.Date(2024, time.January, 2, 10, 30, 0, 0, time.UTC), Reference: time.Date(2024, time.January, 2, 11, 0, 0, 0, time.UTC)}
+	if !window.Until.Equal(expected.Until) || !window.Reference.Equal(expected.Reference) || !window.Since.IsZero() {
+		t.Fatalf("Expected : %+v, got: %+v", expected, window)
+	}
+}
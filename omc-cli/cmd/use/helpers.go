@@ -40,12 +40,13 @@ func humanizeBytes(bytes int64) string {
 }
 
 type WriteCounter struct {
+	out        io.Writer
 	length     string
 	downloaded int64
 	lastShown  time.Time
 }
 
-func NewWriteCounter(total int64) *WriteCounter {
+func NewWriteCounter(out io.Writer, total int64) *WriteCounter {
 	length := ""
 	if total != -1 {
 		length = humanizeBytes(total)
@@ -53,6 +54,7 @@ func NewWriteCounter(total int64) *WriteCounter {
 		length = "?"
 	}
 	counter := &WriteCounter{
+		out:        out,
 		length:     length,
 		downloaded: 0,
 		lastShown:  time.Now(),
@@ -78,8 +80,8 @@ func (counter *WriteCounter) ShowProgress() {
 		return
 	}
 
-	fmt.Printf("\r%s", strings.Repeat(" ", 78))
-	fmt.Printf("\rDownloading... %s / %s", counter.Downloaded(), counter.length)
+	fmt.Fprintf(counter.out, "\r%s", strings.Repeat(" ", 78))
+	fmt.Fprintf(counter.out, "\rDownloading... %s / %s", counter.Downloaded(), counter.length)
 
 	counter.lastShown = time.Now()
 }
@@ -144,11 +146,9 @@ func isXZ(path string) (bool, error) {
 	}
 	defer file.Close()
 
+	// a file which is not xz is not an error, it can still be a tar
 	_, err = xz.NewReader(file)
-	if err != nil {
-		return false, err
-	}
-	return true, nil
+	return err == nil, nil
 }
 
 func IsCompressedFile(path string) (bool, string, error) {
@@ -186,7 +186,7 @@ func IsRemoteFile(path string) bool {
 	return err == nil && parsedURL.Scheme != "" && parsedURL.Host != ""
 }
 
-func DownloadFile(path string) (string, error) {
+func DownloadFile(w io.Writer, path string) (string, error) {
 	tmpdir, err := os.MkdirTemp("", "omc-*")
 	if err != nil {
 		return "", err
@@ -197,6 +197,9 @@ func DownloadFile(path string) (string, error) {
 		return "", err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error downloading %s: %s", path, resp.Status)
+	}
 
 	// Use a sensible filename
 	var filename string
@@ -212,23 +215,28 @@ func DownloadFile(path string) (string, error) {
 			filename = pathlib.Base(parsedURL.Path)
 		}
 	}
+	// the file name comes from the server, it must not point outside of tmpdir
+	filename = filepath.Base(filepath.FromSlash(filename))
+	if filename == "." || !filepath.IsLocal(filename) {
+		filename = "must-gather.archive"
+	}
 
 	outpath := filepath.Join(tmpdir, filename)
-	fmt.Println("downloading file " + path + " in " + outpath)
+	fmt.Fprintln(w, "downloading file "+path+" in "+outpath)
 
 	out, err := os.Create(outpath)
 	if err != nil {
 		return "", err
 	}
 
-	counter := NewWriteCounter(resp.ContentLength)
+	counter := NewWriteCounter(w, resp.ContentLength)
 	if _, err = io.Copy(out, io.TeeReader(resp.Body, counter)); err != nil {
 		out.Close()
 		return "", err
 	}
 
 	out.Close()
-	fmt.Println()
+	fmt.Fprintln(w)
 
 	return out.Name(), nil
 }
@@ -253,28 +261,88 @@ func CopyFile(path string, destinationfile string) error {
 	return err
 }
 
-func DecompressFile(path string, outpath string, fileType string) (string, error) {
-	fmt.Println("decompressing file " + path + " in " + outpath)
-	var err error
-	var mgRootDir string = ""
+func DecompressFile(w io.Writer, path string, outpath string, fileType string) (string, error) {
+	fmt.Fprintln(w, "decompressing file "+path+" in "+outpath)
+	return ExtractArchive(path, outpath, fileType, ExtractLimits{})
+}
+
+// ExtractLimits bounds what an archive can extract, against archive bombs.
+// The zero values mean no limit.
+type ExtractLimits struct {
+	// MaxBytes is the total size of the files extracted.
+	MaxBytes int64
+	// MaxFiles is the number of files and directories extracted.
+	MaxFiles int
+	// Progress, when set, is called with the bytes and files extracted so far.
+	Progress func(bytes int64, files int)
+}
 
+// ExtractArchive extracts the archive at path of type fileType, as returned by
+// IsCompressedFile, in destinationdir and returns the root directory of the
+// must-gather. The entries outside of destinationdir are refused, links are
+// skipped.
+func ExtractArchive(path string, destinationdir string, fileType string, limits ExtractLimits) (string, error) {
+	e := &extractor{limits: limits}
 	switch fileType {
 	case fileTypeTar:
-		mgRootDir, err = ExtractTar(path, outpath)
+		return e.tar(path, destinationdir)
 	case fileTypeTarGzip:
-		mgRootDir, err = ExtractTarGz(path, outpath)
+		return e.tarGz(path, destinationdir)
 	case fileTypeXZ:
-		mgRootDir, err = extractTarXZ(path, outpath)
+		return e.tarXZ(path, destinationdir)
 	case fileTypeZip:
-		mgRootDir, err = ExtractZip(path, outpath)
+		return e.zip(path, destinationdir)
 	default:
 		return "", fmt.Errorf("unable to decompress file: unknown file type %s", fileType)
 	}
+}
 
-	return mgRootDir, err
+// extractor extracts archives within limits.
+type extractor struct {
+	limits ExtractLimits
+	bytes  int64
+	files  int
+}
+
+// path returns the path of the archive entry name in destinationdir, or an
+// error when the entry would be written outside of it.
+func (e *extractor) path(destinationdir string, name string) (string, error) {
+	path := filepath.Join(destinationdir, name)
+	if !filepath.IsLocal(filepath.FromSlash(name)) && filepath.Clean(path) != filepath.Clean(destinationdir) {
+		return "", fmt.Errorf("archive entry %q is outside of the destination directory", name)
+	}
+	e.files++
+	if e.limits.MaxFiles > 0 && e.files > e.limits.MaxFiles {
+		return "", fmt.Errorf("archive has more than %d files", e.limits.MaxFiles)
+	}
+	return path, nil
+}
+
+// copy copies the content of an archive entry, within the size limit.
+func (e *extractor) copy(dst io.Writer, src io.Reader) error {
+	if e.limits.MaxBytes > 0 {
+		// read one byte more than allowed to tell when the limit is exceeded
+		src = io.LimitReader(src, e.limits.MaxBytes-e.bytes+1)
+	}
+	n, err := io.Copy(dst, src)
+	e.bytes += n
+	if err != nil {
+		return err
+	}
+	if e.limits.MaxBytes > 0 && e.bytes > e.limits.MaxBytes {
+		return fmt.Errorf("archive content is larger than %s", humanizeBytes(e.limits.MaxBytes))
+	}
+	if e.limits.Progress != nil {
+		e.limits.Progress(e.bytes, e.files)
+	}
+	return nil
 }
 
 func ExtractTarStream(st io.Reader, destinationdir string) (string, error) {
+	return (&extractor{}).tarStream(st, destinationdir)
+}
+
+func (e *extractor) tarStream(st io.Reader, destinationdir string) (string, error) {
 	firstDirectory := false
 	var mgRootDir string = ""
 	tarReader := tar.NewReader(st)
@@ -293,18 +361,25 @@ func ExtractTarStream(st io.Reader, destinationdir string) (string, error) {
 
 		switch header.Typeflag {
 		case tar.TypeDir:
+			directory, err := e.path(destinationdir, header.Name)
+			if err != nil {
+				return "", err
+			}
 			if !firstDirectory {
 				firstDirectory = true
 				mgRootDir = destinationdir + "/" + header.Name
 			}
-			directory := filepath.Join(destinationdir, header.Name)
 			if _, err := os.Stat(directory); os.IsNotExist(err) {
-				if err := os.Mkdir(directory, 0755); err != nil {
+				if err := os.MkdirAll(directory, 0755); err != nil {
 					fmt.Fprintln(os.Stderr, "mkdir failed extracting tar: "+err.Error())
 					return "", err
 				}
 			}
 		case tar.TypeReg:
+			outpath, err := e.path(destinationdir, header.Name)
+			if err != nil {
+				return "", err
+			}
 			// Root dir is not part of the archive
 			if mgRootDir == "" {
 				mgRootDir = filepath.Join(destinationdir, filepath.Dir(header.Name))
@@ -314,29 +389,39 @@ func ExtractTarStream(st io.Reader, destinationdir string) (string, error) {
 					return "", err
 				}
 			}
-			outpath := filepath.Join(destinationdir, header.Name)
-			if _, err := os.Stat(outpath); !os.IsNotExist(err) {
+			if _, err := os.Lstat(outpath); !os.IsNotExist(err) {
 				fmt.Fprintln(os.Stderr, "create file failed extracting tar: file already exists")
 			}
+			if err := os.MkdirAll(filepath.Dir(outpath), os.ModePerm); err != nil {
+				return "", err
+			}
 			outFile, err := os.Create(outpath)
 			if err != nil {
 				fmt.Fprintln(os.Stderr, "create file failed extracting tar: "+err.Error())
 				return "", err
 			}
-			if _, err := io.Copy(outFile, tarReader); err != nil {
+			if err := e.copy(outFile, tarReader); err != nil {
+				outFile.Close()
 				fmt.Fprintln(os.Stderr, "copy file failed extracting tar: "+err.Error())
 				return "", err
 			}
 			outFile.Close()
+		case tar.TypeSymlink, tar.TypeLink:
+			// links could point outside of the destination directory
+			fmt.Fprintf(os.Stderr, "skipping link %s extracting tar\n", header.Name)
+		case tar.TypeXGlobalHeader:
 		default:
-			fmt.Fprintf(os.Stderr, "unknown type(%s) in %s: "+err.Error(), header.Typeflag, header.Name)
-			return "", err
+			return "", fmt.Errorf("unknown type(%c) in %s extracting tar", header.Typeflag, header.Name)
 		}
 	}
 	return mgRootDir, nil
 }
 
 func ExtractTar(tarfile string, destinationdir string) (string, error) {
+	return (&extractor{}).tar(tarfile, destinationdir)
+}
+
+func (e *extractor) tar(tarfile string, destinationdir string) (string, error) {
 	tarStream, err := os.Open(tarfile)
 	var mgRootDir string
 	if err != nil {
@@ -346,12 +431,16 @@ func ExtractTar(tarfile string, destinationdir string) (string, error) {
 	defer tarStream.Close()
 
 	var fileReader io.ReadCloser = tarStream
-	mgRootDir, err = ExtractTarStream(fileReader, destinationdir)
+	mgRootDir, err = e.tarStream(fileReader, destinationdir)
 
 	return mgRootDir, err
 }
 
 func ExtractZip(zipfile string, destinationdir string) (string, error) {
+	return (&extractor{}).zip(zipfile, destinationdir)
+}
+
+func (e *extractor) zip(zipfile string, destinationdir string) (string, error) {
 
 	firstDirectory := false
 	var mgRootDir string = ""
@@ -363,7 +452,15 @@ func ExtractZip(zipfile string, destinationdir string) (string, error) {
 	defer archive.Close()
 
 	for _, f := range archive.File {
-		filePath := filepath.Join(destinationdir, f.Name)
+		if f.Mode()&os.ModeSymlink != 0 {
+			// links could point outside of the destination directory
+			fmt.Fprintf(os.Stderr, "skipping link %s extracting zip\n", f.Name)
+			continue
+		}
+		filePath, err := e.path(destinationdir, f.Name)
+		if err != nil {
+			return "", err
+		}
 
 		// Root dir is not part of the archive
 		if !f.FileInfo().IsDir() && mgRootDir == "" {
@@ -385,32 +482,44 @@ func ExtractZip(zipfile string, destinationdir string) (string, error) {
 				fmt.Fprintln(os.Stderr, "error: cannot create directory "+filePath+": "+err.Error())
 				return "", err
 			}
-		} else {
-			dstFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-			if err != nil {
-				fmt.Fprintln(os.Stderr, "error: cannot create file "+filePath+": "+err.Error())
-				return "", err
-			}
-			defer dstFile.Close()
+		} else if err := e.zipFile(f, filePath); err != nil {
+			return "", err
+		}
+	}
 
-			fileInArchive, err := f.Open()
-			if err != nil {
-				fmt.Fprintln(os.Stderr, "error: cannot open file "+f.Name+": "+err.Error())
-				return "", err
-			}
-			defer fileInArchive.Close()
+	return mgRootDir, nil
+}
 
-			if _, err := io.Copy(dstFile, fileInArchive); err != nil {
-				fmt.Fprintln(os.Stderr, "error: cannot copy file to "+dstFile.Name()+": "+err.Error())
-				return "", err
-			}
-		}
+func (e *extractor) zipFile(f *zip.File, filePath string) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return err
 	}
+	dstFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode().Perm())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error: cannot create file "+filePath+": "+err.Error())
+		return err
+	}
+	defer dstFile.Close()
 
-	return mgRootDir, err
+	fileInArchive, err := f.Open()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error: cannot open file "+f.Name+": "+err.Error())
+		return err
+	}
+	defer fileInArchive.Close()
+
+	if err := e.copy(dstFile, fileInArchive); err != nil {
+		fmt.Fprintln(os.Stderr, "error: cannot copy file to "+dstFile.Name()+": "+err.Error())
+		return err
+	}
+	return nil
 }
 
 func ExtractTarGz(gzipfile string, destinationdir string) (string, error) {
+	return (&extractor{}).tarGz(gzipfile, destinationdir)
+}
+
+func (e *extractor) tarGz(gzipfile string, destinationdir string) (string, error) {
 	gzipStream, err := os.Open(gzipfile)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "error: cannot open "+gzipfile+": "+err.Error())
@@ -422,10 +531,14 @@ func ExtractTarGz(gzipfile string, destinationdir string) (string, error) {
 		fmt.Fprintln(os.Stderr, "error: cannot uncompress gzip "+gzipfile+": "+err.Error())
 		return "", err
 	}
-	return ExtractTarStream(uncompressedStream, destinationdir)
+	return e.tarStream(uncompressedStream, destinationdir)
 }
 
 func extractTarXZ(xzFile string, destinationdir string) (string, error) {
+	return (&extractor{}).tarXZ(xzFile, destinationdir)
+}
+
+func (e *extractor) tarXZ(xzFile string, destinationdir string) (string, error) {
 	stream, err := os.Open(xzFile)
 	if err != nil {
 		return "", fmt.Errorf("error: cannot open %q: %w", xzFile, err)
@@ -436,7 +549,7 @@ func extractTarXZ(xzFile string, destinationdir string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("error: cannot uncompress xz file %q: %w", xzFile, err)
 	}
-	return ExtractTarStream(xzReader, destinationdir)
+	return e.tarStream(xzReader, destinationdir)
 }
 
 func extractClientVersion(mustGatherLogsFilePath string) string {
@@ -477,7 +590,7 @@ func extractClientVersion(mustGatherLogsFilePath string) string {
 
 	// Handle potential scanning error
 	if err := scanner.Err(); err != nil {
-		fmt.Println("Error reading file:", err)
+		fmt.Fprintln(os.Stderr, "Error reading file:", err)
 		return ""
 	}
 
@@ -1,8 +1,13 @@
 package use
 
 import (
+	"archive/tar"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -37,3 +42,108 @@ func clearTestFiles(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func writeTar(t *testing.T, headers ...*tar.Header) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "archive.tar")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	writer := tar.NewWriter(file)
+	for _, header := range headers {
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write(make([]byte, header.Size)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractArchive(t *testing.T) {
+	tests := map[string]struct {
+		headers []*tar.Header
+		limits  ExtractLimits
+		wantErr bool
+	}{
+		"regular": {
+			headers: []*tar.Header{
+				{Name: "mg/", Typeflag: tar.TypeDir, Mode: 0755},
+				{Name: "mg/timestamp", Typeflag: tar.TypeReg, Mode: 0644, Size: 10},
+			},
+		},
+		"path traversal": {
+			headers: []*tar.Header{{Name: "../escaped", Typeflag: tar.TypeReg, Mode: 0644, Size: 1}},
+			wantErr: true,
+		},
+		"absolute path": {
+			headers: []*tar.Header{{Name: "/tmp/escaped", Typeflag: tar.TypeReg, Mode: 0644, Size: 1}},
+			wantErr: true,
+		},
+		"symlink skipped": {
+			headers: []*tar.Header{
+				{Name: "mg/", Typeflag: tar.TypeDir, Mode: 0755},
+				{Name: "mg/link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+			},
+		},
+		"too large": {
+			headers: []*tar.Header{{Name: "mg/big", Typeflag: tar.TypeReg, Mode: 0644, Size: 100}},
+			limits:  ExtractLimits{MaxBytes: 99},
+			wantErr: true,
+		},
+		"too many files": {
+			headers: []*tar.Header{
+				{Name: "mg/a", Typeflag: tar.TypeReg, Mode: 0644},
+				{Name: "mg/b", Typeflag: tar.TypeReg, Mode: 0644},
+			},
+			limits:  ExtractLimits{MaxFiles: 1},
+			wantErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			destination := t.TempDir()
+			_, err := ExtractArchive(writeTar(t, test.headers...), destination, fileTypeTar, test.limits)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+			if _, err := os.Lstat(filepath.Join(destination, "mg", "link")); !os.IsNotExist(err) {
+				t.Errorf("expected the link to be skipped, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDownloadFileKeepsTheFileInItsDirectory(t *testing.T) {
+	for disposition, want := range map[string]string{
+		`attachment; filename="../../escaped.tar"`: "escaped.tar",
+		`attachment; filename=".."`:                "must-gather.archive",
+		`attachment; filename="must-gather.tar"`:   "must-gather.tar",
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Disposition", disposition)
+			_, _ = w.Write([]byte("archive"))
+		}))
+		path, err := DownloadFile(io.Discard, server.URL+"/download")
+		server.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(filepath.Dir(path))
+		if filepath.Base(path) != want || !strings.HasPrefix(filepath.Base(filepath.Dir(path)), "omc-") {
+			t.Errorf("got path %s for %s, want a file %s in a temporary directory", path, disposition, want)
+		}
+	}
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	if _, err := DownloadFile(io.Discard, server.URL+"/missing.tar"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("got error %v downloading a missing file", err)
+	}
+}
@@ -18,6 +18,7 @@ package use
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -37,13 +38,11 @@ var singleNamespaceInMustGather bool
 
 func useContext(path string, omcConfigFile string, idFlag string) error {
 	if path != "" {
-		_path, err := findMustGatherIn(path)
+		var err error
+		path, err = MustGatherRoot(path)
 		if err != nil {
 			return err
 		}
-		l := strings.Split(_path, "/")
-		path = strings.Join(l[0:(len(l)-1)], "/")
-		path = strings.TrimSuffix(path, "/")
 		vars.MustGatherRootPath = path
 	}
 
@@ -112,6 +111,76 @@ func useContext(path string, omcConfigFile string, idFlag string) error {
 	return nil
 }
 
+// MustGatherRoot returns the directory holding the must-gather resources
+// found in path.
+func MustGatherRoot(path string) (string, error) {
+	_path, err := findMustGatherIn(path)
+	if err != nil {
+		return "", err
+	}
+	l := strings.Split(_path, "/")
+	path = strings.Join(l[0:(len(l)-1)], "/")
+	return strings.TrimSuffix(path, "/"), nil
+}
+
+// Resolve prepares the must-gather found in path, downloading and
+// decompressing it when needed, and returns the context to use it without
+// storing it in the omc config file.
+func Resolve(w io.Writer, path string) (types.Context, error) {
+	path, err := preparePath(w, path)
+	if err != nil {
+		return types.Context{}, err
+	}
+	path, err = MustGatherRoot(path)
+	if err != nil {
+		return types.Context{}, err
+	}
+	project := vars.DefaultProject
+	if project == "" {
+		project = "default"
+	}
+	if namespaces, _ := os.ReadDir(path + "/namespaces/"); len(namespaces) == 1 {
+		project = namespaces[0].Name()
+	}
+	return types.Context{Id: helpers.RandString(8), Path: path, Current: "*", Project: project}, nil
+}
+
+// preparePath returns the local directory of the must-gather pointed by path,
+// downloading it if it is a remote file and decompressing it if it is an
+// archive.
+func preparePath(w io.Writer, path string) (string, error) {
+	var err error
+	if IsRemoteFile(path) {
+		path, err = DownloadFile(w, path)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		if strings.HasSuffix(path, "/") {
+			path = strings.TrimRight(path, "/")
+		}
+		if strings.HasSuffix(path, "\\") {
+			path = strings.TrimRight(path, "\\")
+		}
+		path, _ = filepath.Abs(path)
+	}
+
+	isDir, _ := helpers.IsDirectory(path)
+	if isDir {
+		return path, nil
+	}
+	isCompressedFile, fileType, _ := IsCompressedFile(path)
+	if !isCompressedFile {
+		return "", fmt.Errorf("Error: %s is not a directory not a compressed file.", path)
+	}
+	outputpath := filepath.Dir(path)
+	rootfile, err := DecompressFile(w, path, outputpath, fileType)
+	if err != nil {
+		return "", fmt.Errorf("Error: decompressing %s in %s: %s", path, outputpath, err.Error())
+	}
+	return rootfile, nil
+}
+
 func findMustGatherIn(path string) (string, error) {
 	numDirs := 0
 	dirName := ""
@@ -151,23 +220,22 @@ func findMustGatherIn(path string) (string, error) {
 	return findMustGatherIn(path + "/" + dirName)
 }
 
-func MustGatherInfo() {
-	fmt.Printf("Must-Gather    : %s\n", vars.MustGatherRootPath)
+func MustGatherInfo(w io.Writer) error {
+	fmt.Fprintf(w, "Must-Gather    : %s\n", vars.MustGatherRootPath)
 	if singleNamespaceInMustGather {
-		fmt.Printf("Project        : %s (single project)\n", vars.Namespace)
+		fmt.Fprintf(w, "Project        : %s (single project)\n", vars.Namespace)
 	} else {
-		fmt.Printf("Project        : %s\n", vars.Namespace)
+		fmt.Fprintf(w, "Project        : %s\n", vars.Namespace)
 	}
 	InfrastrctureFilePathExists, _ := helpers.Exists(vars.MustGatherRootPath + "/cluster-scoped-resources/config.openshift.io/infrastructures.yaml")
 	if InfrastrctureFilePathExists {
 		_file, _ := os.ReadFile(vars.MustGatherRootPath + "/cluster-scoped-resources/config.openshift.io/infrastructures.yaml")
 		infrastructureList := configv1.InfrastructureList{}
 		if err := yaml.Unmarshal([]byte(_file), &infrastructureList); err != nil {
-			fmt.Println("Error when trying to unmarshal file: " + vars.MustGatherRootPath + "/cluster-scoped-resources/config.openshift.io/infrastructures.yaml")
-			os.Exit(1)
+			return fmt.Errorf("Error when trying to unmarshal file: %s/cluster-scoped-resources/config.openshift.io/infrastructures.yaml", vars.MustGatherRootPath)
 		} else {
-			fmt.Printf("ApiServerURL   : %s\n", infrastructureList.Items[0].Status.APIServerURL)
-			fmt.Printf("Platform       : %s\n", infrastructureList.Items[0].Status.PlatformStatus.Type)
+			fmt.Fprintf(w, "ApiServerURL   : %s\n", infrastructureList.Items[0].Status.APIServerURL)
+			fmt.Fprintf(w, "Platform       : %s\n", infrastructureList.Items[0].Status.PlatformStatus.Type)
 		}
 	}
 	clusterversionFilePathExists, _ := helpers.Exists(vars.MustGatherRootPath + "/cluster-scoped-resources/config.openshift.io/clusterversions/version.yaml")
@@ -175,8 +243,7 @@ func MustGatherInfo() {
 		_file, _ := os.ReadFile(vars.MustGatherRootPath + "/cluster-scoped-resources/config.openshift.io/clusterversions/version.yaml")
 		ClusterVersion := configv1.ClusterVersion{}
 		if err := yaml.Unmarshal([]byte(_file), &ClusterVersion); err != nil {
-			fmt.Println("Error when trying to unmarshal file: " + vars.MustGatherRootPath + "/cluster-scoped-resources/config.openshift.io/clusterversions/version.yaml")
-			os.Exit(1)
+			return fmt.Errorf("Error when trying to unmarshal file: %s/cluster-scoped-resources/config.openshift.io/clusterversions/version.yaml", vars.MustGatherRootPath)
 		} else {
 			clusterversion := ""
 			versionHistory := ClusterVersion.Status.History
@@ -187,25 +254,25 @@ func MustGatherInfo() {
 				}
 			}
 
-			fmt.Printf("ClusterID      : %s\n", ClusterVersion.Spec.ClusterID)
-			fmt.Printf("ClusterVersion : %s\n", clusterversion)
+			fmt.Fprintf(w, "ClusterID      : %s\n", ClusterVersion.Spec.ClusterID)
+			fmt.Fprintf(w, "ClusterVersion : %s\n", clusterversion)
 		}
 	}
 	mustGatherSplitPath := strings.Split(vars.MustGatherRootPath, "/")
 	mustGatherParentPath := strings.Join(mustGatherSplitPath[0:(len(mustGatherSplitPath)-1)], "/")
 	clientVersion := extractClientVersion(mustGatherParentPath + "/must-gather.logs")
 	if clientVersion != "" {
-		fmt.Printf("ClientVersion  : %s\n", clientVersion)
+		fmt.Fprintf(w, "ClientVersion  : %s\n", clientVersion)
 	}
 	parts := strings.Split(vars.MustGatherRootPath, "/")
 	if len(parts) > 0 {
 		lastPart := parts[len(parts)-1]
 		if strings.Contains(lastPart, "-sha256") {
 			mustGatherImage := strings.Split(lastPart, "-sha256")[0]
-			fmt.Printf("Image          : %s\n", mustGatherImage)
+			fmt.Fprintf(w, "Image          : %s\n", mustGatherImage)
 		}
 	}
-
+	return nil
 }
 
 // useCmd represents the use command
@@ -216,64 +283,29 @@ var UseCmd = &cobra.Command{
 	Select the must-gather to use.
 	If the must-gather does not exists it will be added as default to the managed must-gathers.
 	Use the command 'omc get mg' to see them all.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		var err error
+		out := cmd.OutOrStdout()
 		idFlag, _ := cmd.Flags().GetString("id")
 		path := ""
-		fileType := ""
-		isCompressedFile := false
 		if len(args) == 0 && idFlag == "" {
-			MustGatherInfo()
-			os.Exit(0)
+			return MustGatherInfo(out)
 		}
 		if len(args) > 1 {
-			fmt.Fprintln(os.Stderr, "Expect one argument, found: ", len(args))
-			os.Exit(1)
+			return fmt.Errorf("Expect one argument, found: %d", len(args))
 		}
 		if len(args) == 1 {
-			path = args[0]
-			if IsRemoteFile(path) {
-				path, err = DownloadFile(path)
-				if err != nil {
-					fmt.Fprintln(os.Stderr, err)
-					os.Exit(1)
-				}
-			} else {
-				if strings.HasSuffix(path, "/") {
-					path = strings.TrimRight(path, "/")
-				}
-				if strings.HasSuffix(path, "\\") {
-					path = strings.TrimRight(path, "\\")
-				}
-				path, _ = filepath.Abs(path)
-			}
-
-			isDir, _ := helpers.IsDirectory(path)
-			if !isDir {
-				isCompressedFile, fileType, _ = IsCompressedFile(path)
-				if !isCompressedFile {
-					fmt.Fprintln(os.Stderr, "Error: "+path+" is not a directory not a compressed file.")
-					os.Exit(1)
-				}
-			}
-		}
-
-		if isCompressedFile {
-			outputpath := filepath.Dir(path)
-			rootfile, err := DecompressFile(path, outputpath, fileType)
+			path, err = preparePath(out, args[0])
 			if err != nil {
-				fmt.Fprintln(os.Stderr, "Error: decompressing "+path+" in "+outputpath+": "+err.Error())
-				os.Exit(1)
+				return err
 			}
-			path = rootfile
 		}
 
 		err = useContext(path, viper.ConfigFileUsed(), idFlag)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+			return err
 		}
-		MustGatherInfo()
+		return MustGatherInfo(out)
 	},
 }
 
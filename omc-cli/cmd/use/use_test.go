@@ -3,9 +3,11 @@ package use
 import (
 	"encoding/json"
 	"errors"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gmeghnag/omc/types"
@@ -161,6 +163,55 @@ func TestUseContext(t *testing.T) {
 	}
 }
 
+func TestResolve(t *testing.T) {
+	testCases := []struct {
+		name            string
+		path            string
+		defaultProject  string
+		expectedProject string
+	}{
+		{
+			name:            "single namespace must-gather uses its namespace",
+			path:            "testdata/useContext/must-gather-singleNS.sample",
+			expectedProject: "openshift-etcd",
+		},
+		{
+			name:            "multiple namespaces must-gather uses the default project",
+			path:            "testdata/useContext/must-gather-multipleNS.sample",
+			expectedProject: "default",
+		},
+		{
+			name:            "multiple namespaces must-gather uses the configured default project",
+			path:            "testdata/useContext/must-gather-multipleNS.sample/",
+			defaultProject:  "openshift-kube-apiserver",
+			expectedProject: "openshift-kube-apiserver",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vars.DefaultProject = tc.defaultProject
+			defer func() { vars.DefaultProject = "" }()
+
+			ctx, err := Resolve(io.Discard, tc.path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			expectedPath, _ := filepath.Abs(strings.TrimSuffix(tc.path, "/"))
+			if ctx.Path != expectedPath {
+				t.Errorf("Expected path %s, got %s", expectedPath, ctx.Path)
+			}
+			if ctx.Project != tc.expectedProject {
+				t.Errorf("Expected project %s, got %s", tc.expectedProject, ctx.Project)
+			}
+		})
+	}
+
+	if _, err := Resolve(io.Discard, "testdata/useContext/missing"); err == nil {
+		t.Errorf("Expected an error for a missing must-gather")
+	}
+}
+
 func compareContexts(actualContext []types.Context, expectedContext []types.Context, ignoreContextID bool) bool {
 	if len(actualContext) != len(expectedContext) {
 		return false
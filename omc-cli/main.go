@@ -21,5 +21,5 @@ import (
 
 func main() {
 	//fmt.Println("inside main") // FLOW 3
-	root.RootCmd.Execute()
+	root.Execute()
 }
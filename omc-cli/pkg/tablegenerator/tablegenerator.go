@@ -21,8 +21,8 @@ import (
 )
 
 func CustomColumnsTable(unstruct *unstructured.Unstructured) (*metav1.Table, error) {
-	// Matches .metadata.name and metadata.name formats
-	format := regexp.MustCompile(`^\.?([^{}]+)$`)
+	// Matches .metadata.name, metadata.name, {.metadata.name} and {metadata.name} formats
+	format := regexp.MustCompile(`^\{\.?([^{}]+)\}$|^\.?([^{}]+)$`)
 	fieldSelectors := map[string]string{}
 	prefix := "custom-columns="
 	table := &metav1.Table{}
@@ -43,7 +43,18 @@ func CustomColumnsTable(unstruct *unstructured.Unstructured) (*metav1.Table, err
 	cells := make([]interface{}, 0)
 	for _, column := range table.ColumnDefinitions {
 		matches := format.FindStringSubmatch(fieldSelectors[column.Name])
-		cells = append(cells, helpers.GetFromJsonPath(unstruct.Object, fmt.Sprintf("%s%s%s", "{.", matches[1], "}")))
+		if matches == nil {
+			return nil, fmt.Errorf("unexpected path string %s, expected a 'name1.name2' or '.name1.name2' or '{name1.name2}' or '{.name1.name2}'", fieldSelectors[column.Name])
+		}
+		field := matches[1]
+		if field == "" {
+			field = matches[2]
+		}
+		v, err := helpers.GetFromJsonPath(unstruct.Object, fmt.Sprintf("%s%s%s", "{.", field, "}"))
+		if err != nil {
+			return nil, err
+		}
+		cells = append(cells, v)
 	}
 	table.Rows = []metav1.TableRow{{Cells: cells}}
 	return table, nil
@@ -176,13 +187,19 @@ func GenerateCustomResourceTable(unstruct unstructured.Unstructured) (*metav1.Ta
 						continue
 					}
 					if column.Name == "Since" {
-						v := helpers.GetFromJsonPath(unstruct.Object, fmt.Sprintf("%s%s%s", "{", column.JSONPath, "}"))
+						v, err := helpers.GetFromJsonPath(unstruct.Object, fmt.Sprintf("%s%s%s", "{", column.JSONPath, "}"))
+						if err != nil {
+							return nil, err
+						}
 						parsedTime, _ := time.Parse(time.RFC3339, v)
 						metav1Time := metav1.Time{Time: parsedTime}
 						v = helpers.TranslateTimestamp(metav1Time)
 						cells = append(cells, v)
 					} else {
-						v := helpers.GetFromJsonPath(unstruct.Object, fmt.Sprintf("%s%s%s", "{", column.JSONPath, "}"))
+						v, err := helpers.GetFromJsonPath(unstruct.Object, fmt.Sprintf("%s%s%s", "{", column.JSONPath, "}"))
+						if err != nil {
+							return nil, err
+						}
 						cells = append(cells, v)
 					}
 				}
@@ -16,15 +16,18 @@ limitations under the License.
 package root
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/gmeghnag/omc/cmd"
 	"github.com/gmeghnag/omc/cmd/certs"
 	"github.com/gmeghnag/omc/cmd/config"
 	"github.com/gmeghnag/omc/cmd/describe"
+	"github.com/gmeghnag/omc/cmd/diff"
 	"github.com/gmeghnag/omc/cmd/etcd"
 	"github.com/gmeghnag/omc/cmd/events"
 	"github.com/gmeghnag/omc/cmd/get"
@@ -36,12 +39,14 @@ import (
 	"github.com/gmeghnag/omc/cmd/ovn"
 	"github.com/gmeghnag/omc/cmd/insights"
 	"github.com/gmeghnag/omc/cmd/prometheus"
+	"github.com/gmeghnag/omc/cmd/timeline"
 	"github.com/gmeghnag/omc/cmd/upgrade"
 	"github.com/gmeghnag/omc/cmd/use"
 	"github.com/gmeghnag/omc/types"
 	"github.com/gmeghnag/omc/vars"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
 	goflags "flag"
@@ -49,12 +54,27 @@ import (
 	"k8s.io/klog/v2"
 )
 
+// contextOverride, when set, is used as the current context in place of the
+// one stored in the omc config file.
+var contextOverride *types.Context
+
+// configErr holds the error raised while loading the omc configuration, it is
+// reported before running any command but "use", which is able to fix it.
+var configErr error
+
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{ // FLOW 4
-	Use: "omc",
-	Run: func(cmd *cobra.Command, args []string) {
-		cmd.Help()
-		os.Exit(0)
+	Use:           "omc",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if cmd.Name() != "use" {
+			return configErr
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
 	},
 }
 
@@ -64,6 +84,63 @@ func Execute() {
 	cobra.CheckErr(RootCmd.Execute())
 }
 
+// ExecuteWithArgs runs omc with the given arguments, writing the command
+// output to out instead of the process stdout. The state left by a previous
+// execution (flags and the vars package) is cleared first, so it can be called
+// repeatedly from a long running process. It is not safe for concurrent use.
+func ExecuteWithArgs(args []string, out io.Writer) error {
+	return ExecuteWithContext(context.Background(), args, out)
+}
+
+// ExecuteWithContext runs omc like ExecuteWithArgs, the commands reading the
+// logs stop with the error of ctx once it is done.
+func ExecuteWithContext(ctx context.Context, args []string, out io.Writer) error {
+	vars.Reset()
+	configErr = nil
+	resetFlags(RootCmd)
+	RootCmd.SetArgs(args)
+	RootCmd.SetOut(out)
+	RootCmd.SetErr(out)
+	defer func() {
+		RootCmd.SetArgs(nil)
+		RootCmd.SetOut(nil)
+		RootCmd.SetErr(nil)
+	}()
+	return RootCmd.ExecuteContext(ctx)
+}
+
+// ExecuteInContext runs omc like ExecuteWithContext but against the given
+// must-gather context, the current context of the omc config file is ignored.
+func ExecuteInContext(ctx context.Context, mgContext types.Context, args []string, out io.Writer) error {
+	contextOverride = &mgContext
+	defer func() { contextOverride = nil }()
+	return ExecuteWithContext(ctx, args, out)
+}
+
+// resetFlags restores every flag changed by a previous execution of cmd or of
+// one of its subcommands to its default value. The context of the previous
+// execution is forgotten too, cobra only sets the one of a subcommand which has
+// none.
+func resetFlags(cmd *cobra.Command) {
+	cmd.SetContext(nil)
+	reset := func(f *pflag.Flag) {
+		if !f.Changed {
+			return
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			sv.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, c := range cmd.Commands() {
+		resetFlags(c)
+	}
+}
+
 func init() {
 	//fmt.Println("inside init") //FLOW 0
 	cobra.OnInitialize(initConfig)
@@ -101,6 +178,7 @@ func init() {
 		config.ConfigCmd,
 		get.GetCmd,
 		describe.DescribeCmd,
+		diff.DiffCmd,
 		etcd.Etcd,
 		logs.Logs,
 		machineconfig.MachineConfig,
@@ -109,6 +187,7 @@ func init() {
 		events.EventsCmd,
 		upgrade.Upgrade,
 		insights.InsightsCmd,
+		timeline.TimelineCmd,
 	)
 	loadOmcConfigs()
 }
@@ -116,22 +195,37 @@ func init() {
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
 	home, err := os.UserHomeDir()
-	cobra.CheckErr(err)
+	if err != nil {
+		configErr = err
+		return
+	}
 	exist, _ := helpers.Exists(home + "/.omc/omc.json")
 	if !exist {
 		if _, err := os.Stat(home + "/.omc"); errors.Is(err, os.ErrNotExist) {
 			err := os.Mkdir(home+"/.omc", os.ModePerm)
 			if err != nil {
-				cobra.CheckErr(err)
+				configErr = err
+				return
 			}
 		}
-		helpers.CreateConfigFile(home + "/.omc/omc.json")
+		if err := helpers.CreateConfigFile(home + "/.omc/omc.json"); err != nil {
+			configErr = err
+			return
+		}
 	}
 	if _, err := os.Stat(home + "/.omc/customresourcedefinitions"); errors.Is(err, os.ErrNotExist) {
 		err := os.Mkdir(home+"/.omc/customresourcedefinitions", os.ModePerm)
 		if err != nil {
-			cobra.CheckErr(err)
+			configErr = err
+			return
+		}
+	}
+	if contextOverride != nil {
+		vars.MustGatherRootPath = contextOverride.Path
+		if vars.Namespace == "" {
+			vars.Namespace = contextOverride.Project
 		}
+		return
 	}
 	// Search config in home directory with name ".omc" (without extension).
 	viper.AddConfigPath(home + "/.omc/")
@@ -158,9 +252,9 @@ func initConfig() {
 			if !exist {
 				files, err := os.ReadDir(vars.MustGatherRootPath)
 				if err != nil {
-					fmt.Println(err)
+					fmt.Fprintln(os.Stderr, err)
 					cmd.DeleteContext(vars.MustGatherRootPath, viper.ConfigFileUsed(), "")
-					fmt.Println("Cleaning", viper.ConfigFileUsed())
+					fmt.Fprintln(os.Stderr, "Cleaning", viper.ConfigFileUsed())
 				} else {
 					baseDir := ""
 					for _, f := range files {
@@ -170,9 +264,8 @@ func initConfig() {
 							break
 						}
 					}
-					if baseDir == "" && !helpers.StringInSlice("use", os.Args) {
-						fmt.Fprintln(os.Stderr, "wrong must-gather file composition for", vars.MustGatherRootPath)
-						os.Exit(1)
+					if baseDir == "" {
+						configErr = fmt.Errorf("wrong must-gather file composition for %s", vars.MustGatherRootPath)
 					}
 				}
 			}
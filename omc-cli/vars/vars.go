@@ -6,11 +6,12 @@ import (
 	"github.com/gmeghnag/omc/types"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/kubernetes/pkg/printers"
 )
 
-var CfgFile, Namespace, MustGatherRootPath, OutputStringVar, LabelSelectorStringVar, Id, Container, OMCVersionHash, OMCVersionTag, DiffCmd, CurrentKind, LastKind, DefaultProject, ForResource string
+var CfgFile, Namespace, MustGatherRootPath, OutputStringVar, LabelSelectorStringVar, FieldSelectorStringVar, SortByStringVar, Id, Container, OMCVersionHash, OMCVersionTag, DiffCmd, CurrentKind, LastKind, DefaultProject, ForResource string
 var AllNamespaceBoolVar, ShowLabelsBoolVar, Previous, Rotated, AllContainers, UseLocalCRDs, SingleResource, Wide, ShowKind, ShowNamespace, ShowManagedFields, NoHeaders, InsecureLogs bool
 
 var EventTypes []string
@@ -29,3 +30,23 @@ var JsonPathList types.JsonPathList
 var Output bytes.Buffer
 
 var Table metav1.Table
+
+// Reset clears the state collected while running a single command, so omc
+// can be executed more than once within the same process.
+func Reset() {
+	Namespace, MustGatherRootPath, Id, Container, ForResource = "", "", "", "", ""
+	OutputStringVar, LabelSelectorStringVar, FieldSelectorStringVar, SortByStringVar = "", "", "", ""
+	CurrentKind, LastKind = "", ""
+	AllNamespaceBoolVar, ShowLabelsBoolVar, ShowManagedFields, NoHeaders = false, false, false, false
+	Previous, Rotated, AllContainers, InsecureLogs = false, false, false, false
+	SingleResource, Wide, ShowKind, ShowNamespace = false, false, false, false
+	EventTypes = nil
+	GetArgs = make(map[string]map[string]struct{})
+	AliasToCrd = make(map[string]apiextensionsv1.CustomResourceDefinition)
+	ArgPresent = make(map[string]bool)
+	CRD = nil
+	UnstructuredList = types.UnstructuredList{Kind: "List", ApiVersion: "v1", Items: []unstructured.Unstructured{}}
+	JsonPathList = types.JsonPathList{Kind: "List", ApiVersion: "v1"}
+	Output.Reset()
+	Table = metav1.Table{}
+}
@@ -3,6 +3,11 @@ package main
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/mark3labs/mcp-go/server"
 	omcServer "github.com/shivprakashmuley/mustgather-mcp-server/mcp/server"
@@ -31,30 +36,103 @@ OpenShift must-gather Model Context Protocol (MCP) server
   mustgather-mcp-server --sse-port 8080
 
   # start a SSE server on port 8443 with a public HTTPS host of example.com
-  mustgather-mcp-server --sse-port 8443 --sse-base-url https://example.com:8443`,
+  mustgather-mcp-server --sse-port 8443 --sse-base-url https://example.com:8443
+
+  # start a Streamable HTTP server on port 8080, serving http://localhost:8080/mcp, without STDIO
+  mustgather-mcp-server --http-port 8080 --disable-stdio
+
+  # start a Streamable HTTP server over TLS, for the clients listed in auth.yaml
+  mustgather-mcp-server --http-port 8443 --disable-stdio --tls-cert tls.crt --tls-key tls.key --auth-config auth.yaml
+
+  # start STDIO server returning at most 64KiB per tool response
+  mustgather-mcp-server --max-response-bytes 65536`,
 
 	Run: func(cmd *cobra.Command, args []string) {
 		if viper.GetBool("version") {
 			fmt.Println(version.Version)
 			return
 		}
-		mcpServer, err := omcServer.NewSever()
+		configuration := omcServer.Configuration{
+			MaxResponseBytes:    viper.GetInt("max-response-bytes"),
+			MaxExtractBytes:     viper.GetInt64("max-extract-bytes"),
+			MaxExtractFiles:     viper.GetInt("max-extract-files"),
+			ExtractionRetention: viper.GetDuration("extraction-retention"),
+			TLSCertFile:         viper.GetString("tls-cert"),
+			TLSKeyFile:          viper.GetString("tls-key"),
+			TLSClientCAFile:     viper.GetString("tls-client-ca"),
+		}
+		if authConfig := viper.GetString("auth-config"); authConfig != "" {
+			auth, err := omcServer.LoadAuthConfiguration(authConfig)
+			if err != nil {
+				panic(err)
+			}
+			configuration.Auth = auth
+		}
+		mcpServer, err := omcServer.NewSever(configuration)
 		if err != nil {
 			panic(err)
 		}
+		defer mcpServer.Close()
 
+		ssePort, httpPort := viper.GetInt("sse-port"), viper.GetInt("http-port")
+		disableStdio := viper.GetBool("disable-stdio")
+		if disableStdio && ssePort <= 0 && httpPort <= 0 {
+			panic(errors.New("--disable-stdio requires --sse-port or --http-port"))
+		}
+
+		// the network servers run until stdio ends, or when it is disabled
+		// until the process is interrupted, the server fails when one of them
+		// can't listen or stops serving
+		errs := make(chan error, 2)
 		var sseServer *server.SSEServer
-		if ssePort := viper.GetInt("sse-port"); ssePort > 0 {
-			sseServer = mcpServer.ServeSse(viper.GetString("sse-base-url"))
-			if err := sseServer.Start(fmt.Sprintf(":%d", ssePort)); err != nil {
+		if ssePort > 0 {
+			var srv *http.Server
+			sseServer, srv, err = mcpServer.ServeSse(fmt.Sprintf(":%d", ssePort), viper.GetString("sse-base-url"))
+			if err != nil {
 				panic(err)
 			}
+			go func() {
+				errs <- omcServer.ListenAndServe(srv)
+			}()
 		}
-		if err := mcpServer.ServeStdio(); err != nil && !errors.Is(err, context.Canceled) {
-			panic(err)
+		var httpServer *server.StreamableHTTPServer
+		if httpPort > 0 {
+			var srv *http.Server
+			httpServer, srv, err = mcpServer.ServeHTTP(fmt.Sprintf(":%d", httpPort))
+			if err != nil {
+				panic(err)
+			}
+			go func() {
+				errs <- omcServer.ListenAndServe(srv)
+			}()
+		}
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		stdio := make(chan error, 1)
+		if !disableStdio {
+			go func() {
+				stdio <- mcpServer.ServeStdio()
+			}()
 		}
+		select {
+		case <-ctx.Done():
+		case err := <-stdio:
+			if err != nil && !errors.Is(err, context.Canceled) {
+				panic(err)
+			}
+		case err := <-errs:
+			if !errors.Is(err, http.ErrServerClosed) {
+				panic(err)
+			}
+		}
+		// the streams still open are closed after a grace period
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
 		if sseServer != nil {
-			_ = sseServer.Shutdown(cmd.Context())
+			_ = sseServer.Shutdown(ctx)
+		}
+		if httpServer != nil {
+			_ = httpServer.Shutdown(ctx)
 		}
 	},
 }
@@ -63,6 +141,16 @@ func init() {
 	rootCmd.Flags().BoolP("version", "v", false, "Print version information and quit")
 	rootCmd.Flags().IntP("sse-port", "", 0, "Start a SSE server on the specified port")
 	rootCmd.Flags().StringP("sse-base-url", "", "", "SSE public base URL to use when sending the endpoint message (e.g. https://example.com)")
+	rootCmd.Flags().IntP("http-port", "", 0, "Start a Streamable HTTP server on the specified port, serving the /mcp endpoint")
+	rootCmd.Flags().BoolP("disable-stdio", "", false, "Do not serve STDIO, requires --sse-port or --http-port")
+	rootCmd.Flags().StringP("tls-cert", "", "", "Certificate file serving the SSE and HTTP servers over TLS")
+	rootCmd.Flags().StringP("tls-key", "", "", "Private key file of --tls-cert")
+	rootCmd.Flags().StringP("tls-client-ca", "", "", "CA bundle verifying the client certificates (mTLS), requires --tls-cert")
+	rootCmd.Flags().StringP("auth-config", "", "", "YAML file listing the bearer tokens and client certificate names allowed on the SSE and HTTP servers, and their tools")
+	rootCmd.Flags().IntP("max-response-bytes", "", omcServer.DefaultMaxResponseBytes, "Maximum size in bytes of a tool response, larger outputs are paginated")
+	rootCmd.Flags().Int64P("max-extract-bytes", "", omcServer.DefaultMaxExtractBytes, "Maximum size in bytes of a must-gather downloaded by download_must_gather, once extracted")
+	rootCmd.Flags().IntP("max-extract-files", "", omcServer.DefaultMaxExtractFiles, "Maximum number of files of a must-gather downloaded by download_must_gather")
+	rootCmd.Flags().DurationP("extraction-retention", "", omcServer.DefaultExtractionRetention, "How long the must-gathers downloaded by download_must_gather are kept once no session uses them")
 	_ = viper.BindPFlags(rootCmd.Flags())
 }
 